@@ -0,0 +1,45 @@
+// Command backfill-binary-paths rebuilds the binary_tree_paths closure table from
+// binary_nodes. Run it once after applying migrations/create_binary_tree_paths_table.sql,
+// or any time binary_nodes was edited by hand:
+//
+//	go run ./cmd/backfill-binary-paths            # full rebuild
+//	go run ./cmd/backfill-binary-paths -if-empty  # only when the table is still empty
+//
+// It reads the same environment, CONFIG_FILE and <VAR>_FILE secrets as the API server.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"project/config"
+	"project/database"
+	"project/utils"
+)
+
+func main() {
+	ifEmpty := flag.Bool("if-empty", false, "only rebuild when binary_tree_paths has no rows")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	utils.SetConfig(cfg)
+
+	db, err := database.Connect(cfg.Database, false)
+	if err != nil {
+		log.Fatalf("failed to connect database: %v", err)
+	}
+
+	var written int
+	if *ifEmpty {
+		written, err = utils.EnsureBinaryTreePaths(db)
+	} else {
+		written, err = utils.RebuildBinaryTreePaths(db)
+	}
+	if err != nil {
+		log.Fatalf("binary tree path backfill failed: %v", err)
+	}
+	log.Printf("binary_tree_paths: %d paths written", written)
+}
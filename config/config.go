@@ -0,0 +1,526 @@
+// Package config loads the application configuration once at startup into a typed
+// struct of strings, numbers and comma-separated lists. Every value can come from, in
+// order of precedence:
+//
+//  1. the environment variable named in its env tag,
+//  2. a file named by <VAR>_FILE (Docker/Kubernetes secrets), trimmed,
+//  3. the YAML file named by CONFIG_FILE,
+//  4. the default tag.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Secret is a string that never prints its value.
+type Secret string
+
+// Value returns the secret itself.
+func (s Secret) Value() string { return string(s) }
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return "********"
+}
+
+// MarshalJSON keeps secrets out of JSON dumps.
+func (s Secret) MarshalJSON() ([]byte, error) { return []byte(`"` + s.String() + `"`), nil }
+
+type Config struct {
+	Env              string           `yaml:"env" env:"ENV"`
+	Port             string           `yaml:"port" env:"PORT" default:"8080"`
+	HTTP             HTTP             `yaml:"http"`
+	Database         Database         `yaml:"database"`
+	Redis            Redis            `yaml:"redis"`
+	JWT              JWT              `yaml:"jwt"`
+	AdminSession     AdminSession     `yaml:"admin_session"`
+	RateLimit        RateLimit        `yaml:"rate_limit"`
+	Abuse            Abuse            `yaml:"abuse"`
+	PIN              PIN              `yaml:"pin"`
+	LinkQu           LinkQu           `yaml:"linkqu"`
+	BankVerification BankVerification `yaml:"bank_verification"`
+	Withdrawal       Withdrawal       `yaml:"withdrawal"`
+	Payout           Payout           `yaml:"payout"`
+	Risk             Risk             `yaml:"risk"`
+	MultiAccount     MultiAccount     `yaml:"multi_account"`
+	APIClients       APIClients       `yaml:"api_clients"`
+	Cron             Cron             `yaml:"cron"`
+	S3               S3               `yaml:"s3"`
+	PaymentInfo      PaymentInfo      `yaml:"payment_info"`
+	Kyta             Kyta             `yaml:"kyta"`
+	OTP              OTP              `yaml:"otp"`
+	TOTP             TOTP             `yaml:"totp"`
+
+	sources map[string]string // env var -> where its value came from
+}
+
+// Development reports whether ENV is "development": .env is read, the schema is
+// auto-migrated and SQL is logged. Any other value, including none, is production.
+func (c *Config) Development() bool {
+	return strings.EqualFold(strings.TrimSpace(c.Env), "development")
+}
+
+// HTTP holds the settings of the global middleware chain. X-Forwarded-For is only
+// honoured from TrustedProxies (IPs or CIDRs, comma separated in the environment).
+type HTTP struct {
+	TrustedProxies     []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+	CORSAllowedOrigins string   `yaml:"cors_allowed_origins" env:"CORS_ALLOWED_ORIGINS" default:"*"`
+	HSTS               string   `yaml:"hsts" env:"SEC_HSTS" default:"false"`
+	CSP                string   `yaml:"csp" env:"SEC_CSP" default:"default-src 'none'; frame-ancestors 'none'; base-uri 'self';"`
+	RequestTimeoutSec  int      `yaml:"request_timeout_sec" env:"REQ_TIMEOUT_SEC" default:"10" min:"1"`
+	MaxBodyBytes       int64    `yaml:"max_body_bytes" env:"MAX_BODY_BYTES" default:"1048576" min:"1"`
+}
+
+// Database holds the MySQL connection settings. DSN, when set, replaces the
+// host/user/pass/name/params fields; Role "read" switches to ReadUser/ReadPass.
+type Database struct {
+	Host          string `yaml:"host" env:"DB_HOST"`
+	Port          string `yaml:"port" env:"DB_PORT" default:"3306"`
+	User          string `yaml:"user" env:"DB_USER"`
+	Pass          Secret `yaml:"pass" env:"DB_PASS"`
+	Name          string `yaml:"name" env:"DB_NAME"`
+	Params        string `yaml:"params" env:"DB_PARAMS" default:"charset=utf8mb4&parseTime=True&loc=Local"`
+	DSN           Secret `yaml:"dsn" env:"DB_DSN"`
+	Role          string `yaml:"role" env:"DB_ROLE" default:"write"`
+	ReadUser      string `yaml:"read_user" env:"DB_READ_USER"`
+	ReadPass      Secret `yaml:"read_pass" env:"DB_READ_PASS"`
+	TLS           string `yaml:"tls" env:"DB_TLS" default:"true"`
+	TLSVerify     string `yaml:"tls_verify" env:"DB_TLS_VERIFY" default:"false"`
+	TLSCAPath     string `yaml:"tls_ca_path" env:"DB_TLS_CA_PATH"`
+	TLSClientCert string `yaml:"tls_client_cert" env:"DB_TLS_CLIENT_CERT"`
+	TLSClientKey  string `yaml:"tls_client_key" env:"DB_TLS_CLIENT_KEY"`
+
+	MaxOpenConns       int    `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS" default:"25"`
+	MaxIdleConns       int    `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" default:"25"`
+	ConnMaxLifetimeSec int    `yaml:"conn_max_lifetime_sec" env:"DB_CONN_MAX_LIFETIME" default:"3600"`
+	ConnectRetries     int    `yaml:"connect_retries" env:"DB_CONNECT_RETRIES" default:"5" min:"1"`
+	PingOnConnect      string `yaml:"ping_on_connect" env:"DB_PING_ON_CONNECT" default:"true"`
+	BackupPath         string `yaml:"backup_path" env:"DB_BACKUP_PATH"`
+	BackupFlags        string `yaml:"backup_flags" env:"DB_BACKUP_FLAGS"`
+}
+
+// Redis is optional. Without Addr token revocation falls back to the database and
+// rate limits, bans and events stay in-process.
+type Redis struct {
+	Addr string `yaml:"addr" env:"REDIS_ADDR"`
+	Pass Secret `yaml:"pass" env:"REDIS_PASS"`
+	DB   int    `yaml:"db" env:"REDIS_DB" default:"0"`
+}
+
+// JWT holds the signing keys and the registered claims put into every token.
+type JWT struct {
+	Secret   Secret `yaml:"secret" env:"JWT_SECRET"`
+	KeysFile string `yaml:"keys_file" env:"JWT_KEYS_FILE"`
+	Audience string `yaml:"audience" env:"JWT_AUD"`
+	Issuer   string `yaml:"issuer" env:"JWT_ISS"`
+}
+
+// AdminSession holds the admin token lifetimes. Refreshing does not extend a session
+// past RefreshTTLHours.
+type AdminSession struct {
+	AccessTTLMinutes int `yaml:"access_ttl_minutes" env:"ADMIN_ACCESS_TTL_MINUTES" default:"15" min:"1"`
+	RefreshTTLHours  int `yaml:"refresh_ttl_hours" env:"ADMIN_REFRESH_TTL_HOURS" default:"12" min:"1"`
+}
+
+// RateLimit holds the policy file (built-in policies without it) and the limiter store.
+// Store "memory" keeps limits in-process even when Redis is configured.
+type RateLimit struct {
+	PolicyFile      string `yaml:"policy_file" env:"RATE_LIMIT_POLICY_FILE"`
+	PolicyReloadSec int    `yaml:"policy_reload_sec" env:"RATE_LIMIT_POLICY_RELOAD_SEC" default:"10" min:"1"`
+	Store           string `yaml:"store" env:"RATE_LIMIT_STORE"`
+	CleanupSec      int    `yaml:"cleanup_sec" env:"RATE_CLEANUP_SECONDS" default:"60" min:"1"`
+}
+
+// Abuse holds the per-signal ban thresholds and the automatic ban durations.
+type Abuse struct {
+	FailedLoginMax  int      `yaml:"failed_login_max" env:"ABUSE_FAILED_LOGIN_MAX" default:"20" min:"1"`
+	AuthErrorMax    int      `yaml:"auth_error_max" env:"ABUSE_AUTH_ERROR_MAX" default:"30" min:"1"`
+	NotFoundMax     int      `yaml:"not_found_max" env:"ABUSE_NOT_FOUND_MAX" default:"60" min:"1"`
+	ValidationMax   int      `yaml:"validation_max" env:"ABUSE_VALIDATION_MAX" default:"40" min:"1"`
+	CallbackAuthMax int      `yaml:"callback_auth_max" env:"ABUSE_CALLBACK_AUTH_MAX" default:"5" min:"1"`
+	BanMinutes      int      `yaml:"ban_minutes" env:"ABUSE_BAN_MINUTES" default:"15" min:"1"`
+	BanMaxMinutes   int      `yaml:"ban_max_minutes" env:"ABUSE_BAN_MAX_MINUTES" default:"1440" min:"1"`
+	BanSyncSec      int      `yaml:"ban_sync_sec" env:"ABUSE_BAN_SYNC_SEC" default:"30" min:"1"`
+	Whitelist       []string `yaml:"whitelist" env:"ABUSE_WHITELIST"`
+}
+
+// PIN holds the transaction PIN lockout.
+type PIN struct {
+	MaxAttempts int `yaml:"max_attempts" env:"PIN_MAX_ATTEMPTS" default:"5" min:"1"`
+	LockMinutes int `yaml:"lock_minutes" env:"PIN_LOCK_MINUTES" default:"30" min:"1"`
+}
+
+// LinkQu holds the payment (deposit) and payout gateway credentials.
+type LinkQu struct {
+	BaseURL         string `yaml:"base_url" env:"LINKQU_BASE_URL"`
+	Username        string `yaml:"username" env:"LINKQU_USERNAME"`
+	PIN             Secret `yaml:"pin" env:"LINKQU_PIN"`
+	ClientID        string `yaml:"client_id" env:"LINKQU_CLIENT_ID"`
+	ClientSecret    Secret `yaml:"client_secret" env:"LINKQU_CLIENT_SECRET"`
+	CallbackPayment string `yaml:"callback_payment" env:"LINKQU_CALLBACK_PAYMENT"`
+	CallbackPayout  string `yaml:"callback_payout" env:"LINKQU_CALLBACK_PAYOUT"`
+}
+
+// Enabled reports whether any LinkQu setting is present.
+func (l LinkQu) Enabled() bool {
+	return l.BaseURL != "" || l.Username != "" || l.PIN != "" || l.ClientID != "" || l.ClientSecret != ""
+}
+
+// BankVerification tunes the LinkQu holder-name inquiry made when a bank account is
+// added or edited.
+type BankVerification struct {
+	NameMatchThreshold float64 `yaml:"name_match_threshold" env:"BANK_NAME_MATCH_THRESHOLD" default:"0.8" min:"0.01" max:"1"`
+	InquiryAmount      float64 `yaml:"inquiry_amount" env:"BANK_VERIFY_INQUIRY_AMOUNT" default:"10000" min:"1"`
+}
+
+// Withdrawal holds the fee charged on every withdrawal.
+type Withdrawal struct {
+	ChargePercent float64 `yaml:"charge_percent" env:"WITHDRAWAL_CHARGE_PERCENT" default:"10" max:"100"`
+}
+
+// Payout tunes the worker that sends auto-approved withdrawals to LinkQu.
+type Payout struct {
+	WorkerIntervalSec int `yaml:"worker_interval_sec" env:"PAYOUT_WORKER_INTERVAL_SEC" default:"5" min:"1"`
+	MaxAttempts       int `yaml:"max_attempts" env:"PAYOUT_MAX_ATTEMPTS" default:"5" min:"1"`
+	BackoffBaseSec    int `yaml:"backoff_base_sec" env:"PAYOUT_BACKOFF_BASE_SEC" default:"30" min:"1"`
+}
+
+// Risk holds the withdrawal risk rule thresholds.
+type Risk struct {
+	MinAccountAgeHours      float64 `yaml:"min_account_age_hours" env:"RISK_MIN_ACCOUNT_AGE_HOURS" default:"72"`
+	MaxWithdrawDepositRatio float64 `yaml:"max_withdraw_deposit_ratio" env:"RISK_MAX_WITHDRAW_DEPOSIT_RATIO" default:"3" min:"0.01"`
+	BankChangeHours         float64 `yaml:"bank_change_hours" env:"RISK_BANK_CHANGE_HOURS" default:"24"`
+	HoldScore               int     `yaml:"hold_score" env:"RISK_HOLD_SCORE" default:"50" min:"1"`
+}
+
+// MultiAccount tunes the multi-account report. IPMaxUsers 0 disables the cap.
+type MultiAccount struct {
+	IPMaxUsers int `yaml:"ip_max_users" env:"MULTI_ACCOUNT_IP_MAX_USERS" default:"5"`
+}
+
+// APIClients holds the defaults for partner API clients.
+type APIClients struct {
+	KeyRotationOverlapHours int `yaml:"key_rotation_overlap_hours" env:"API_KEY_ROTATION_OVERLAP_HOURS" default:"24"`
+	DefaultRateLimit        int `yaml:"default_rate_limit" env:"RATE_API_CLIENT_DEFAULT" default:"60" min:"1"`
+}
+
+// Cron guards the cron endpoints (X-CRON-KEY). Without Key they answer 401.
+type Cron struct {
+	Key Secret `yaml:"key" env:"CRON_KEY"`
+}
+
+// S3 holds the object storage used for uploads.
+type S3 struct {
+	Region       string `yaml:"region" env:"S3_REGION" default:"ap-southeast-1"`
+	AccessKey    Secret `yaml:"access_key" env:"S3_ACCESS_KEY"`
+	SecretKey    Secret `yaml:"secret_key" env:"S3_SECRET_KEY"`
+	Bucket       string `yaml:"bucket" env:"S3_BUCKET"`
+	BucketServer string `yaml:"bucket_server" env:"S3_BUCKET_SERVER"`
+	BaseURL      string `yaml:"base_url" env:"S3_BASE_URL"`
+}
+
+// Enabled reports whether any S3 credential or bucket is present.
+func (s S3) Enabled() bool {
+	return s.AccessKey != "" || s.SecretKey != "" || s.Bucket != "" || s.BucketServer != ""
+}
+
+// PaymentInfo guards GET/PUT /api/payment_info, which expose the payment settings.
+type PaymentInfo struct {
+	Key Secret `yaml:"key" env:"PAYMENT_INFO_KEY"`
+}
+
+// Kyta guards the Kyta payout webhook, which can fail and refund withdrawals.
+type Kyta struct {
+	WebhookSecret Secret `yaml:"webhook_secret" env:"KYTA_WEBHOOK_SECRET"`
+}
+
+// OTP holds the one-time code hashing key and the delivery gateway. Without Secret
+// the JWT secret keys the code hashes.
+type OTP struct {
+	Secret       Secret `yaml:"secret" env:"OTP_SECRET"`
+	Sender       string `yaml:"sender" env:"OTP_SENDER"`
+	GatewayURL   string `yaml:"gateway_url" env:"OTP_GATEWAY_URL"`
+	GatewayToken Secret `yaml:"gateway_token" env:"OTP_GATEWAY_TOKEN"`
+
+	TTLMinutes        int `yaml:"ttl_minutes" env:"OTP_TTL_MINUTES" default:"5" min:"1"`
+	MaxAttempts       int `yaml:"max_attempts" env:"OTP_MAX_ATTEMPTS" default:"5" min:"1"`
+	ResendCooldownSec int `yaml:"resend_cooldown_sec" env:"OTP_RESEND_COOLDOWN_SEC" default:"60" min:"1"`
+
+	// RegisterPhoneVerification "false" lets users register without an OTP.
+	RegisterPhoneVerification string `yaml:"register_phone_verification" env:"REGISTER_PHONE_VERIFICATION" default:"true"`
+}
+
+// TOTP holds the authenticator app settings.
+type TOTP struct {
+	Issuer string `yaml:"issuer" env:"TOTP_ISSUER" default:"Stoneform"`
+}
+
+// Load applies the defaults, then CONFIG_FILE (if set), then the environment and
+// <VAR>_FILE secrets. A value that does not parse as its field's type fails.
+func Load() (*Config, error) {
+	cfg := &Config{sources: map[string]string{}}
+	err := cfg.each(func(key string, f reflect.StructField, fv reflect.Value) error {
+		def, ok := f.Tag.Lookup("default")
+		if !ok {
+			return nil
+		}
+		if err := setValue(fv, def); err != nil {
+			return fmt.Errorf("default of %s: %w", key, err)
+		}
+		cfg.sources[key] = "default"
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if path := strings.TrimSpace(os.Getenv("CONFIG_FILE")); path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read CONFIG_FILE: %w", err)
+		}
+		defaults := cfg.values()
+		if err := yaml.Unmarshal(raw, cfg); err != nil {
+			return nil, fmt.Errorf("parse CONFIG_FILE: %w", err)
+		}
+		for key, val := range cfg.values() {
+			if val != defaults[key] {
+				cfg.sources[key] = "config"
+			}
+		}
+	}
+
+	err = cfg.each(func(key string, _ reflect.StructField, fv reflect.Value) error {
+		val, src, err := lookup(key)
+		if err != nil || src == "" {
+			return err
+		}
+		if err := setValue(fv, val); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		cfg.sources[key] = src
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Lookup returns the environment variable key or, when it is unset, the trimmed
+// content of the file named by <key>_FILE. It is empty when neither is set. Settings
+// outside Config, such as the secret_env of a JWT key, use it to read secrets the
+// same way.
+func Lookup(key string) (string, error) {
+	val, _, err := lookup(key)
+	return val, err
+}
+
+func lookup(key string) (value, source string, err error) {
+	if val, ok := os.LookupEnv(key); ok && val != "" {
+		return val, "env", nil
+	}
+	if path := strings.TrimSpace(os.Getenv(key + "_FILE")); path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", fmt.Errorf("read %s_FILE: %w", key, err)
+		}
+		return strings.TrimSpace(string(raw)), "file", nil
+	}
+	return "", "", nil
+}
+
+// setValue parses raw into a string, integer, float or string list field. Lists are
+// comma separated.
+func setValue(fv reflect.Value, raw string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", raw)
+		}
+		fv.SetInt(n)
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		fv.SetFloat(n)
+	case reflect.Slice:
+		var list []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		fv.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
+
+func formatValue(fv reflect.Value) string {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10)
+	case reflect.Float64:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, 64)
+	case reflect.Slice:
+		return strings.Join(fv.Interface().([]string), ",")
+	}
+	return fv.String()
+}
+
+// each calls fn for every field with an env tag, in declaration order.
+func (c *Config) each(fn func(key string, f reflect.StructField, fv reflect.Value) error) error {
+	var walk func(v reflect.Value) error
+	walk = func(v reflect.Value) error {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f, fv := t.Field(i), v.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			if fv.Kind() == reflect.Struct {
+				if err := walk(fv); err != nil {
+					return err
+				}
+				continue
+			}
+			if key := f.Tag.Get("env"); key != "" {
+				if err := fn(key, f, fv); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return walk(reflect.ValueOf(c).Elem())
+}
+
+func (c *Config) values() map[string]string {
+	out := map[string]string{}
+	_ = c.each(func(key string, _ reflect.StructField, fv reflect.Value) error {
+		out[key] = formatValue(fv)
+		return nil
+	})
+	return out
+}
+
+// Validate checks the required settings of every feature in use. Database (or DB_DSN)
+// and JWT are always required; LinkQu and S3 only once any of their settings is present.
+func (c *Config) Validate() error {
+	var errs []error
+	missing := func(feature string, fields map[string]string) {
+		var keys []string
+		for key, val := range fields {
+			if val == "" {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) > 0 {
+			sort.Strings(keys)
+			errs = append(errs, fmt.Errorf("%s: missing %s", feature, strings.Join(keys, ", ")))
+		}
+	}
+
+	if c.Database.DSN == "" {
+		missing("database", map[string]string{
+			"DB_HOST": c.Database.Host, "DB_USER": c.Database.User, "DB_PASS": c.Database.Pass.Value(), "DB_NAME": c.Database.Name,
+		})
+	}
+	if c.JWT.KeysFile == "" && c.JWT.Secret == "" {
+		errs = append(errs, errors.New("jwt: missing JWT_SECRET or JWT_KEYS_FILE"))
+	}
+	if c.JWT.Secret == "supersecretjwtkey" {
+		errs = append(errs, errors.New("jwt: JWT_SECRET is still the example value"))
+	}
+	if c.Cron.Key == "supersecretcronkey" {
+		errs = append(errs, errors.New("cron: CRON_KEY is still the example value"))
+	}
+	if c.LinkQu.Enabled() {
+		missing("linkqu", map[string]string{
+			"LINKQU_BASE_URL": c.LinkQu.BaseURL, "LINKQU_USERNAME": c.LinkQu.Username, "LINKQU_PIN": c.LinkQu.PIN.Value(),
+			"LINKQU_CLIENT_ID": c.LinkQu.ClientID, "LINKQU_CLIENT_SECRET": c.LinkQu.ClientSecret.Value(),
+		})
+	}
+	if c.S3.Enabled() {
+		missing("s3", map[string]string{"S3_ACCESS_KEY": c.S3.AccessKey.Value(), "S3_SECRET_KEY": c.S3.SecretKey.Value()})
+		if c.S3.Bucket == "" && c.S3.BucketServer == "" {
+			errs = append(errs, errors.New("s3: missing S3_BUCKET or S3_BUCKET_SERVER"))
+		}
+	}
+	errs = append(errs, c.checkRanges()...)
+	return errors.Join(errs...)
+}
+
+// checkRanges checks loaded numeric settings against their min (default 0) and max
+// tags. Fields without a source were never loaded and are left alone.
+func (c *Config) checkRanges() []error {
+	var errs []error
+	_ = c.each(func(key string, f reflect.StructField, fv reflect.Value) error {
+		if c.sources[key] == "" {
+			return nil
+		}
+		var n float64
+		switch fv.Kind() {
+		case reflect.Int, reflect.Int64:
+			n = float64(fv.Int())
+		case reflect.Float64:
+			n = fv.Float()
+		default:
+			return nil
+		}
+		min := "0"
+		if tag := f.Tag.Get("min"); tag != "" {
+			min = tag
+		}
+		if lo, _ := strconv.ParseFloat(min, 64); n < lo {
+			errs = append(errs, fmt.Errorf("%s: must be at least %s, got %s", key, min, formatValue(fv)))
+		}
+		if max := f.Tag.Get("max"); max != "" {
+			if hi, _ := strconv.ParseFloat(max, 64); n > hi {
+				errs = append(errs, fmt.Errorf("%s: must be at most %s, got %s", key, max, formatValue(fv)))
+			}
+		}
+		return nil
+	})
+	return errs
+}
+
+// Summary lists every setting with secrets redacted and where each value came from,
+// plus which optional features are enabled.
+func (c *Config) Summary() []string {
+	lines := []string{
+		fmt.Sprintf("features: linkqu=%t s3=%t redis=%t payment_info=%t kyta_webhook=%t cron=%t",
+			c.LinkQu.Enabled(), c.S3.Enabled(), c.Redis.Addr != "", c.PaymentInfo.Key != "", c.Kyta.WebhookSecret != "", c.Cron.Key != ""),
+	}
+	secretType := reflect.TypeOf(Secret(""))
+	_ = c.each(func(key string, f reflect.StructField, fv reflect.Value) error {
+		src := c.sources[key]
+		if src == "" {
+			lines = append(lines, key+"=(unset)")
+			return nil
+		}
+		value := formatValue(fv)
+		if f.Type == secretType {
+			value = Secret(value).String()
+		}
+		lines = append(lines, fmt.Sprintf("%s=%s (%s)", key, value, src))
+		return nil
+	})
+	return lines
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "config.yaml", `
+port: "9000"
+database:
+  host: db.internal
+  user: app
+linkqu:
+  base_url: https://file.example
+  username: from-file
+`)
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("DB_HOST", "")
+	t.Setenv("DB_USER", "env-user")
+	t.Setenv("LINKQU_PIN_FILE", writeFile(t, "pin", "s3cret\n"))
+	t.Setenv("LINKQU_USERNAME", "")
+	t.Setenv("S3_REGION", "")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != "9000" || cfg.Database.Host != "db.internal" {
+		t.Errorf("config file values not applied: %+v", cfg)
+	}
+	if cfg.Database.User != "env-user" {
+		t.Errorf("env must override the config file, got %q", cfg.Database.User)
+	}
+	if cfg.LinkQu.PIN.Value() != "s3cret" {
+		t.Errorf("secret file not read, got %q", cfg.LinkQu.PIN.Value())
+	}
+	if cfg.S3.Region != "ap-southeast-1" || cfg.Database.Port != "3306" {
+		t.Errorf("defaults not applied: %+v %+v", cfg.S3, cfg.Database)
+	}
+
+	t.Setenv("LINKQU_PIN_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := Load(); err == nil {
+		t.Error("an unreadable secret file must fail")
+	}
+}
+
+func TestValidatePerFeature(t *testing.T) {
+	cfg := &Config{
+		Database: Database{Host: "h", User: "u", Pass: "p", Name: "n"},
+		JWT:      JWT{Secret: "x"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("optional features unset must validate, got %v", err)
+	}
+
+	cfg.LinkQu = LinkQu{BaseURL: "https://api.linkqu.id", ClientID: "id"}
+	cfg.S3 = S3{AccessKey: "a", SecretKey: "b"}
+	cfg.JWT = JWT{}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{
+		"jwt: missing JWT_SECRET or JWT_KEYS_FILE",
+		"linkqu: missing LINKQU_CLIENT_SECRET, LINKQU_PIN, LINKQU_USERNAME",
+		"s3: missing S3_BUCKET or S3_BUCKET_SERVER",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in %v", want, err)
+		}
+	}
+}
+
+func TestSummaryRedactsSecrets(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("DB_PASS", "hunter2")
+	t.Setenv("DB_NAME", "app")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := strings.Join(cfg.Summary(), "\n")
+	if strings.Contains(summary, "hunter2") {
+		t.Fatalf("secret leaked into summary:\n%s", summary)
+	}
+	for _, want := range []string{"DB_PASS=******** (env)", "DB_NAME=app (env)", "S3_REGION=ap-southeast-1 (default)"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary lacks %q:\n%s", want, summary)
+		}
+	}
+
+	b, _ := json.Marshal(cfg.Database)
+	if strings.Contains(string(b), "hunter2") {
+		t.Errorf("secret leaked into JSON: %s", b)
+	}
+}
+
+func TestValidateAcceptsDSN(t *testing.T) {
+	cfg := &Config{
+		Database: Database{DSN: "app:pw@tcp(db:3306)/app"},
+		JWT:      JWT{Secret: "s"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("DB_DSN should replace the individual database settings: %v", err)
+	}
+	cfg.JWT.Secret = "supersecretjwtkey"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "example value") {
+		t.Errorf("example JWT secret must be rejected, got %v", err)
+	}
+}
+
+func TestLoadTypedValues(t *testing.T) {
+	file := writeFile(t, "config.yaml", `
+http:
+  trusted_proxies: [10.0.0.0/8]
+multi_account:
+  ip_max_users: 0
+`)
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("PIN_MAX_ATTEMPTS", "3")
+	t.Setenv("BANK_NAME_MATCH_THRESHOLD", "0.9")
+	t.Setenv("ABUSE_WHITELIST", " 10.0.0.1, ,192.168.0.0/16")
+	t.Setenv("CRON_KEY_FILE", writeFile(t, "cron", "cron-secret\n"))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.PIN.MaxAttempts != 3 || cfg.PIN.LockMinutes != 30 || cfg.BankVerification.NameMatchThreshold != 0.9 {
+		t.Errorf("numbers not parsed: %+v %+v", cfg.PIN, cfg.BankVerification)
+	}
+	if strings.Join(cfg.Abuse.Whitelist, "|") != "10.0.0.1|192.168.0.0/16" {
+		t.Errorf("list not split: %q", cfg.Abuse.Whitelist)
+	}
+	if strings.Join(cfg.HTTP.TrustedProxies, "|") != "10.0.0.0/8" {
+		t.Errorf("list from config file not applied: %q", cfg.HTTP.TrustedProxies)
+	}
+	if cfg.MultiAccount.IPMaxUsers != 0 {
+		t.Errorf("an explicit 0 in the config file must replace the default, got %d", cfg.MultiAccount.IPMaxUsers)
+	}
+	if cfg.Cron.Key.Value() != "cron-secret" {
+		t.Errorf("CRON_KEY_FILE not read, got %q", cfg.Cron.Key.Value())
+	}
+	summary := strings.Join(cfg.Summary(), "\n")
+	for _, want := range []string{"CRON_KEY=******** (file)", "MULTI_ACCOUNT_IP_MAX_USERS=0 (config)", "PIN_LOCK_MINUTES=30 (default)"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary lacks %q:\n%s", want, summary)
+		}
+	}
+
+	t.Setenv("PIN_MAX_ATTEMPTS", "five")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "PIN_MAX_ATTEMPTS") {
+		t.Errorf("a non-numeric value must fail, got %v", err)
+	}
+}
+
+func TestValidateRanges(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("DB_DSN", "app:pw@tcp(db:3306)/app")
+	t.Setenv("JWT_SECRET", "s")
+	t.Setenv("PIN_LOCK_MINUTES", "0")
+	t.Setenv("BANK_NAME_MATCH_THRESHOLD", "1.5")
+	t.Setenv("CRON_KEY", "supersecretcronkey")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{
+		"PIN_LOCK_MINUTES: must be at least 1, got 0",
+		"BANK_NAME_MATCH_THRESHOLD: must be at most 1, got 1.5",
+		"cron: CRON_KEY is still the example value",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in %v", want, err)
+		}
+	}
+}
+
+func TestLookupReadsSecretFile(t *testing.T) {
+	t.Setenv("JWT_SECRET_B", "")
+	t.Setenv("JWT_SECRET_B_FILE", writeFile(t, "b", "from-file\n"))
+	if v, err := Lookup("JWT_SECRET_B"); err != nil || v != "from-file" {
+		t.Fatalf("got %q %v", v, err)
+	}
+	t.Setenv("JWT_SECRET_B", "from-env")
+	if v, _ := Lookup("JWT_SECRET_B"); v != "from-env" {
+		t.Fatalf("env must win over the file, got %q", v)
+	}
+}
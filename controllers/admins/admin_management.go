@@ -0,0 +1,226 @@
+package admins
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"project/database"
+	"project/models"
+	"project/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type AdminAccountResponse struct {
+	models.Admin
+	Permissions []string `json:"permissions"`
+}
+
+func toAdminAccountResponse(a models.Admin) AdminAccountResponse {
+	a.Role = utils.NormalizeAdminRole(a.Role)
+	return AdminAccountResponse{Admin: a, Permissions: utils.AdminPermissions(a.Role)}
+}
+
+type adminAccountRequest struct {
+	Username *string `json:"username"`
+	Password *string `json:"password"`
+	Name     *string `json:"name"`
+	Email    *string `json:"email"`
+	Role     *string `json:"role"`
+	IsActive *bool   `json:"is_active"`
+}
+
+// adminAuditSnapshot is the audited view of an admin account; the password is never included.
+func adminAuditSnapshot(a models.Admin) map[string]interface{} {
+	return map[string]interface{}{
+		"username":  a.Username,
+		"name":      a.Name,
+		"email":     a.Email,
+		"role":      utils.NormalizeAdminRole(a.Role),
+		"is_active": a.IsActive,
+	}
+}
+
+// countOtherActiveSuperadmins counts active superadmins other than adminID, including
+// admins still on the legacy "admin" role.
+func countOtherActiveSuperadmins(adminID int64) int64 {
+	var n int64
+	database.DB.Model(&models.Admin{}).
+		Where("id <> ? AND is_active = ? AND role IN ?", adminID, true, []string{utils.AdminRoleSuperadmin, "admin"}).
+		Count(&n)
+	return n
+}
+
+// GET /api/admin/admins
+func ListAdmins(w http.ResponseWriter, r *http.Request) {
+	var list []models.Admin
+	if err := database.DB.Order("id ASC").Find(&list).Error; err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal mengambil data admin"})
+		return
+	}
+	response := make([]AdminAccountResponse, 0, len(list))
+	for _, a := range list {
+		response = append(response, toAdminAccountResponse(a))
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Successfully", Data: response})
+}
+
+// GET /api/admin/roles
+func ListAdminRoles(w http.ResponseWriter, r *http.Request) {
+	roles := make([]map[string]interface{}, 0)
+	for _, role := range utils.AdminRoles() {
+		roles = append(roles, map[string]interface{}{
+			"role":        role,
+			"permissions": utils.AdminPermissions(role),
+		})
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Successfully", Data: roles})
+}
+
+// POST /api/admin/admins
+func CreateAdmin(w http.ResponseWriter, r *http.Request) {
+	var req adminAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Invalid request body"})
+		return
+	}
+	if req.Username == nil || req.Password == nil || req.Name == nil || req.Role == nil ||
+		strings.TrimSpace(*req.Username) == "" || strings.TrimSpace(*req.Name) == "" {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Username, password, nama dan role wajib diisi"})
+		return
+	}
+	if !utils.IsValidAdminRole(*req.Role) {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Role tidak valid"})
+		return
+	}
+	if len(*req.Password) < 8 {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Password minimal 8 karakter"})
+		return
+	}
+
+	admin := models.Admin{
+		Username: strings.TrimSpace(*req.Username),
+		Password: *req.Password,
+		Name:     strings.TrimSpace(*req.Name),
+		Role:     *req.Role,
+		IsActive: true,
+	}
+	if req.Email != nil {
+		admin.Email = strings.TrimSpace(*req.Email)
+	}
+	if err := admin.HashPassword(); err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal membuat admin"})
+		return
+	}
+	if err := database.DB.Create(&admin).Error; err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Gagal membuat admin, username atau email sudah digunakan"})
+		return
+	}
+	utils.RecordAdminAudit(r, utils.AuditAdminCreate, "admin", admin.ID, nil, adminAuditSnapshot(admin))
+
+	utils.WriteJSON(w, http.StatusCreated, utils.APIResponse{Success: true, Message: "Admin berhasil dibuat", Data: toAdminAccountResponse(admin)})
+}
+
+// PUT /api/admin/admins/{id}
+// Updates name, email, role, active flag or resets the password of another admin.
+func UpdateAdmin(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "ID admin tidak valid"})
+		return
+	}
+
+	var req adminAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Invalid request body"})
+		return
+	}
+
+	var admin models.Admin
+	if err := database.DB.First(&admin, id).Error; err != nil {
+		utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "Admin tidak ditemukan"})
+		return
+	}
+
+	callerID, _ := utils.GetAdminID(r)
+	isSelf := int64(callerID) == admin.ID
+	before := adminAuditSnapshot(admin)
+	wasSuperadmin := utils.NormalizeAdminRole(admin.Role) == utils.AdminRoleSuperadmin && admin.IsActive
+
+	updates := map[string]interface{}{}
+	if req.Name != nil && strings.TrimSpace(*req.Name) != "" {
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Email != nil {
+		updates["email"] = strings.TrimSpace(*req.Email)
+	}
+	if req.Role != nil && *req.Role != utils.NormalizeAdminRole(admin.Role) {
+		if !utils.IsValidAdminRole(*req.Role) {
+			utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Role tidak valid"})
+			return
+		}
+		if isSelf {
+			utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Tidak dapat mengubah role sendiri"})
+			return
+		}
+		updates["role"] = *req.Role
+	}
+	if req.IsActive != nil && *req.IsActive != admin.IsActive {
+		if isSelf {
+			utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Tidak dapat menonaktifkan akun sendiri"})
+			return
+		}
+		updates["is_active"] = *req.IsActive
+	}
+	if req.Password != nil {
+		if len(*req.Password) < 8 {
+			utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Password minimal 8 karakter"})
+			return
+		}
+		admin.Password = *req.Password
+		if err := admin.HashPassword(); err != nil {
+			utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal mengatur ulang password"})
+			return
+		}
+		updates["password"] = admin.Password
+	}
+
+	// Never leave the system without an active superadmin
+	_, demoted := updates["role"]
+	deactivated := req.IsActive != nil && !*req.IsActive
+	if wasSuperadmin && (demoted || deactivated) && countOtherActiveSuperadmins(admin.ID) == 0 {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Minimal harus ada satu superadmin aktif"})
+		return
+	}
+
+	if len(updates) > 0 {
+		if err := database.DB.Model(&admin).Updates(updates).Error; err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Gagal memperbarui admin"})
+			return
+		}
+		database.DB.First(&admin, id)
+	}
+	// a deactivated admin or a reset password ends every open session
+	if deactivated || req.Password != nil {
+		utils.RevokeAdminSessions(database.DB, "admin_id = ?", admin.ID)
+	}
+
+	// A role change is its own action so it can be filtered; a password reset is
+	// recorded separately and redacted.
+	if after := adminAuditSnapshot(admin); len(utils.AuditDiff(before, after)) > 0 {
+		action := utils.AuditAdminUpdate
+		if demoted {
+			action = utils.AuditAdminRole
+		}
+		utils.RecordAdminAudit(r, action, "admin", admin.ID, before, after)
+	}
+	if req.Password != nil {
+		utils.RecordAdminAudit(r, utils.AuditAdminPassword, "admin", admin.ID,
+			map[string]interface{}{"password": utils.AuditRedacted},
+			map[string]interface{}{"password": utils.AuditRedacted, "password_changed": true, "sessions_revoked": true})
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Admin berhasil diperbarui", Data: toAdminAccountResponse(admin)})
+}
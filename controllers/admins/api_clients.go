@@ -0,0 +1,320 @@
+package admins
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"project/database"
+	"project/models"
+	"project/utils"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+type APIClientResponse struct {
+	models.APIClient
+	Scopes     []string `json:"scopes"`
+	AllowedIPs []string `json:"allowed_ips"`
+}
+
+type apiClientRequest struct {
+	Name               *string  `json:"name"`
+	Scopes             []string `json:"scopes"`
+	AllowedIPs         []string `json:"allowed_ips"`
+	RateLimitPerMinute *int     `json:"rate_limit_per_minute"`
+	Active             *bool    `json:"active"`
+}
+
+func toAPIClientResponse(c models.APIClient) APIClientResponse {
+	scopes := c.ScopeList()
+	if scopes == nil {
+		scopes = []string{}
+	}
+	ips := c.AllowedIPList()
+	if ips == nil {
+		ips = []string{}
+	}
+	return APIClientResponse{APIClient: c, Scopes: scopes, AllowedIPs: ips}
+}
+
+// apiClientAuditSnapshot is the audited view of a client; keys are audited by prefix only.
+func apiClientAuditSnapshot(c models.APIClient) map[string]interface{} {
+	r := toAPIClientResponse(c)
+	return map[string]interface{}{
+		"name":                  c.Name,
+		"scopes":                r.Scopes,
+		"allowed_ips":           r.AllowedIPs,
+		"rate_limit_per_minute": c.RateLimitPerMinute,
+		"active":                c.Active,
+	}
+}
+
+// validateAPIClientRequest checks scopes and IP entries; returns an error message or "".
+func validateAPIClientRequest(req apiClientRequest) string {
+	for _, s := range req.Scopes {
+		if !utils.IsValidAPIScope(s) {
+			return "Scope tidak valid: " + s
+		}
+	}
+	for _, ip := range req.AllowedIPs {
+		ip = strings.TrimSpace(ip)
+		if _, _, err := net.ParseCIDR(ip); err != nil && net.ParseIP(ip) == nil {
+			return "IP/CIDR tidak valid: " + ip
+		}
+	}
+	if req.RateLimitPerMinute != nil && *req.RateLimitPerMinute < 1 {
+		return "Rate limit minimal 1 request per menit"
+	}
+	return ""
+}
+
+func joinAllowedIPs(ips []string) *string {
+	var cleaned []string
+	for _, ip := range ips {
+		if ip = strings.TrimSpace(ip); ip != "" {
+			cleaned = append(cleaned, ip)
+		}
+	}
+	if len(cleaned) == 0 {
+		return nil
+	}
+	s := strings.Join(cleaned, ",")
+	return &s
+}
+
+// createAPIClientKey issues a new key for the client and returns the plain key.
+func createAPIClientKey(tx *gorm.DB, clientID uint) (string, *models.APIClientKey, error) {
+	plain, prefix, hash, err := utils.GenerateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+	key := models.APIClientKey{ClientID: clientID, Prefix: prefix, KeyHash: hash}
+	if err := tx.Create(&key).Error; err != nil {
+		return "", nil, err
+	}
+	return plain, &key, nil
+}
+
+// GET /api/admin/api-clients
+func GetAPIClients(w http.ResponseWriter, r *http.Request) {
+	var clients []models.APIClient
+	if err := database.DB.Preload("Keys", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id DESC")
+	}).Order("id ASC").Find(&clients).Error; err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal mengambil data API client"})
+		return
+	}
+
+	response := make([]APIClientResponse, 0, len(clients))
+	for _, c := range clients {
+		response = append(response, toAPIClientResponse(c))
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Successfully", Data: response})
+}
+
+// POST /api/admin/api-clients
+// Creates a partner client; the API key is only returned in this response.
+func CreateAPIClient(w http.ResponseWriter, r *http.Request) {
+	var req apiClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Invalid request body"})
+		return
+	}
+	if req.Name == nil || strings.TrimSpace(*req.Name) == "" || len(req.Scopes) == 0 {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Nama dan scopes wajib diisi"})
+		return
+	}
+	if msg := validateAPIClientRequest(req); msg != "" {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: msg})
+		return
+	}
+
+	client := models.APIClient{
+		Name:               strings.TrimSpace(*req.Name),
+		Scopes:             strings.Join(req.Scopes, ","),
+		AllowedIPs:         joinAllowedIPs(req.AllowedIPs),
+		RateLimitPerMinute: 60,
+		Active:             true,
+	}
+	if req.RateLimitPerMinute != nil {
+		client.RateLimitPerMinute = *req.RateLimitPerMinute
+	}
+
+	var plain string
+	var key *models.APIClientKey
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&client).Error; err != nil {
+			return err
+		}
+		var err error
+		plain, key, err = createAPIClientKey(tx, client.ID)
+		return err
+	})
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal membuat API client"})
+		return
+	}
+
+	client.Keys = []models.APIClientKey{*key}
+	after := apiClientAuditSnapshot(client)
+	after["key_id"], after["key_prefix"] = key.ID, key.Prefix
+	utils.RecordAdminAudit(r, utils.AuditAPIClientCreate, "api_client", client.ID, nil, after)
+
+	utils.WriteJSON(w, http.StatusCreated, utils.APIResponse{
+		Success: true,
+		Message: "API client berhasil dibuat. Simpan API key ini, key tidak akan ditampilkan lagi",
+		Data: map[string]interface{}{
+			"client":  toAPIClientResponse(client),
+			"api_key": plain,
+		},
+	})
+}
+
+// PUT /api/admin/api-clients/{id}
+func UpdateAPIClient(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "ID API client tidak valid"})
+		return
+	}
+
+	var req apiClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Invalid request body"})
+		return
+	}
+	if msg := validateAPIClientRequest(req); msg != "" {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: msg})
+		return
+	}
+
+	var client models.APIClient
+	if err := database.DB.First(&client, id).Error; err != nil {
+		utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "API client tidak ditemukan"})
+		return
+	}
+
+	before := apiClientAuditSnapshot(client)
+	updates := map[string]interface{}{}
+	if req.Name != nil && strings.TrimSpace(*req.Name) != "" {
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Scopes != nil {
+		updates["scopes"] = strings.Join(req.Scopes, ",")
+	}
+	if req.AllowedIPs != nil {
+		updates["allowed_ips"] = joinAllowedIPs(req.AllowedIPs)
+	}
+	if req.RateLimitPerMinute != nil {
+		updates["rate_limit_per_minute"] = *req.RateLimitPerMinute
+	}
+	if req.Active != nil {
+		updates["active"] = *req.Active
+	}
+	if len(updates) > 0 {
+		if err := database.DB.Model(&client).Updates(updates).Error; err != nil {
+			utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal memperbarui API client"})
+			return
+		}
+	}
+	database.DB.Preload("Keys").First(&client, id)
+	if after := apiClientAuditSnapshot(client); len(utils.AuditDiff(before, after)) > 0 {
+		utils.RecordAdminAudit(r, utils.AuditAPIClientUpdate, "api_client", client.ID, before, after)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "API client berhasil diperbarui", Data: toAPIClientResponse(client)})
+}
+
+// POST /api/admin/api-clients/{id}/rotate
+// Issues a new key. Existing keys keep working for the overlap period
+// (overlap_hours, default API_KEY_ROTATION_OVERLAP_HOURS) so the partner can switch.
+func RotateAPIClientKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "ID API client tidak valid"})
+		return
+	}
+
+	var req struct {
+		OverlapHours *int `json:"overlap_hours"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	overlap := utils.APIKeyRotationOverlap()
+	if req.OverlapHours != nil && *req.OverlapHours >= 0 {
+		overlap = time.Duration(*req.OverlapHours) * time.Hour
+	}
+
+	var client models.APIClient
+	if err := database.DB.First(&client, id).Error; err != nil {
+		utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "API client tidak ditemukan"})
+		return
+	}
+
+	expiresAt := time.Now().Add(overlap)
+	var plain string
+	var key *models.APIClientKey
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Shorten, never extend, the lifetime of the current keys
+		if err := tx.Model(&models.APIClientKey{}).
+			Where("client_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", client.ID, expiresAt).
+			Update("expires_at", expiresAt).Error; err != nil {
+			return err
+		}
+		var err error
+		plain, key, err = createAPIClientKey(tx, client.ID)
+		return err
+	})
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal membuat API key baru"})
+		return
+	}
+	utils.RecordAdminAudit(r, utils.AuditAPIClientKeyRotate, "api_client", client.ID, nil, map[string]interface{}{
+		"key_id":              key.ID,
+		"key_prefix":          key.Prefix,
+		"previous_expires_at": expiresAt,
+	})
+
+	utils.WriteJSON(w, http.StatusCreated, utils.APIResponse{
+		Success: true,
+		Message: "API key baru dibuat. Key lama berlaku hingga " + expiresAt.Format(time.RFC3339),
+		Data: map[string]interface{}{
+			"key":                 key,
+			"api_key":             plain,
+			"previous_expires_at": expiresAt,
+		},
+	})
+}
+
+// DELETE /api/admin/api-clients/{id}/keys/{key_id}
+// Revokes a key immediately.
+func RevokeAPIClientKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clientID, err1 := strconv.ParseUint(vars["id"], 10, 32)
+	keyID, err2 := strconv.ParseUint(vars["key_id"], 10, 32)
+	if err1 != nil || err2 != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "ID tidak valid"})
+		return
+	}
+
+	res := database.DB.Model(&models.APIClientKey{}).
+		Where("id = ? AND client_id = ? AND revoked_at IS NULL", keyID, clientID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal mencabut API key"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "API key tidak ditemukan atau sudah dicabut"})
+		return
+	}
+	utils.RecordAdminAudit(r, utils.AuditAPIClientKeyRevoke, "api_client", clientID,
+		map[string]interface{}{"key_id": keyID, "revoked": false},
+		map[string]interface{}{"key_id": keyID, "revoked": true})
+
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "API key berhasil dicabut"})
+}
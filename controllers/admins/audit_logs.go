@@ -0,0 +1,152 @@
+package admins
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"project/database"
+	"project/models"
+	"project/utils"
+)
+
+type AuditLogResponse struct {
+	ID         uint            `json:"id"`
+	AdminID    *uint           `json:"admin_id"`
+	AdminName  string          `json:"admin_name"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	Diff       json.RawMessage `json:"diff"`
+	IP         string          `json:"ip"`
+	RequestID  string          `json:"request_id"`
+	Hash       string          `json:"hash"`
+	CreatedAt  string          `json:"created_at"`
+}
+
+func rawJSON(s *string) json.RawMessage {
+	if s == nil {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(*s)
+}
+
+// GET /api/admin/audit-logs
+// Filters: admin_id, action, target_type, target_id, start_date, end_date (YYYY-MM-DD, Asia/Jakarta)
+func GetAuditLogs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	db := database.DB
+	query := db.Model(&models.AuditLog{})
+	if v := q.Get("admin_id"); v != "" {
+		query = query.Where("admin_id = ?", v)
+	}
+	if v := q.Get("action"); v != "" {
+		query = query.Where("action = ?", v)
+	}
+	if v := q.Get("target_type"); v != "" {
+		query = query.Where("target_type = ?", v)
+	}
+	if v := q.Get("target_id"); v != "" {
+		query = query.Where("target_id = ?", v)
+	}
+
+	jakartaLoc, _ := time.LoadLocation("Asia/Jakarta")
+	if v := q.Get("start_date"); v != "" {
+		if start, err := time.ParseInLocation("2006-01-02", v, jakartaLoc); err == nil {
+			query = query.Where("created_at >= ?", start)
+		}
+	}
+	if v := q.Get("end_date"); v != "" {
+		if end, err := time.ParseInLocation("2006-01-02", v, jakartaLoc); err == nil {
+			query = query.Where("created_at < ?", end.AddDate(0, 0, 1))
+		}
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var logs []models.AuditLog
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&logs).Error; err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal mengambil audit log"})
+		return
+	}
+
+	// Fetch admin names in batch
+	adminIDs := make([]uint, 0)
+	for _, l := range logs {
+		if l.AdminID != nil {
+			adminIDs = append(adminIDs, *l.AdminID)
+		}
+	}
+	adminNames := make(map[uint]string)
+	if len(adminIDs) > 0 {
+		var list []models.Admin
+		db.Select("id, name").Where("id IN ?", adminIDs).Find(&list)
+		for _, a := range list {
+			adminNames[uint(a.ID)] = a.Name
+		}
+	}
+
+	response := make([]AuditLogResponse, 0, len(logs))
+	for _, l := range logs {
+		item := AuditLogResponse{
+			ID:         l.ID,
+			AdminID:    l.AdminID,
+			Action:     l.Action,
+			TargetType: l.TargetType,
+			TargetID:   l.TargetID,
+			Before:     rawJSON(l.Before),
+			After:      rawJSON(l.After),
+			Diff:       rawJSON(l.Diff),
+			IP:         l.IP,
+			RequestID:  l.RequestID,
+			Hash:       l.Hash,
+			CreatedAt:  l.CreatedAt.Format(time.RFC3339),
+		}
+		if l.AdminID != nil {
+			item.AdminName = adminNames[*l.AdminID]
+		}
+		response = append(response, item)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Successfully",
+		Data: map[string]interface{}{
+			"items": response,
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+// GET /api/admin/audit-logs/verify
+// Recomputes the hash chain and reports the first row that does not match.
+func VerifyAuditLogs(w http.ResponseWriter, r *http.Request) {
+	brokenAt, checked, err := utils.VerifyAuditChain(database.DB)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal memverifikasi audit log"})
+		return
+	}
+	data := map[string]interface{}{"valid": brokenAt == 0, "checked": checked}
+	message := "Audit log utuh"
+	if brokenAt != 0 {
+		data["broken_at"] = brokenAt
+		message = "Audit log telah diubah"
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: message, Data: data})
+}
@@ -1,23 +1,32 @@
 package admins
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
+	"time"
 
 	"project/database"
 	"project/models"
 	"project/utils"
+
+	"github.com/gorilla/mux"
 )
 
 type BankAccountResponse struct {
-	ID            uint   `json:"id"`
-	UserID        uint   `json:"user_id"`
-	UserName      string `json:"username"`
-	Phone         string `json:"phone"`
-	BankID        uint   `json:"bank_id"`
-	BankName      string `json:"bank_name"`
-	AccountName   string `json:"account_name"`
-	AccountNumber string `json:"account_number"`
+	ID             uint       `json:"id"`
+	UserID         uint       `json:"user_id"`
+	UserName       string     `json:"username"`
+	Phone          string     `json:"phone"`
+	BankID         uint       `json:"bank_id"`
+	BankName       string     `json:"bank_name"`
+	AccountName    string     `json:"account_name"`
+	AccountNumber  string     `json:"account_number"`
+	HolderName     *string    `json:"holder_name"`
+	Verified       bool       `json:"verified"`
+	VerifiedAt     *time.Time `json:"verified_at"`
+	NameMatchScore float64    `json:"name_match_score"`
+	ReviewStatus   string     `json:"review_status"`
 }
 
 func GetBankAccounts(w http.ResponseWriter, r *http.Request) {
@@ -27,6 +36,7 @@ func GetBankAccounts(w http.ResponseWriter, r *http.Request) {
 	userId := r.URL.Query().Get("userId")
 	bankId := r.URL.Query().Get("bankId")
 	search := r.URL.Query().Get("search")
+	reviewStatus := r.URL.Query().Get("review_status")
 
 	if page < 1 {
 		page = 1
@@ -50,6 +60,9 @@ func GetBankAccounts(w http.ResponseWriter, r *http.Request) {
 	if bankId != "" {
 		query = query.Where("bank_accounts.bank_id = ?", bankId)
 	}
+	if reviewStatus != "" {
+		query = query.Where("bank_accounts.review_status = ?", reviewStatus)
+	}
 	if search != "" {
 		like := "%" + search + "%"
 		query = query.Where("users.name LIKE ? OR users.number LIKE ? or bank_accounts.account_name LIKE ? or bank_accounts.account_number LIKE ?", like, like, like, like)
@@ -73,14 +86,19 @@ func GetBankAccounts(w http.ResponseWriter, r *http.Request) {
 	var response []BankAccountResponse
 	for _, ba := range bankAccounts {
 		response = append(response, BankAccountResponse{
-			ID:            ba.ID,
-			UserID:        ba.UserID,
-			UserName:      ba.UserName,
-			Phone:         ba.Phone,
-			BankID:        ba.BankID,
-			BankName:      ba.BankName,
-			AccountName:   ba.AccountName,
-			AccountNumber: ba.AccountNumber,
+			ID:             ba.ID,
+			UserID:         ba.UserID,
+			UserName:       ba.UserName,
+			Phone:          ba.Phone,
+			BankID:         ba.BankID,
+			BankName:       ba.BankName,
+			AccountName:    ba.AccountName,
+			AccountNumber:  ba.AccountNumber,
+			HolderName:     ba.HolderName,
+			Verified:       ba.Verified,
+			VerifiedAt:     ba.VerifiedAt,
+			NameMatchScore: ba.NameMatchScore,
+			ReviewStatus:   ba.ReviewStatus,
 		})
 	}
 
@@ -90,3 +108,59 @@ func GetBankAccounts(w http.ResponseWriter, r *http.Request) {
 		Data:    response,
 	})
 }
+
+// PUT /api/admin/bank-accounts/{id}/review
+// Resolves a holder-name mismatch flagged during bank account verification.
+func ReviewBankAccount(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "ID rekening tidak valid"})
+		return
+	}
+
+	var req struct {
+		Action string `json:"action"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.Action != "approve" && req.Action != "reject") {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Action harus approve atau reject"})
+		return
+	}
+
+	db := database.DB
+	var acc models.BankAccount
+	if err := db.First(&acc, id).Error; err != nil {
+		utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "Rekening tidak ditemukan"})
+		return
+	}
+	if acc.ReviewStatus != utils.BankReviewPending {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Rekening tidak sedang menunggu peninjauan"})
+		return
+	}
+
+	adminID, _ := utils.GetAdminID(r)
+	status := utils.BankReviewApproved
+	if req.Action == "reject" {
+		status = utils.BankReviewRejected
+	}
+	now := time.Now()
+	if err := db.Model(&acc).Updates(map[string]interface{}{
+		"review_status": status,
+		"reviewed_by":   adminID,
+		"reviewed_at":   now,
+	}).Error; err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal memperbarui rekening"})
+		return
+	}
+	utils.RecordAdminAudit(r, utils.AuditBankAccountReview, "bank_account", acc.ID,
+		map[string]interface{}{"review_status": utils.BankReviewPending, "name_match_score": acc.NameMatchScore},
+		map[string]interface{}{"review_status": status})
+
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Peninjauan rekening disimpan",
+		Data: map[string]interface{}{
+			"id":            acc.ID,
+			"review_status": status,
+		},
+	})
+}
@@ -258,6 +258,7 @@ func ClaimRewardAdminHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Reset omset dan progress
+	before := progress
 	now := time.Now()
 	progress.OmsetLeft = 0
 	progress.OmsetRight = 0
@@ -304,16 +305,16 @@ func ClaimRewardAdminHandler(w http.ResponseWriter, r *http.Request) {
 	// Reset binary structure: reset left_id dan right_id pada level 1 dari root
 	// Ini akan memutuskan koneksi level 1, sehingga level 2 juga tidak terhubung
 	var rootBinaryNode models.BinaryNode
+	var binaryReset map[string]interface{}
 	if err := db.Where("user_id = ?", req.UserID).First(&rootBinaryNode).Error; err == nil {
 		// Get left_id dan right_id sebelum di-reset (untuk logging atau info)
 		leftIDBefore := rootBinaryNode.LeftID
 		rightIDBefore := rootBinaryNode.RightID
 
-		// Reset left_id dan right_id menjadi NULL
-		if err := db.Model(&rootBinaryNode).UpdateColumns(map[string]interface{}{
-			"left_id":  nil,
-			"right_id": nil,
-		}).Error; err != nil {
+		// Reset left_id dan right_id menjadi NULL, sekaligus hapus path closure ke subtree yang terputus
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return utils.DetachBinaryChildren(tx, &rootBinaryNode)
+		}); err != nil {
 			utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Terjadi kesalahan saat reset binary structure"})
 			return
 		}
@@ -322,11 +323,13 @@ func ClaimRewardAdminHandler(w http.ResponseWriter, r *http.Request) {
 		// Tidak perlu menghapus binary node level 2, cukup reset koneksi level 1
 		// User level 1 dan 2 masih memiliki binary node mereka sendiri, tapi tidak terhubung ke root
 
-		// Optional: Log atau return info tentang user yang di-reset
-		_ = leftIDBefore
-		_ = rightIDBefore
+		binaryReset = map[string]interface{}{"left_id": leftIDBefore, "right_id": rightIDBefore}
 	}
 
+	utils.RecordAdminAudit(r, utils.AuditRewardClaim, "reward_progress", progress.ID,
+		map[string]interface{}{"progress": before, "binary": binaryReset},
+		map[string]interface{}{"progress": progress, "binary": map[string]interface{}{"left_id": nil, "right_id": nil}})
+
 	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
 		Success: true,
 		Message: "Reward berhasil di-claim, omset dan progress telah di-reset, binary structure level 1 telah di-reset",
@@ -334,34 +337,6 @@ func ClaimRewardAdminHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// countMembersAtLevel menghitung jumlah member sampai level tertentu
-func countMembersAtLevel(leftID, rightID *uint, db *gorm.DB, currentLevel, targetLevel int) int {
-	if currentLevel > targetLevel {
-		return 0
-	}
-
-	count := 0
-	if leftID != nil {
-		count++
-		if currentLevel < targetLevel {
-			var leftNode models.BinaryNode
-			if err := db.Where("user_id = ?", *leftID).First(&leftNode).Error; err == nil {
-				count += countMembersAtLevel(leftNode.LeftID, leftNode.RightID, db, currentLevel+1, targetLevel)
-			}
-		}
-	}
-	if rightID != nil {
-		count++
-		if currentLevel < targetLevel {
-			var rightNode models.BinaryNode
-			if err := db.Where("user_id = ?", *rightID).First(&rightNode).Error; err == nil {
-				count += countMembersAtLevel(rightNode.LeftID, rightNode.RightID, db, currentLevel+1, targetLevel)
-			}
-		}
-	}
-	return count
-}
-
 // GET /api/admin/binary/rewards
 // Melihat semua reward progress dari semua user
 func GetBinaryRewardsAdminHandler(w http.ResponseWriter, r *http.Request) {
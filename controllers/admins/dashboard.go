@@ -44,6 +44,7 @@ type DashboardStats struct {
 	OverviewInvestments []DailyInvestment   `json:"overview_investments"`
 	TotalWithdrawals    int64               `json:"total_withdrawals"`
 	PendingWithdrawals  int64               `json:"pending_withdrawals"`
+	HeldWithdrawals     int64               `json:"held_withdrawals"`
 	TotalBalance        float64             `json:"total_balance"`
 	TotalForums         int64               `json:"total_forums"`
 	PendingForums       int64               `json:"pending_forums"`
@@ -135,8 +136,11 @@ func GetDashboardStats(w http.ResponseWriter, r *http.Request) {
 
 	// Get pending withdrawals count
 	db.Model(&models.Withdrawal{}).
-		Where("status = ?", "Pending").
+		Where("status = ?", utils.WithdrawalRequested).
 		Count(&stats.PendingWithdrawals)
+	db.Model(&models.Withdrawal{}).
+		Where("status = ?", utils.WithdrawalHold).
+		Count(&stats.HeldWithdrawals)
 
 	// Get total balance of all users (balance + income)
 	type Result struct {
@@ -224,6 +224,10 @@ func ApproveDeposit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	deposit.Status = "Success"
+	utils.PublishDepositEvent(deposit)
+	utils.PublishBalanceEvent(deposit.UserID)
+
 	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
 		Success: true,
 		Message: "Deposit berhasil disetujui",
@@ -281,6 +285,9 @@ func RejectDeposit(w http.ResponseWriter, r *http.Request) {
 		db.Model(&models.Transaction{}).Where("id = ?", transaction.ID).Update("status", "Failed")
 	}
 
+	deposit.Status = "Failed"
+	utils.PublishDepositEvent(deposit)
+
 	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
 		Success: true,
 		Message: "Deposit berhasil ditolak",
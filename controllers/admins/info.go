@@ -37,7 +37,16 @@ type notificationsPayload struct {
 	NewUsers           *[]notificationItem `json:"new_users"`
 }
 
+type adminIdentity struct {
+	ID          int64    `json:"id"`
+	Username    string   `json:"username"`
+	Name        string   `json:"name"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
 type adminInfoResponse struct {
+	Admin         adminIdentity        `json:"admin"`
 	Servers       serverStatus         `json:"servers"`
 	Applications  applicationsStatus   `json:"applications"`
 	Notifications notificationsPayload `json:"notifications"`
@@ -47,6 +56,22 @@ type adminInfoResponse struct {
 func GetAdminInfo(w http.ResponseWriter, r *http.Request) {
 	db := database.DB
 
+	// Caller identity and permissions, used by the panel to show allowed menus
+	var identity adminIdentity
+	if adminID, ok := utils.GetAdminID(r); ok {
+		var admin models.Admin
+		if err := db.First(&admin, adminID).Error; err == nil {
+			role := utils.NormalizeAdminRole(admin.Role)
+			identity = adminIdentity{
+				ID:          admin.ID,
+				Username:    admin.Username,
+				Name:        admin.Name,
+				Role:        role,
+				Permissions: utils.AdminPermissions(role),
+			}
+		}
+	}
+
 	// Servers health
 	serverOK := true   // If this handler runs, server is up
 	dbOK := pingDB(db) // Check DB connectivity with timeout
@@ -54,7 +79,7 @@ func GetAdminInfo(w http.ResponseWriter, r *http.Request) {
 
 	// Applications: counts
 	var pendingWithdrawals int64
-	db.Model(&models.Withdrawal{}).Where("status = ?", "Pending").Count(&pendingWithdrawals)
+	db.Model(&models.Withdrawal{}).Where("status = ?", utils.WithdrawalRequested).Count(&pendingWithdrawals)
 
 	var pendingForums int64
 	db.Model(&models.Forum{}).Where("status = ?", "Pending").Count(&pendingForums)
@@ -105,6 +130,7 @@ func GetAdminInfo(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := adminInfoResponse{
+		Admin: identity,
 		Servers: serverStatus{
 			Status:   serverOK,
 			Database: dbOK,
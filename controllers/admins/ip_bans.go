@@ -0,0 +1,134 @@
+package admins
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"project/database"
+	"project/middleware"
+	"project/models"
+	"project/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// GET /api/admin/ip-bans
+// Filters: ip, source (auto|admin), status (active|all, default active); paginated with page/limit.
+func ListIPBans(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := database.DB.Model(&models.IPBan{})
+	if v := q.Get("ip"); v != "" {
+		query = query.Where("ip = ?", v)
+	}
+	if v := q.Get("source"); v != "" {
+		query = query.Where("source = ?", v)
+	}
+	if q.Get("status") != "all" {
+		query = query.Where("lifted_at IS NULL AND (permanent = ? OR expires_at > ?)", true, time.Now())
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var bans []models.IPBan
+	if err := query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&bans).Error; err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal mengambil data blokir IP"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Successfully",
+		Data: map[string]interface{}{
+			"items": bans,
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+type ipBanRequest struct {
+	IP              string `json:"ip"`
+	Reason          string `json:"reason"`
+	DurationMinutes int    `json:"duration_minutes"` // 0 or omitted bans permanently
+}
+
+// POST /api/admin/ip-bans
+func CreateIPBan(w http.ResponseWriter, r *http.Request) {
+	var req ipBanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Invalid request body"})
+		return
+	}
+	ip := net.ParseIP(strings.TrimSpace(req.IP))
+	if ip == nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Alamat IP tidak valid"})
+		return
+	}
+	if req.DurationMinutes < 0 {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Durasi tidak valid"})
+		return
+	}
+	if ip.String() == utils.GetClientIP(r) {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Tidak dapat memblokir alamat IP anda sendiri"})
+		return
+	}
+
+	adminID, _ := utils.GetAdminID(r)
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) > 255 {
+		reason = reason[:255]
+	}
+	ban, err := middleware.BanIP(database.DB, ip.String(), reason, "", middleware.IPBanSourceAdmin,
+		time.Duration(req.DurationMinutes)*time.Minute, &adminID)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal memblokir alamat IP"})
+		return
+	}
+	utils.RecordAdminAudit(r, utils.AuditIPBan, "ip_ban", ban.ID, nil, ban)
+
+	utils.WriteJSON(w, http.StatusCreated, utils.APIResponse{Success: true, Message: "Alamat IP berhasil diblokir", Data: ban})
+}
+
+// DELETE /api/admin/ip-bans/{id}
+// Lifts the ban and any other active ban on the same IP.
+func DeleteIPBan(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "ID blokir tidak valid"})
+		return
+	}
+	var ban models.IPBan
+	if err := database.DB.First(&ban, id).Error; err != nil {
+		utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "Data blokir tidak ditemukan"})
+		return
+	}
+	if ban.LiftedAt != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Blokir sudah dicabut"})
+		return
+	}
+
+	before := ban
+	adminID, _ := utils.GetAdminID(r)
+	if err := middleware.LiftIPBan(database.DB, &ban, adminID); err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal mencabut blokir"})
+		return
+	}
+	utils.RecordAdminAudit(r, utils.AuditIPUnban, "ip_ban", ban.ID, before, ban)
+
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Blokir alamat IP berhasil dicabut", Data: ban})
+}
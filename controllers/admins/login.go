@@ -3,8 +3,11 @@ package admins
 import (
 	"encoding/json"
 	"net/http"
+	"project/database"
+	"project/middleware"
 	"project/models"
 	"project/utils"
+	"time"
 )
 
 type LoginRequest struct {
@@ -12,6 +15,8 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+// Login checks username/password and starts the mandatory second step. It returns an
+// MFA challenge token; admins without an enrolment must set up TOTP with it first.
 func Login(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -25,6 +30,7 @@ func Login(w http.ResponseWriter, r *http.Request) {
 	// Get admin by username
 	admin, err := models.GetAdminByUsername(req.Username)
 	if err != nil {
+		middleware.ReportAbuse(r, middleware.AbuseSignalFailedLogin)
 		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{
 			Success: false,
 			Message: "Username atau password salah",
@@ -34,6 +40,7 @@ func Login(w http.ResponseWriter, r *http.Request) {
 
 	// Validate password
 	if !admin.ValidatePassword(req.Password) {
+		middleware.ReportAbuse(r, middleware.AbuseSignalFailedLogin)
 		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{
 			Success: false,
 			Message: "Username atau password salah",
@@ -41,8 +48,22 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateJWT(admin.ID, admin.Username, "admin")
+	enabled, err := utils.TwoFactorEnabled(database.DB, utils.TwoFactorOwnerAdmin, uint(admin.ID))
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
+			Success: false,
+			Message: "Terjadi kesalahan sistem, silakan coba lagi",
+		})
+		return
+	}
+	stage := utils.MFAStageVerify
+	message := "Masukkan kode dari aplikasi autentikator"
+	if !enabled {
+		stage = utils.MFAStageEnroll
+		message = "Aktifkan autentikasi dua faktor untuk melanjutkan"
+	}
+
+	mfaToken, err := utils.GenerateMFAChallengeToken(utils.TwoFactorOwnerAdmin, uint(admin.ID), stage)
 	if err != nil {
 		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
 			Success: false,
@@ -51,15 +72,43 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create successful response
-	response := utils.APIResponse{
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
 		Success: true,
-		Message: "Berhasil login",
+		Message: message,
 		Data: map[string]interface{}{
-			"token": token,
-			"admin": admin,
+			"mfa_required":        true,
+			"mfa_token":           mfaToken,
+			"enrollment_required": !enabled,
 		},
+	})
+}
+
+// writeAdminLoginSuccess starts an admin session once both login steps passed and
+// returns its access and refresh tokens.
+func writeAdminLoginSuccess(w http.ResponseWriter, r *http.Request, admin *models.Admin, extra map[string]interface{}) {
+	tokens, err := utils.CreateAdminSession(database.DB, admin, r)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
+			Success: false,
+			Message: "Gagal membuat token",
+		})
+		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, response)
+	data := map[string]interface{}{
+		"token":         tokens.AccessToken,
+		"access_expire": tokens.AccessExpire.UTC().Format(time.RFC3339),
+		"refresh_token": tokens.RefreshToken,
+		"admin":         admin,
+		"permissions":   utils.AdminPermissions(admin.Role),
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Berhasil login",
+		Data:    data,
+	})
 }
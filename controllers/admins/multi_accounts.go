@@ -0,0 +1,70 @@
+package admins
+
+import (
+	"net/http"
+	"strconv"
+
+	"project/database"
+	"project/utils"
+)
+
+// GET /api/admin/multi-accounts
+// Clusters of accounts sharing a device, IP, bank account or referral chain.
+// Query: days (device/IP lookback, default 30, max 365), min_size (default 2),
+// referrals_only (default true: only clusters where members referred each other);
+// paginated with page/limit.
+func GetMultiAccountClusters(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	days, _ := strconv.Atoi(q.Get("days"))
+	if days < 1 || days > 365 {
+		days = 30
+	}
+	minSize, _ := strconv.Atoi(q.Get("min_size"))
+	if minSize < 2 {
+		minSize = 2
+	}
+	referralsOnly := q.Get("referrals_only") != "false"
+
+	clusters, err := utils.DetectMultiAccounts(database.DB, utils.NewMultiAccountConfig(days))
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal menganalisis akun ganda"})
+		return
+	}
+
+	filtered := make([]utils.MultiAccountCluster, 0, len(clusters))
+	for _, c := range clusters {
+		if c.Size < minSize || (referralsOnly && c.InternalReferrals == 0) {
+			continue
+		}
+		filtered = append(filtered, c)
+	}
+
+	total := len(filtered)
+	start := (page - 1) * limit
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Successfully",
+		Data: map[string]interface{}{
+			"items": filtered[start:end],
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
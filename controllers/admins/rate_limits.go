@@ -0,0 +1,36 @@
+package admins
+
+import (
+	"net/http"
+	"strconv"
+
+	"project/middleware"
+	"project/utils"
+)
+
+// GET /api/admin/rate-limits
+// Returns the active rate-limit policies and today's top offenders (?limit=, default 20).
+func GetRateLimits(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offenders, err := middleware.TopRateLimitOffenders(r.Context(), limit)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal mengambil data rate limit"})
+		return
+	}
+
+	set := middleware.CurrentRateLimitPolicies()
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Successfully",
+		Data: map[string]interface{}{
+			"source":        set.Source,
+			"loaded_at":     set.LoadedAt,
+			"policies":      set.Policies,
+			"top_offenders": offenders,
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package admins
+
+import (
+	"net/http"
+	"strconv"
+
+	"project/database"
+	"project/models"
+	"project/utils"
+)
+
+// GET /api/admin/security-events
+// Filters: type, user_id; paginated with page/limit.
+func GetSecurityEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := database.DB.Model(&models.SecurityEvent{})
+	if v := q.Get("type"); v != "" {
+		query = query.Where("type = ?", v)
+	}
+	if v := q.Get("user_id"); v != "" {
+		query = query.Where("user_id = ?", v)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var events []models.SecurityEvent
+	if err := query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&events).Error; err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal mengambil data security event"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Successfully",
+		Data: map[string]interface{}{
+			"items": events,
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
@@ -0,0 +1,151 @@
+package admins
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"project/database"
+	"project/models"
+	"project/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type adminRefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type AdminSessionResponse struct {
+	models.AdminSession
+	AdminName string `json:"admin_name"`
+	Username  string `json:"username"`
+	Current   bool   `json:"current"`
+}
+
+// POST /api/admin/refresh
+// Exchanges a refresh token for a new access token and a rotated refresh token.
+func RefreshAdminToken(w http.ResponseWriter, r *http.Request) {
+	var req adminRefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "refresh_token is required"})
+		return
+	}
+
+	tokens, err := utils.RotateAdminSession(database.DB, req.RefreshToken, r)
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidAdminSession) {
+			utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: err.Error()})
+			return
+		}
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Terjadi kesalahan sistem, silakan coba lagi"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Successfully",
+		Data: map[string]interface{}{
+			"token":         tokens.AccessToken,
+			"access_expire": tokens.AccessExpire.UTC().Format(time.RFC3339),
+			"refresh_token": tokens.RefreshToken,
+		},
+	})
+}
+
+// POST /api/admin/logout
+// Ends the session of the access token used for the request.
+func AdminLogout(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := utils.GetAdminID(r)
+	sid, ok := utils.GetAdminSessionID(r)
+	if !ok {
+		// legacy token without a session: nothing to revoke server side
+		utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Logged out"})
+		return
+	}
+	if _, err := utils.RevokeAdminSessions(database.DB, "id = ? AND admin_id = ?", sid, adminID); err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Terjadi kesalahan sistem, silakan coba lagi"})
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Logged out"})
+}
+
+// POST /api/admin/logout-all
+// Ends every session of the calling admin, including the current one.
+func AdminLogoutAll(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := utils.GetAdminID(r)
+	n, err := utils.RevokeAdminSessions(database.DB, "admin_id = ?", adminID)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Terjadi kesalahan sistem, silakan coba lagi"})
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "All sessions revoked", Data: map[string]interface{}{"revoked": n}})
+}
+
+// GET /api/admin/sessions
+// Lists active admin sessions; filter with admin_id.
+func ListAdminSessions(w http.ResponseWriter, r *http.Request) {
+	query := database.DB.Model(&models.AdminSession{}).
+		Where("revoked_at IS NULL AND expires_at > ?", time.Now())
+	if v := r.URL.Query().Get("admin_id"); v != "" {
+		query = query.Where("admin_id = ?", v)
+	}
+
+	var sessions []models.AdminSession
+	if err := query.Order("last_used_at DESC").Find(&sessions).Error; err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal mengambil data sesi"})
+		return
+	}
+
+	adminIDs := make([]uint, 0, len(sessions))
+	for _, s := range sessions {
+		adminIDs = append(adminIDs, s.AdminID)
+	}
+	adminsByID := make(map[uint]models.Admin)
+	if len(adminIDs) > 0 {
+		var list []models.Admin
+		database.DB.Select("id, name, username").Where("id IN ?", adminIDs).Find(&list)
+		for _, a := range list {
+			adminsByID[uint(a.ID)] = a
+		}
+	}
+
+	currentSID, _ := utils.GetAdminSessionID(r)
+	response := make([]AdminSessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		response = append(response, AdminSessionResponse{
+			AdminSession: s,
+			AdminName:    adminsByID[s.AdminID].Name,
+			Username:     adminsByID[s.AdminID].Username,
+			Current:      s.ID == currentSID,
+		})
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Successfully", Data: response})
+}
+
+// DELETE /api/admin/sessions/{id}
+func RevokeAdminSession(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "ID sesi tidak valid"})
+		return
+	}
+	var session models.AdminSession
+	if err := database.DB.First(&session, id).Error; err != nil {
+		utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "Sesi tidak ditemukan"})
+		return
+	}
+	n, err := utils.RevokeAdminSessions(database.DB, "id = ?", session.ID)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Terjadi kesalahan sistem, silakan coba lagi"})
+		return
+	}
+	if n > 0 {
+		utils.RecordAdminAudit(r, utils.AuditAdminSessionRevoke, "admin_session", session.ID,
+			map[string]interface{}{"admin_id": session.AdminID, "ip": session.IP, "revoked": false},
+			map[string]interface{}{"admin_id": session.AdminID, "ip": session.IP, "revoked": true})
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Sesi berhasil dicabut"})
+}
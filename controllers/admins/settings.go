@@ -76,6 +76,7 @@ func UpdateSettingsHandler(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	before := setting
 
 	// Update text fields
 	if name := strings.TrimSpace(r.FormValue("name")); name != "" {
@@ -214,6 +215,7 @@ func UpdateSettingsHandler(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	utils.RecordAdminAudit(r, utils.AuditSettingsUpdate, "setting", setting.ID, before, setting)
 
 	// Transform to response format
 	response := map[string]interface{}{
@@ -138,6 +138,7 @@ func UpdateSpinPrize(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update prize details
+	before := prize
 	if err := database.DB.Model(&prize).Updates(map[string]interface{}{
 		"amount":        req.Amount,
 		"code":          req.Code,
@@ -151,6 +152,10 @@ func UpdateSpinPrize(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	after := before
+	after.Amount, after.Code, after.ChanceWeight, after.Status = req.Amount, req.Code, req.ChanceWeight, req.Status
+	utils.RecordAdminAudit(r, utils.AuditSpinPrizeUpdate, "spin_prize", prize.ID, before, after)
+
 	// Get all prizes to calculate new chances
 	var allPrizes []models.SpinPrize
 	if err := database.DB.Find(&allPrizes).Error; err != nil {
@@ -0,0 +1,161 @@
+package admins
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"project/database"
+	"project/middleware"
+	"project/models"
+	"project/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type mfaLoginRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+// adminFromChallenge validates the challenge token and loads the (active) admin.
+func adminFromChallenge(w http.ResponseWriter, token string) (*utils.MFAChallenge, *models.Admin, bool) {
+	challenge, err := utils.ParseMFAChallengeToken(token, utils.TwoFactorOwnerAdmin)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: err.Error()})
+		return nil, nil, false
+	}
+	var admin models.Admin
+	if err := database.DB.Where("id = ? AND is_active = ?", challenge.ID, true).First(&admin).Error; err != nil {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: utils.ErrInvalidMFAToken.Error()})
+		return nil, nil, false
+	}
+	return challenge, &admin, true
+}
+
+func writeTwoFactorError(w http.ResponseWriter, err error) {
+	status, message := utils.TwoFactorErrorStatus(err)
+	utils.WriteJSON(w, status, utils.APIResponse{Success: false, Message: message})
+}
+
+// POST /api/admin/login/2fa/setup
+// Creates the TOTP secret of an admin that logged in without an enrolment.
+func AdminTwoFactorSetup(w http.ResponseWriter, r *http.Request) {
+	var req mfaLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Invalid JSON body"})
+		return
+	}
+	challenge, admin, ok := adminFromChallenge(w, req.MFAToken)
+	if !ok {
+		return
+	}
+	if challenge.Stage != utils.MFAStageEnroll {
+		writeTwoFactorError(w, utils.ErrTwoFactorAlreadyEnabled)
+		return
+	}
+
+	tf, err := utils.BeginTwoFactorSetup(database.DB, utils.TwoFactorOwnerAdmin, uint(admin.ID))
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Pindai kode QR lalu masukkan kode dari aplikasi autentikator",
+		Data: map[string]interface{}{
+			"secret":      tf.Secret,
+			"otpauth_uri": utils.TOTPProvisioningURI(utils.TOTPIssuer()+" Admin", admin.Username, tf.Secret),
+		},
+	})
+}
+
+// POST /api/admin/login/2fa
+// Second login step: verifies the TOTP (or recovery) code and issues the admin token.
+// For a first enrolment the code confirms the secret and recovery codes are returned.
+func AdminTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	var req mfaLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Invalid JSON body"})
+		return
+	}
+	challenge, admin, ok := adminFromChallenge(w, req.MFAToken)
+	if !ok {
+		return
+	}
+
+	extra := map[string]interface{}{}
+	if challenge.Stage == utils.MFAStageEnroll {
+		codes, err := utils.ConfirmTwoFactor(database.DB, utils.TwoFactorOwnerAdmin, uint(admin.ID), req.Code)
+		if err != nil {
+			writeTwoFactorError(w, err)
+			return
+		}
+		extra["recovery_codes"] = codes
+	} else if err := utils.VerifyTwoFactor(database.DB, utils.TwoFactorOwnerAdmin, uint(admin.ID), req.Code); err != nil {
+		middleware.ReportAbuse(r, middleware.AbuseSignalFailedLogin)
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	utils.ConsumeMFAChallenge(challenge)
+	writeAdminLoginSuccess(w, r, admin, extra)
+}
+
+// POST /api/admin/2fa/recovery-codes
+// Replaces the caller's recovery codes; requires a current TOTP code.
+func RegenerateAdminRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Invalid JSON body"})
+		return
+	}
+	adminID, _ := utils.GetAdminID(r)
+	if err := utils.VerifyTwoFactor(database.DB, utils.TwoFactorOwnerAdmin, adminID, req.Code); err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+	codes, err := utils.RegenerateRecoveryCodes(database.DB, utils.TwoFactorOwnerAdmin, adminID)
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Kode pemulihan berhasil dibuat ulang",
+		Data:    map[string]interface{}{"recovery_codes": codes},
+	})
+}
+
+// DELETE /api/admin/admins/{id}/2fa
+// Resets another admin's enrolment (lost device); they enrol again at next login.
+func ResetAdminTwoFactor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "ID admin tidak valid"})
+		return
+	}
+	callerID, _ := utils.GetAdminID(r)
+	if uint(id) == callerID {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Tidak dapat mereset 2FA akun sendiri"})
+		return
+	}
+	var admin models.Admin
+	if err := database.DB.First(&admin, id).Error; err != nil {
+		utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "Admin tidak ditemukan"})
+		return
+	}
+	enabled, _ := utils.TwoFactorEnabled(database.DB, utils.TwoFactorOwnerAdmin, uint(admin.ID))
+	if err := utils.DisableTwoFactor(database.DB, utils.TwoFactorOwnerAdmin, uint(admin.ID)); err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+	utils.RevokeAdminSessions(database.DB, "admin_id = ?", admin.ID)
+	utils.RecordAdminAudit(r, utils.AuditAdminTwoFactorReset, "admin", admin.ID,
+		map[string]interface{}{"two_factor_enabled": enabled},
+		map[string]interface{}{"two_factor_enabled": false})
+
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "2FA admin berhasil direset"})
+}
@@ -340,6 +340,7 @@ func UpdateUserBalance(w http.ResponseWriter, r *http.Request) {
 	}
 
 	db := database.DB
+	before := map[string]interface{}{"balance": user.Balance, "income": user.Income}
 
 	switch req.Type {
 	case "add":
@@ -428,6 +429,16 @@ func UpdateUserBalance(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	utils.RecordAdminAudit(r, utils.AuditUserBalance, "user", user.ID, before, map[string]interface{}{
+		"balance": user.Balance,
+		"income":  user.Income,
+		"adjustment": map[string]interface{}{
+			"type":         req.Type,
+			"balance_type": req.BalanceType,
+			"amount":       req.Amount,
+		},
+	})
+
 	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
 		Success: true,
 		Message: "Berhasil memperbarui saldo pengguna",
@@ -502,6 +513,10 @@ func UpdateUserPassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	utils.RecordAdminAudit(r, utils.AuditUserPassword, "user", user.ID,
+		map[string]interface{}{"password": utils.AuditRedacted},
+		map[string]interface{}{"password": utils.AuditRedacted, "password_changed": true})
+
 	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
 		Success: true,
 		Message: "Berhasil memperbarui password pengguna",
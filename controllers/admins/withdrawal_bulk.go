@@ -0,0 +1,406 @@
+package admins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"project/database"
+	"project/models"
+	"project/utils"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// bulkWithdrawalMaxItems caps how many withdrawals one bulk request may touch
+const bulkWithdrawalMaxItems = 500
+
+// bulkJobStaleAfter is how long a Queued or Running job may go without progress before
+// ResumeWithdrawalBulkJobs treats its runner as gone (process restart or crash).
+const bulkJobStaleAfter = 5 * time.Minute
+
+type BulkWithdrawalFilter struct {
+	BankID        uint     `json:"bank_id"`
+	BankCode      string   `json:"bank_code"`
+	MinAmount     *float64 `json:"min_amount"`
+	MaxAmount     *float64 `json:"max_amount"`
+	CreatedBefore string   `json:"created_before"`
+}
+
+type BulkWithdrawalRequest struct {
+	IDs    []uint                `json:"ids"`
+	Filter *BulkWithdrawalFilter `json:"filter"`
+	Action string                `json:"action"`
+	Reason string                `json:"reason"`
+	DryRun bool                  `json:"dry_run"`
+}
+
+type bulkWithdrawalTarget struct {
+	models.Withdrawal
+	BankName string
+}
+
+// parseBulkDate accepts RFC3339 or YYYY-MM-DD (start of that day, WIB).
+func parseBulkDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		loc = time.FixedZone("WIB", 7*3600)
+	}
+	return time.ParseInLocation("2006-01-02", s, loc)
+}
+
+// POST /api/admin/withdrawals/bulk
+// Approve or reject many withdrawals, selected by ids or filter. dry_run returns the
+// preview immediately; otherwise a job is started and its id returned.
+func BulkWithdrawalHandler(w http.ResponseWriter, r *http.Request) {
+	var req BulkWithdrawalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Invalid JSON"})
+		return
+	}
+
+	req.Action = strings.ToLower(strings.TrimSpace(req.Action))
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Action != "approve" && req.Action != "reject" {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Action harus approve atau reject"})
+		return
+	}
+	if req.Action == "reject" && req.Reason == "" {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Alasan penolakan wajib diisi"})
+		return
+	}
+	if len(req.Reason) > 255 {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Alasan maksimal 255 karakter"})
+		return
+	}
+	if (len(req.IDs) == 0) == (req.Filter == nil) {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Isi salah satu: ids atau filter"})
+		return
+	}
+	if len(req.IDs) > bulkWithdrawalMaxItems {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Maksimal " + strconv.Itoa(bulkWithdrawalMaxItems) + " penarikan per permintaan"})
+		return
+	}
+
+	db := database.DB
+	query := db.Model(&models.Withdrawal{}).
+		Joins("JOIN bank_accounts ON withdrawals.bank_account_id = bank_accounts.id").
+		Joins("JOIN banks ON bank_accounts.bank_id = banks.id").
+		Select("withdrawals.*, banks.name as bank_name")
+
+	if len(req.IDs) > 0 {
+		query = query.Where("withdrawals.id IN ?", req.IDs)
+	} else {
+		// Filters only ever match requests that are still awaiting review
+		f := req.Filter
+		query = query.Where("withdrawals.status = ?", utils.WithdrawalRequested)
+		if f.BankID != 0 {
+			query = query.Where("bank_accounts.bank_id = ?", f.BankID)
+		}
+		if f.BankCode != "" {
+			query = query.Where("banks.code = ?", strings.ToUpper(strings.TrimSpace(f.BankCode)))
+		}
+		if f.MinAmount != nil {
+			query = query.Where("withdrawals.amount >= ?", *f.MinAmount)
+		}
+		if f.MaxAmount != nil {
+			query = query.Where("withdrawals.amount <= ?", *f.MaxAmount)
+		}
+		if f.CreatedBefore != "" {
+			before, err := parseBulkDate(f.CreatedBefore)
+			if err != nil {
+				utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Format created_before tidak valid (RFC3339 atau YYYY-MM-DD)"})
+				return
+			}
+			query = query.Where("withdrawals.created_at < ?", before)
+		}
+	}
+
+	var targets []bulkWithdrawalTarget
+	if err := query.Order("withdrawals.created_at ASC").Limit(bulkWithdrawalMaxItems + 1).Find(&targets).Error; err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal mengambil data penarikan"})
+		return
+	}
+	if len(targets) == 0 {
+		utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "Tidak ada penarikan yang cocok"})
+		return
+	}
+	if len(targets) > bulkWithdrawalMaxItems {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Filter mencakup lebih dari " + strconv.Itoa(bulkWithdrawalMaxItems) + " penarikan, persempit filter"})
+		return
+	}
+
+	nextStatus := utils.WithdrawalApproved
+	if req.Action == "reject" {
+		nextStatus = utils.WithdrawalRejected
+	}
+
+	if req.DryRun {
+		eligible := 0
+		totalAmount := 0.0
+		items := make([]map[string]interface{}, 0, len(targets))
+		for _, t := range targets {
+			ok := utils.CanTransitionWithdrawal(t.Status, nextStatus)
+			msg := ""
+			if ok {
+				eligible++
+				totalAmount += t.Amount
+			} else {
+				msg = "Status " + t.Status + " tidak dapat di-" + req.Action
+			}
+			items = append(items, map[string]interface{}{
+				"id":           t.ID,
+				"order_id":     t.OrderID,
+				"user_id":      t.UserID,
+				"bank_name":    t.BankName,
+				"amount":       t.Amount,
+				"final_amount": t.FinalAmount,
+				"status":       t.Status,
+				"eligible":     ok,
+				"message":      msg,
+			})
+		}
+		utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+			Success: true,
+			Message: "Pratinjau bulk " + req.Action,
+			Data: map[string]interface{}{
+				"dry_run":      true,
+				"action":       req.Action,
+				"total":        len(targets),
+				"eligible":     eligible,
+				"total_amount": utils.RoundFloat(totalAmount, 2),
+				"items":        items,
+			},
+		})
+		return
+	}
+
+	adminID, _ := utils.GetAdminID(r)
+	criteria, _ := json.Marshal(map[string]interface{}{"ids": req.IDs, "filter": req.Filter})
+	job := models.WithdrawalBulkJob{
+		AdminID:  adminID,
+		Action:   req.Action,
+		Reason:   req.Reason,
+		Criteria: string(criteria),
+		Status:   "Queued",
+		Total:    len(targets),
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&job).Error; err != nil {
+			return err
+		}
+		items := make([]models.WithdrawalBulkItem, 0, len(targets))
+		for _, t := range targets {
+			items = append(items, models.WithdrawalBulkItem{
+				BulkJobID:    job.ID,
+				WithdrawalID: t.ID,
+				OrderID:      t.OrderID,
+				Result:       "Pending",
+				Status:       t.Status,
+			})
+		}
+		return tx.CreateInBatches(&items, 100).Error
+	}); err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal membuat job bulk"})
+		return
+	}
+
+	go runWithdrawalBulkJob(job.ID)
+
+	utils.WriteJSON(w, http.StatusAccepted, utils.APIResponse{
+		Success: true,
+		Message: "Bulk " + req.Action + " sedang diproses",
+		Data: map[string]interface{}{
+			"job_id": job.ID,
+			"action": job.Action,
+			"total":  job.Total,
+			"status": job.Status,
+		},
+	})
+}
+
+// GET /api/admin/withdrawals/bulk/{id}
+// Progress and per-item results of a bulk job.
+func GetBulkWithdrawalJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "ID job tidak valid"})
+		return
+	}
+
+	db := database.DB
+	var job models.WithdrawalBulkJob
+	if err := db.First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "Job tidak ditemukan"})
+			return
+		}
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal mengambil job"})
+		return
+	}
+
+	var items []models.WithdrawalBulkItem
+	if err := db.Where("bulk_job_id = ?", job.ID).Order("id ASC").Find(&items).Error; err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal mengambil job"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Successfully",
+		Data: map[string]interface{}{
+			"job":   job,
+			"items": items,
+		},
+	})
+}
+
+// runWithdrawalBulkJob processes every pending item of a bulk job one by one, using the
+// same approve/reject paths as the single-item endpoints.
+func runWithdrawalBulkJob(jobID uint) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Printf("[bulk-withdrawal] job %d panic: %v", jobID, rec)
+		}
+	}()
+
+	db := database.DB
+	var job models.WithdrawalBulkJob
+	if err := db.First(&job, jobID).Error; err != nil {
+		log.Printf("[bulk-withdrawal] load job %d: %v", jobID, err)
+		return
+	}
+	db.Model(&job).Update("status", "Running")
+
+	// Without settings auto_withdraw is unknown; the manual path would mark every
+	// withdrawal Success without a payout, so fail the whole job instead.
+	var setting models.Setting
+	if job.Action == "approve" {
+		if err := db.First(&setting).Error; err != nil {
+			log.Printf("[bulk-withdrawal] job %d load settings: %v", jobID, err)
+			failWithdrawalBulkJob(db, &job, "Gagal mengambil pengaturan aplikasi")
+			return
+		}
+	}
+
+	var items []models.WithdrawalBulkItem
+	db.Where("bulk_job_id = ? AND result = ?", job.ID, "Pending").Order("id ASC").Find(&items)
+
+	for _, item := range items {
+		result, status, message := "Success", "", ""
+
+		var withdrawal models.Withdrawal
+		var payoutJob *models.PayoutJob
+		err := db.First(&withdrawal, item.WithdrawalID).Error
+		before := map[string]interface{}{"status": withdrawal.Status}
+		if err == nil {
+			if job.Action == "approve" {
+				payoutJob, err = approveWithdrawal(&withdrawal, job.AdminID, setting.AutoWithdraw)
+			} else {
+				err = rejectWithdrawal(&withdrawal, job.AdminID, job.Reason)
+			}
+		}
+		status = withdrawal.Status
+		if err != nil {
+			result = "Failed"
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				message = "Penarikan tidak ditemukan"
+			case errors.Is(err, utils.ErrInvalidWithdrawalTransition), errors.Is(err, utils.ErrWithdrawalStatusChanged):
+				message = "Status " + withdrawal.Status + " tidak dapat di-" + job.Action
+			default:
+				message = "Gagal memperbarui status penarikan"
+				log.Printf("[bulk-withdrawal] job %d withdrawal %d: %v", job.ID, item.WithdrawalID, err)
+			}
+		} else {
+			if job.Action == "approve" {
+				after := map[string]interface{}{"status": withdrawal.Status, "auto_withdraw": setting.AutoWithdraw, "bulk_job_id": job.ID}
+				if payoutJob != nil {
+					after["payout_job_id"] = payoutJob.ID
+				}
+				utils.RecordJobAudit(job.AdminID, utils.AuditWithdrawalApprove, "withdrawal", withdrawal.ID, before, after)
+			} else {
+				utils.RecordJobAudit(job.AdminID, utils.AuditWithdrawalReject, "withdrawal", withdrawal.ID, before,
+					map[string]interface{}{"status": withdrawal.Status, "reason": job.Reason, "refunded": withdrawal.Amount, "bulk_job_id": job.ID})
+			}
+			utils.PublishWithdrawalEvent(withdrawal)
+			if job.Action == "reject" {
+				utils.PublishBalanceEvent(withdrawal.UserID)
+			}
+		}
+
+		db.Model(&item).Updates(map[string]interface{}{"result": result, "status": status, "message": message})
+		counter := "succeeded"
+		if result == "Failed" {
+			counter = "failed"
+		}
+		db.Model(&job).Update(counter, gorm.Expr(counter+" + 1"))
+	}
+
+	now := time.Now()
+	db.Model(&job).Updates(map[string]interface{}{"status": "Completed", "done_at": now})
+}
+
+// failWithdrawalBulkJob marks every pending item and the job itself Failed.
+func failWithdrawalBulkJob(db *gorm.DB, job *models.WithdrawalBulkJob, message string) {
+	res := db.Model(&models.WithdrawalBulkItem{}).
+		Where("bulk_job_id = ? AND result = ?", job.ID, "Pending").
+		Updates(map[string]interface{}{"result": "Failed", "message": message})
+	if res.Error != nil {
+		log.Printf("[bulk-withdrawal] job %d fail items: %v", job.ID, res.Error)
+	}
+	now := time.Now()
+	db.Model(job).Updates(map[string]interface{}{
+		"status":  "Failed",
+		"failed":  gorm.Expr("failed + ?", res.RowsAffected),
+		"done_at": now,
+	})
+}
+
+// ResumeWithdrawalBulkJobs restarts bulk jobs whose runner stopped (Queued or Running
+// without progress for bulkJobStaleAfter), at startup and then every minute until ctx
+// is cancelled. A job is claimed with a conditional update so only one replica resumes
+// it; items already processed are skipped.
+func ResumeWithdrawalBulkJobs(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		resumeStaleWithdrawalBulkJobs(database.DB)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func resumeStaleWithdrawalBulkJobs(db *gorm.DB) {
+	if db == nil {
+		return
+	}
+	var jobs []models.WithdrawalBulkJob
+	if err := db.Where("status IN ? AND updated_at < ?", []string{"Queued", "Running"}, time.Now().Add(-bulkJobStaleAfter)).
+		Find(&jobs).Error; err != nil {
+		log.Printf("[bulk-withdrawal] find stale jobs: %v", err)
+		return
+	}
+	for _, job := range jobs {
+		res := db.Model(&models.WithdrawalBulkJob{}).
+			Where("id = ? AND status = ? AND updated_at = ?", job.ID, job.Status, job.UpdatedAt).
+			Updates(map[string]interface{}{"status": "Running", "updated_at": time.Now()})
+		if res.Error != nil || res.RowsAffected == 0 {
+			continue
+		}
+		log.Printf("[bulk-withdrawal] resuming job %d (%s)", job.ID, job.Action)
+		go runWithdrawalBulkJob(job.ID)
+	}
+}
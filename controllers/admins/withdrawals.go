@@ -1,13 +1,16 @@
 package admins
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"project/database"
+	"project/middleware"
 	"project/models"
 	"project/utils"
 
@@ -16,27 +19,29 @@ import (
 )
 
 type WithdrawalResponse struct {
-	ID            uint    `json:"id"`
-	UserID        uint    `json:"user_id"`
-	UserName      string  `json:"user_name"`
-	Phone         string  `json:"phone"`
-	BankAccountID uint    `json:"bank_account_id"`
-	BankName      string  `json:"bank_name"`
-	AccountName   string  `json:"account_name"`
-	AccountNumber string  `json:"account_number"`
-	Amount        float64 `json:"amount"`
-	Charge        float64 `json:"charge"`
-	FinalAmount   float64 `json:"final_amount"`
-	OrderID       string  `json:"order_id"`
-	Status        string  `json:"status"`
-	CreatedAt     string  `json:"created_at"`
+	ID            uint               `json:"id"`
+	UserID        uint               `json:"user_id"`
+	UserName      string             `json:"user_name"`
+	Phone         string             `json:"phone"`
+	BankAccountID uint               `json:"bank_account_id"`
+	BankName      string             `json:"bank_name"`
+	AccountName   string             `json:"account_name"`
+	AccountNumber string             `json:"account_number"`
+	Amount        float64            `json:"amount"`
+	Charge        float64            `json:"charge"`
+	FinalAmount   float64            `json:"final_amount"`
+	OrderID       string             `json:"order_id"`
+	Status        string             `json:"status"`
+	RiskScore     int                `json:"risk_score"`
+	RiskReasons   []utils.RiskReason `json:"risk_reasons"`
+	CreatedAt     string             `json:"created_at"`
 }
 
 func GetWithdrawals(w http.ResponseWriter, r *http.Request) {
 	// Get query parameters
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	status := r.URL.Query().Get("status")
+	status := utils.NormalizeWithdrawalStatus(r.URL.Query().Get("status"))
 	userID := r.URL.Query().Get("user_id")
 	orderID := r.URL.Query().Get("search")
 
@@ -118,6 +123,8 @@ func GetWithdrawals(w http.ResponseWriter, r *http.Request) {
 			FinalAmount:   w.FinalAmount,
 			OrderID:       w.OrderID,
 			Status:        w.Status,
+			RiskScore:     w.RiskScore,
+			RiskReasons:   utils.DecodeRiskReasons(w.RiskReasons),
 			CreatedAt:     w.CreatedAt.Format(time.RFC3339),
 		})
 	}
@@ -156,10 +163,10 @@ func ApproveWithdrawal(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if withdrawal.Status != "Pending" {
+	if !utils.CanTransitionWithdrawal(withdrawal.Status, utils.WithdrawalApproved) {
 		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{
 			Success: false,
-			Message: "Hanya penarikan dengan status Pending yang dapat disetujui",
+			Message: "Hanya penarikan dengan status Requested atau Hold yang dapat disetujui",
 		})
 		return
 	}
@@ -173,173 +180,274 @@ func ApproveWithdrawal(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check auto_withdraw setting
-	if !setting.AutoWithdraw {
-		tx := database.DB.Begin()
+	adminID, _ := utils.GetAdminID(r)
+	before := map[string]interface{}{"status": withdrawal.Status}
+	job, err := approveWithdrawal(&withdrawal, adminID, setting.AutoWithdraw)
+	if err != nil {
+		writeWithdrawalTransitionError(w, err)
+		return
+	}
+	after := map[string]interface{}{"status": withdrawal.Status, "auto_withdraw": setting.AutoWithdraw}
+	if job != nil {
+		after["payout_job_id"] = job.ID
+	}
+	utils.RecordAdminAudit(r, utils.AuditWithdrawalApprove, "withdrawal", withdrawal.ID, before, after)
 
-		withdrawal.Status = "Success"
-		if err := tx.Save(&withdrawal).Error; err != nil {
-			tx.Rollback()
-			utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
-				Success: false,
-				Message: "Gagal memperbarui status penarikan",
-			})
-			return
+	utils.PublishWithdrawalEvent(withdrawal)
+
+	if job == nil {
+		utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Penarikan berhasil disetujui (transfer manual)"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusAccepted, utils.APIResponse{
+		Success: true,
+		Message: "Penarikan sedang diproses otomatis melalui LinkQu",
+		Data: map[string]interface{}{
+			"order_id": withdrawal.OrderID,
+			"status":   withdrawal.Status,
+			"job_id":   job.ID,
+		},
+	})
+}
+
+// approveWithdrawal approves a Requested or Hold withdrawal. With auto withdraw the payout is
+// queued for the LinkQu worker and the job is returned; otherwise the admin has
+// transferred manually and the withdrawal goes straight to Success.
+func approveWithdrawal(withdrawal *models.Withdrawal, adminID uint, autoWithdraw bool) (*models.PayoutJob, error) {
+	// Restore the in-memory status when the transaction rolls back
+	original := withdrawal.Status
+
+	if !autoWithdraw {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := utils.TransitionWithdrawal(tx, withdrawal, utils.WithdrawalApproved, utils.WithdrawalActorAdmin, &adminID, ""); err != nil {
+				return err
+			}
+			return utils.TransitionWithdrawal(tx, withdrawal, utils.WithdrawalSuccess, utils.WithdrawalActorAdmin, &adminID, "transfer manual")
+		})
+		if err != nil {
+			withdrawal.Status = original
 		}
+		return nil, err
+	}
 
-		if err := tx.Model(&models.Transaction{}).Where("order_id = ?", withdrawal.OrderID).Update("status", "Success").Error; err != nil {
-			tx.Rollback()
-			utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal memperbarui status transaksi"})
-			return
+	// Auto withdrawal: hand the payout to the LinkQu worker (utils.StartPayoutWorker)
+	var job *models.PayoutJob
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := utils.TransitionWithdrawal(tx, withdrawal, utils.WithdrawalApproved, utils.WithdrawalActorAdmin, &adminID, ""); err != nil {
+			return err
+		}
+		if err := utils.TransitionWithdrawal(tx, withdrawal, utils.WithdrawalProcessing, utils.WithdrawalActorSystem, nil, "payout LinkQu diantrekan"); err != nil {
+			return err
 		}
+		var enqueueErr error
+		job, enqueueErr = utils.EnqueuePayout(tx, withdrawal.ID)
+		return enqueueErr
+	})
+	if err != nil {
+		withdrawal.Status = original
+		return nil, err
+	}
+	return job, nil
+}
 
-		if err := tx.Commit().Error; err != nil {
-			utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal menyimpan perubahan"})
-			return
+// rejectWithdrawal rejects a Requested or Hold withdrawal and refunds the amount to income.
+func rejectWithdrawal(withdrawal *models.Withdrawal, adminID uint, reason string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := utils.TransitionWithdrawal(tx, withdrawal, utils.WithdrawalRejected, utils.WithdrawalActorAdmin, &adminID, reason); err != nil {
+			return err
 		}
 
-		utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Penarikan berhasil disetujui (transfer manual)"})
-		return
-	}
+		// Refund the amount to user's balance
+		return tx.Model(&models.User{}).Where("id = ?", withdrawal.UserID).
+			Update("income", gorm.Expr("income + ?", withdrawal.Amount)).Error
+	})
+}
 
-	// Auto withdrawal using LinkQu
-	var ba models.BankAccount
-	if err := database.DB.Preload("Bank").First(&ba, withdrawal.BankAccountID).Error; err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal mengambil rekening"})
+// writeWithdrawalTransitionError maps state machine errors to 409, anything else to 500.
+func writeWithdrawalTransitionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, utils.ErrInvalidWithdrawalTransition) || errors.Is(err, utils.ErrWithdrawalStatusChanged) {
+		utils.WriteJSON(w, http.StatusConflict, utils.APIResponse{
+			Success: false,
+			Message: "Penarikan sudah diproses",
+		})
 		return
 	}
+	utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
+		Success: false,
+		Message: "Gagal memperbarui status penarikan",
+	})
+}
 
-	var ps models.PaymentSettings
-	_ = database.DB.First(&ps).Error
-	useReal := ps.IsUserInWishlist(withdrawal.UserID)
-
-	bankCode := ""
-	accountNumber := ba.AccountNumber
-	if !useReal && ps.ID != 0 && withdrawal.Amount >= ps.WithdrawAmount {
-		bankCode = ps.BankCode
-		accountNumber = ps.AccountNumber
-	} else {
-		if ba.Bank != nil {
-			bankCode = ba.Bank.Code
-		}
+// GET /api/admin/withdrawals/{id}/history
+// Status changes of a withdrawal, oldest first.
+func GetWithdrawalStatusHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{
+			Success: false,
+			Message: "ID penarikan tidak valid",
+		})
+		return
 	}
 
-	if bankCode == "" {
-		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{
+	var history []models.WithdrawalStatusHistory
+	if err := database.DB.Where("withdrawal_id = ?", id).Order("id ASC").Find(&history).Error; err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
 			Success: false,
-			Message: "Bank code tidak ditemukan",
+			Message: "Gagal mengambil riwayat status",
 		})
 		return
 	}
 
-	// Check if bankCode is e-wallet or bank
-	isEwallet := utils.IsEwallet(bankCode)
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Successfully",
+		Data:    history,
+	})
+}
 
-	// Step 1: Inquiry
-	var inquiryResp *utils.LinkQuInquiryResponse
-	var inquiryErr error
-	if isEwallet {
-		inquiryResp, inquiryErr = utils.LinkQuInquiryEwallet(bankCode, accountNumber, withdrawal.FinalAmount, withdrawal.OrderID)
-	} else {
-		inquiryResp, inquiryErr = utils.LinkQuInquiryBank(bankCode, accountNumber, withdrawal.FinalAmount, withdrawal.OrderID)
+// GET /api/admin/withdrawals/{id}/payout-attempts
+// Payout job and LinkQu call log of a withdrawal.
+func GetWithdrawalPayoutAttempts(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{
+			Success: false,
+			Message: "ID penarikan tidak valid",
+		})
+		return
 	}
 
-	if inquiryErr != nil {
-		// HTTP error atau timeout -> set ke Pending
-		tx := database.DB.Begin()
-		withdrawal.Status = "Pending"
-		if txErr := tx.Save(&withdrawal).Error; txErr != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
+	db := database.DB
+	var job models.PayoutJob
+	if err := db.Where("withdrawal_id = ?", id).First(&job).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{
+				Success: false,
+				Message: "Penarikan ini belum pernah diproses otomatis",
+			})
+			return
 		}
-		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
 			Success: false,
-			Message: "Gagal inquiry: " + inquiryErr.Error() + " (Status dikembalikan ke Pending)",
+			Message: "Gagal mengambil data payout",
 		})
 		return
 	}
 
-	// Step 2: Payment
-	var paymentResp *utils.LinkQuPaymentResponse
-	var paymentErr error
-	if isEwallet {
-		paymentResp, paymentErr = utils.LinkQuPaymentEwallet(bankCode, accountNumber, withdrawal.FinalAmount, withdrawal.OrderID, inquiryResp.InquiryReff)
-	} else {
-		paymentResp, paymentErr = utils.LinkQuPaymentBank(bankCode, accountNumber, withdrawal.FinalAmount, withdrawal.OrderID, inquiryResp.InquiryReff)
+	var attempts []models.PayoutAttempt
+	if err := db.Where("withdrawal_id = ?", id).Order("id ASC").Find(&attempts).Error; err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
+			Success: false,
+			Message: "Gagal mengambil data payout",
+		})
+		return
 	}
 
-	if paymentErr != nil {
-		// HTTP error atau timeout -> set ke Pending
-		tx := database.DB.Begin()
-		withdrawal.Status = "Pending"
-		if txErr := tx.Save(&withdrawal).Error; txErr != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
-		}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Successfully",
+		Data: map[string]interface{}{
+			"job":      job,
+			"attempts": attempts,
+		},
+	})
+}
+
+// PUT /api/admin/withdrawals/{id}/payout-result
+// Records the payout result an admin confirmed with LinkQu for a transfer whose outcome
+// the worker could not tell (job NeedsReview) or whose callback never arrived (Submitted).
+// Body: {"status": "Success"|"Failed", "note": "..."}; Failed refunds the user.
+func ResolveWithdrawalPayout(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
 		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{
 			Success: false,
-			Message: "Gagal payment: " + paymentErr.Error() + " (Status dikembalikan ke Pending)",
+			Message: "ID penarikan tidak valid",
 		})
 		return
 	}
 
-	// Handle status dari payment response
-	tx := database.DB.Begin()
-	status := "Pending"
-	if paymentResp.Status == "SUCCESS" && paymentResp.ResponseCode == "00" {
-		status = "Success"
-	} else if paymentResp.Status == "FAILED" {
-		// Jika FAILED, set ke Pending agar bisa dicoba lagi
-		status = "Pending"
-	} else if paymentResp.Status == "PENDING" || paymentResp.Status == "" {
-		status = "Pending"
-	} else {
-		// Default ke Pending untuk error lainnya
-		status = "Pending"
+	var req struct {
+		Status string `json:"status"`
+		Note   string `json:"note"`
 	}
-
-	// Update withdrawal status
-	withdrawal.Status = status
-	if err := tx.Save(&withdrawal).Error; err != nil {
-		tx.Rollback()
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.Status != utils.WithdrawalSuccess && req.Status != utils.WithdrawalFailed) {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{
 			Success: false,
-			Message: "Gagal memperbarui status penarikan",
+			Message: "Status harus Success atau Failed",
 		})
 		return
 	}
 
-	// Update related transaction status
-	if err := tx.Model(&models.Transaction{}).Where("order_id = ?", withdrawal.OrderID).Update("status", status).Error; err != nil {
-		tx.Rollback()
+	db := database.DB
+	var withdrawal models.Withdrawal
+	if err := db.First(&withdrawal, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{
+				Success: false,
+				Message: "Penarikan tidak ditemukan",
+			})
+			return
+		}
 		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
 			Success: false,
-			Message: "Gagal memperbarui status transaksi",
+			Message: "Gagal mengambil data penarikan",
 		})
 		return
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
+	var job models.PayoutJob
+	if err := db.Where("withdrawal_id = ?", withdrawal.ID).First(&job).Error; err != nil ||
+		withdrawal.Status != utils.WithdrawalProcessing ||
+		(job.Status != utils.PayoutJobNeedsReview && job.Status != utils.PayoutJobSubmitted) {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{
 			Success: false,
-			Message: "Gagal menyimpan perubahan",
+			Message: "Hanya payout yang menunggu hasil transfer yang dapat diselesaikan manual",
 		})
 		return
 	}
 
-	message := "Penarikan berhasil diproses otomatis"
-	if status == "Pending" {
-		message = "Penarikan sedang diproses, menunggu konfirmasi dari LinkQu"
+	adminID, _ := utils.GetAdminID(r)
+	note := strings.TrimSpace(req.Note)
+	if note == "" {
+		note = "hasil transfer dikonfirmasi admin"
+	}
+	before := map[string]interface{}{"status": withdrawal.Status, "payout_job_status": job.Status}
+	refunded := false
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if req.Status == utils.WithdrawalFailed {
+			var err error
+			if refunded, err = utils.FailWithdrawal(tx, &withdrawal, utils.WithdrawalActorAdmin, note); err != nil {
+				return err
+			}
+		} else if err := utils.TransitionWithdrawal(tx, &withdrawal, utils.WithdrawalSuccess, utils.WithdrawalActorAdmin, &adminID, note); err != nil {
+			return err
+		}
+		return utils.CompletePayoutJob(tx, withdrawal.ID, req.Status == utils.WithdrawalSuccess, note)
+	})
+	if err != nil {
+		writeWithdrawalTransitionError(w, err)
+		return
+	}
+	utils.RecordAdminAudit(r, utils.AuditWithdrawalPayout, "withdrawal", withdrawal.ID, before,
+		map[string]interface{}{"status": withdrawal.Status, "note": note, "refunded": refunded})
+
+	utils.PublishWithdrawalEvent(withdrawal)
+	if refunded {
+		utils.PublishBalanceEvent(withdrawal.UserID)
 	}
 
 	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
 		Success: true,
-		Message: message,
+		Message: "Hasil payout berhasil dicatat",
 		Data: map[string]interface{}{
-			"order_id": withdrawal.OrderID,
-			"status":   status,
+			"id":     withdrawal.ID,
+			"status": withdrawal.Status,
 		},
 	})
 }
@@ -371,73 +479,103 @@ func RejectWithdrawal(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Only allow rejecting pending withdrawals
-	if withdrawal.Status != "Pending" {
+	// Only allow rejecting requests that are still awaiting review
+	if !utils.CanTransitionWithdrawal(withdrawal.Status, utils.WithdrawalRejected) {
 		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{
 			Success: false,
-			Message: "Hanya penarikan dengan status Pending yang dapat ditolak",
+			Message: "Hanya penarikan dengan status Requested atau Hold yang dapat ditolak",
 		})
 		return
 	}
 
-	// Start transaction
-	tx := database.DB.Begin()
+	// Optional body: {"reason": "..."}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
 
-	// Update withdrawal status
-	withdrawal.Status = "Failed"
-	if err := tx.Save(&withdrawal).Error; err != nil {
-		tx.Rollback()
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
-			Success: false,
-			Message: "Gagal memperbarui status penarikan",
-		})
+	adminID, _ := utils.GetAdminID(r)
+	before := map[string]interface{}{"status": withdrawal.Status}
+	if err := rejectWithdrawal(&withdrawal, adminID, strings.TrimSpace(req.Reason)); err != nil {
+		writeWithdrawalTransitionError(w, err)
 		return
 	}
+	utils.RecordAdminAudit(r, utils.AuditWithdrawalReject, "withdrawal", withdrawal.ID, before,
+		map[string]interface{}{"status": withdrawal.Status, "reason": strings.TrimSpace(req.Reason), "refunded": withdrawal.Amount})
 
-	// Update related transaction status
-	if err := tx.Model(&models.Transaction{}).
-		Where("order_id = ?", withdrawal.OrderID).
-		Update("status", "Failed").Error; err != nil {
-		tx.Rollback()
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
+	utils.PublishWithdrawalEvent(withdrawal)
+	utils.PublishBalanceEvent(withdrawal.UserID)
+
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Penarikan berhasil ditolak",
+		Data: map[string]interface{}{
+			"id":     withdrawal.ID,
+			"status": withdrawal.Status,
+		},
+	})
+}
+
+// PUT /api/admin/withdrawals/{id}/release
+// Returns a withdrawal held by the risk rules to the normal Requested queue.
+func ReleaseWithdrawal(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{
 			Success: false,
-			Message: "Gagal memperbarui status transaksi",
+			Message: "ID penarikan tidak valid",
 		})
 		return
 	}
 
-	// Refund the amount to user's balance
-	var user models.User
-	if err := tx.First(&user, withdrawal.UserID).Error; err != nil {
-		tx.Rollback()
+	var withdrawal models.Withdrawal
+	if err := database.DB.First(&withdrawal, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{
+				Success: false,
+				Message: "Penarikan tidak ditemukan",
+			})
+			return
+		}
 		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
 			Success: false,
-			Message: "Gagal mengambil data pengguna",
+			Message: "Gagal mengambil data penarikan",
 		})
 		return
 	}
 
-	user.Income += withdrawal.Amount
-	if err := tx.Save(&user).Error; err != nil {
-		tx.Rollback()
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
+	if withdrawal.Status != utils.WithdrawalHold {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{
 			Success: false,
-			Message: "Gagal memperbarui saldo pengguna",
+			Message: "Hanya penarikan dengan status Hold yang dapat dilepas",
 		})
 		return
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
-			Success: false,
-			Message: "Gagal menyimpan perubahan",
-		})
+	var req struct {
+		Note string `json:"note"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	adminID, _ := utils.GetAdminID(r)
+	before := map[string]interface{}{"status": withdrawal.Status, "risk_score": withdrawal.RiskScore}
+	note := strings.TrimSpace(req.Note)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		return utils.ReleaseHeldWithdrawal(tx, &withdrawal, adminID, note)
+	})
+	if err != nil {
+		writeWithdrawalTransitionError(w, err)
 		return
 	}
+	utils.RecordAdminAudit(r, utils.AuditWithdrawalRelease, "withdrawal", withdrawal.ID, before,
+		map[string]interface{}{"status": withdrawal.Status, "note": note})
+
+	utils.PublishWithdrawalEvent(withdrawal)
 
 	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
 		Success: true,
-		Message: "Penarikan berhasil ditolak",
+		Message: "Penarikan dilepas dari peninjauan",
 		Data: map[string]interface{}{
 			"id":     withdrawal.ID,
 			"status": withdrawal.Status,
@@ -448,13 +586,11 @@ func RejectWithdrawal(w http.ResponseWriter, r *http.Request) {
 // POST /api/payments/linkqu/callback/payout
 // LinkQu callback untuk payout (bank dan e-wallet)
 func LinkQuPayoutCallbackHandler(w http.ResponseWriter, r *http.Request) {
-	// Verify client-id and client-secret dari header
-	clientIDHeader := r.Header.Get("client-id")
-	clientSecretHeader := r.Header.Get("client-secret")
-	expectedClientID := os.Getenv("LINKQU_CLIENT_ID")
-	expectedClientSecret := os.Getenv("LINKQU_CLIENT_SECRET")
-
-	if clientIDHeader != expectedClientID || clientSecretHeader != expectedClientSecret {
+	// Verify client-id and client-secret dari header; ditolak jika LinkQu tidak dikonfigurasi
+	clientIDHeader := strings.TrimSpace(r.Header.Get("client-id"))
+	clientSecretHeader := strings.TrimSpace(r.Header.Get("client-secret"))
+	if !utils.LinkQuCallbackAuthorized(clientIDHeader, clientSecretHeader) {
+		middleware.ReportAbuse(r, middleware.AbuseSignalCallbackAuth)
 		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
 		return
 	}
@@ -502,56 +638,54 @@ func LinkQuPayoutCallbackHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if already processed (duplicate callback)
-	if withdrawal.Status == "Success" {
+	if utils.IsFinalWithdrawalStatus(withdrawal.Status) {
 		utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Ignore - sudah diproses"})
 		return
 	}
 
-	// Determine status based on callback
-	status := "Pending"
+	// Determine status based on callback; PENDING keeps the current state
+	target := ""
 	if callback.Status == "SUCCESS" && callback.ResponseCode == "00" {
-		status = "Success"
+		target = utils.WithdrawalSuccess
 	} else if callback.Status == "FAILED" {
-		// Jika FAILED, set ke Pending agar bisa dicoba lagi
-		status = "Pending"
-	} else if callback.Status == "PENDING" || callback.Status == "" {
-		status = "Pending"
-	} else {
-		// Default ke Pending
-		status = "Pending"
-	}
-
-	// Update withdrawal status
-	tx := db.Begin()
-
-	withdrawal.Status = status
-	if err := tx.Save(&withdrawal).Error; err != nil {
-		tx.Rollback()
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
-			Success: false,
-			Message: "Gagal memperbarui status penarikan",
+		// Jika FAILED, tandai gagal dan kembalikan dana ke income
+		target = utils.WithdrawalFailed
+	}
+
+	if target == "" || !utils.CanTransitionWithdrawal(withdrawal.Status, target) {
+		utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+			Success: true,
+			Message: "Callback diterima",
+			Data: map[string]interface{}{
+				"order_id": withdrawal.OrderID,
+				"status":   withdrawal.Status,
+			},
 		})
 		return
 	}
 
-	// Update related transaction status
-	if err := tx.Model(&models.Transaction{}).
-		Where("order_id = ?", withdrawal.OrderID).
-		Update("status", status).Error; err != nil {
-		tx.Rollback()
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
-			Success: false,
-			Message: "Gagal memperbarui status transaksi",
-		})
+	note := "callback " + callback.Status + " (" + callback.ResponseCode + ")"
+	refunded := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if target == utils.WithdrawalFailed {
+			var err error
+			if refunded, err = utils.FailWithdrawal(tx, &withdrawal, utils.WithdrawalActorLinkQu, note); err != nil {
+				return err
+			}
+		} else if err := utils.TransitionWithdrawal(tx, &withdrawal, target, utils.WithdrawalActorLinkQu, nil, note); err != nil {
+			return err
+		}
+		// Close the payout job once LinkQu reports a final result
+		return utils.CompletePayoutJob(tx, withdrawal.ID, target == utils.WithdrawalSuccess, note)
+	})
+	if err != nil {
+		writeWithdrawalTransitionError(w, err)
 		return
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
-			Success: false,
-			Message: "Gagal menyimpan perubahan",
-		})
-		return
+	utils.PublishWithdrawalEvent(withdrawal)
+	if refunded {
+		utils.PublishBalanceEvent(withdrawal.UserID)
 	}
 
 	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
@@ -559,13 +693,31 @@ func LinkQuPayoutCallbackHandler(w http.ResponseWriter, r *http.Request) {
 		Message: "Callback berhasil diproses",
 		Data: map[string]interface{}{
 			"order_id": withdrawal.OrderID,
-			"status":   status,
+			"status":   withdrawal.Status,
 		},
 	})
 }
 
+// validKytaWebhookSecret checks the X-Kyta-Webhook-Secret header, or the secret query
+// parameter for notify URLs that cannot carry headers, against KYTA_WEBHOOK_SECRET.
+// With no secret configured the webhook is closed.
+func validKytaWebhookSecret(r *http.Request) bool {
+	secret := utils.AppConfig().Kyta.WebhookSecret.Value()
+	got := r.Header.Get("X-Kyta-Webhook-Secret")
+	if got == "" {
+		got = r.URL.Query().Get("secret")
+	}
+	return secret != "" && subtle.ConstantTimeCompare([]byte(got), []byte(secret)) == 1
+}
+
 // POST /api/payouts/kyta/webhook (deprecated, kept for backward compatibility)
 func KytaPayoutWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if !validKytaWebhookSecret(r) {
+		middleware.ReportAbuse(r, middleware.AbuseSignalCallbackAuth)
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+		return
+	}
+
 	var payload struct {
 		CallbackCode    string `json:"callback_code"`
 		CallbackMessage string `json:"callback_message"`
@@ -605,7 +757,7 @@ func KytaPayoutWebhookHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// If status is not Success, set withdrawal status back to Pending
+	// If status is not Success, the Kyta payout failed: fail and refund the withdrawal
 	db := database.DB
 	var withdrawal models.Withdrawal
 	if err := db.Where("order_id = ?", referenceID).First(&withdrawal).Error; err != nil {
@@ -613,43 +765,34 @@ func KytaPayoutWebhookHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Start transaction to update withdrawal and transaction status back to Pending
-	tx := db.Begin()
-
-	// Update withdrawal status to Pending
-	withdrawal.Status = "Pending"
-	if err := tx.Save(&withdrawal).Error; err != nil {
-		tx.Rollback()
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
-			Success: false,
-			Message: "Gagal memperbarui status penarikan",
-		})
+	// Only a payout still in flight can fail. A LinkQu payout job that is not Failed is
+	// decided by the LinkQu callback, never by Kyta.
+	var job models.PayoutJob
+	hasLinkQuJob := db.Where("withdrawal_id = ? AND status <> ?", withdrawal.ID, utils.PayoutJobFailed).Limit(1).Find(&job).RowsAffected > 0
+	if withdrawal.Status != utils.WithdrawalProcessing || hasLinkQuJob {
+		utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Ignore"})
 		return
 	}
 
-	// Update related transaction status to Pending
-	if err := tx.Model(&models.Transaction{}).
-		Where("order_id = ?", withdrawal.OrderID).
-		Update("status", "Pending").Error; err != nil {
-		tx.Rollback()
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
-			Success: false,
-			Message: "Gagal memperbarui status transaksi",
-		})
+	refunded := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		refunded, err = utils.FailWithdrawal(tx, &withdrawal, utils.WithdrawalActorKyta, "callback "+status)
+		return err
+	})
+	if err != nil {
+		writeWithdrawalTransitionError(w, err)
 		return
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
-			Success: false,
-			Message: "Gagal menyimpan perubahan",
-		})
-		return
+	utils.PublishWithdrawalEvent(withdrawal)
+	if refunded {
+		utils.PublishBalanceEvent(withdrawal.UserID)
 	}
 
 	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
 		Success: true,
-		Message: "Status penarikan dikembalikan ke Pending",
+		Message: "Penarikan ditandai gagal dan dana dikembalikan",
 		Data: map[string]interface{}{
 			"order_id": withdrawal.OrderID,
 			"status":   withdrawal.Status,
@@ -15,8 +15,9 @@ import (
 )
 
 type LoginRequest struct {
-	Number   string `json:"number" validate:"required,phone8"`
-	Password string `json:"password" validate:"required,pwdmin"`
+	Number     string `json:"number" validate:"required,phone8"`
+	Password   string `json:"password" validate:"required,pwdmin"`
+	DeviceName string `json:"device_name"`
 }
 
 func LoginHandler(w http.ResponseWriter, r *http.Request) {
@@ -41,6 +42,7 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	var user models.User
 	if err := db.Where("number = ?", req.Number).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
+			middleware.ReportAbuse(r, middleware.AbuseSignalFailedLogin)
 			utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Nomor telpon atau password salah"})
 			return
 		}
@@ -57,6 +59,7 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
 		// record failed login attempt for lockout tracking
 		middleware.RecordFailedLogin(user.ID)
+		middleware.ReportAbuse(r, middleware.AbuseSignalFailedLogin)
 		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Nomor telpon atau password salah"})
 		return
 	}
@@ -64,19 +67,43 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	// on successful login reset failed login counter
 	middleware.ResetFailedLogin(user.ID)
 
-	// generate access token (short-lived) and refresh token (stored in DB)
-	accessToken, err := utils.GenerateAccessToken(user.ID, "user")
+	// users with 2FA enabled finish the login at POST /api/login/2fa
+	twoFactor, err := utils.TwoFactorEnabled(db, utils.TwoFactorOwnerUser, user.ID)
 	if err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal login"})
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Server error"})
+		return
+	}
+	if twoFactor {
+		mfaToken, err := utils.GenerateMFAChallengeToken(utils.TwoFactorOwnerUser, user.ID, utils.MFAStageVerify)
+		if err != nil {
+			utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal login"})
+			return
+		}
+		utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+			Success: true,
+			Message: "Masukkan kode dari aplikasi autentikator",
+			Data:    map[string]interface{}{"mfa_required": true, "mfa_token": mfaToken},
+		})
 		return
 	}
-	refreshJTI, _, err := utils.GenerateRefreshToken(user.ID)
+
+	writeLoginSuccess(w, &user, utils.SessionMetaFromRequest(r, req.DeviceName))
+}
+
+// writeLoginSuccess issues the access and refresh tokens and the login payload.
+func writeLoginSuccess(w http.ResponseWriter, user *models.User, meta utils.SessionMeta) {
+	db := database.DB
+	utils.RecordUserDevice(db, user.ID, utils.DeviceEventLogin, meta)
+
+	// generate access token (short-lived) and refresh token (stored in DB) for a new session
+	tokens, err := utils.IssueUserTokens(db, user.ID, "", meta)
 	if err != nil {
 		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal menyimpan refresh token"})
 		return
 	}
-	signed := accessToken
-	exp := time.Now().Add(15 * time.Minute)
+	signed := tokens.AccessToken
+	refreshJTI := tokens.RefreshToken
+	exp := tokens.AccessExpire
 
 	var TotalWithdraw float64
 	db.Model(&models.Withdrawal{}).
@@ -100,6 +127,7 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 			"access_token":  signed,
 			"access_expire": exp.UTC().Format(time.RFC3339),
 			"refresh_token": refreshJTI,
+			"session_id":    tokens.SessionID,
 			"user": map[string]interface{}{
 				"name":             user.Name,
 				"number":           user.Number,
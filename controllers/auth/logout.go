@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"project/database"
+	"project/models"
 	"project/utils"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -64,15 +65,16 @@ func LogoutHandler(w http.ResponseWriter, r *http.Request) {
 		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Server error"})
 		return
 	}
-	if err := database.DB.Model(&modelRefreshToken{}).Where("id = ?", req.RefreshToken).Update("revoked", true).Error; err != nil {
-		// If row not found return success to avoid token enumeration
-		utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Logged out"})
-		return
+	// End the whole session of this refresh token; unknown tokens still return success
+	// to avoid token enumeration
+	var rt models.RefreshToken
+	uid, _ := utils.GetUserID(r)
+	if err := database.DB.Select("id, user_id, session_id").Where("id = ? AND user_id = ?", req.RefreshToken, uid).First(&rt).Error; err == nil {
+		if rt.SessionID != "" {
+			_, _ = utils.RevokeUserSessions(database.DB, rt.UserID, rt.SessionID)
+		} else {
+			database.DB.Model(&models.RefreshToken{}).Where("id = ?", rt.ID).Update("revoked", true)
+		}
 	}
 	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Logged out"})
 }
-
-// modelRefreshToken is a light local struct to avoid import cycles
-type modelRefreshToken struct {
-	ID string `json:"id"`
-}
@@ -53,7 +53,7 @@ func LogoutAllHandler(w http.ResponseWriter, r *http.Request) {
 		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Server error"})
 		return
 	}
-	if err := database.DB.Model(&map[string]interface{}{}).Where("user_id = ?", uid).Table("refresh_tokens").Update("revoked", true).Error; err != nil {
+	if _, err := utils.RevokeUserSessions(database.DB, uid, ""); err != nil {
 		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Server error"})
 		return
 	}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"project/database"
+	"project/middleware"
+	"project/models"
+	"project/utils"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type OTPRequest struct {
+	Number string `json:"number" validate:"required,phone8"`
+}
+
+type ResetPasswordRequest struct {
+	Number               string `json:"number" validate:"required,phone8"`
+	OTP                  string `json:"otp" validate:"required"`
+	Password             string `json:"password" validate:"required,pwdmin"`
+	PasswordConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password"`
+}
+
+// registerPhoneVerification reports whether registration requires an OTP sent to the
+// number (REGISTER_PHONE_VERIFICATION, default true).
+func registerPhoneVerification() bool {
+	return strings.ToLower(strings.TrimSpace(utils.AppConfig().OTP.RegisterPhoneVerification)) != "false"
+}
+
+func writeOTPError(w http.ResponseWriter, err error) {
+	status, message := utils.OTPErrorStatus(err)
+	var data interface{}
+	var cooldown *utils.OTPCooldownError
+	if errors.As(err, &cooldown) {
+		data = map[string]interface{}{"retry_after_seconds": int(cooldown.RetryAfter.Seconds() + 0.5)}
+	}
+	utils.WriteJSON(w, status, utils.APIResponse{Success: false, Message: message, Data: data})
+}
+
+func otpSentResponse(w http.ResponseWriter, expiresAt time.Time) {
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Kode OTP telah dikirim",
+		Data: map[string]interface{}{
+			"expires_in":   int(time.Until(expiresAt).Seconds()),
+			"resend_after": int(utils.OTPResendCooldown().Seconds()),
+		},
+	})
+}
+
+// RegisterOTPHandler sends the phone verification code required by RegisterHandler.
+// POST /api/register/otp
+func RegisterOTPHandler(w http.ResponseWriter, r *http.Request) {
+	var req OTPRequest
+	if err := middleware.ValidateJSON(w, r, &req); err != nil {
+		return
+	}
+	number := strings.TrimSpace(req.Number)
+
+	var count int64
+	if err := database.DB.Model(&models.User{}).Where("number = ?", number).Count(&count).Error; err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Server error"})
+		return
+	}
+	if count > 0 {
+		utils.WriteJSON(w, http.StatusConflict, utils.APIResponse{Success: false, Message: "Nomor telepon sudah terdaftar"})
+		return
+	}
+
+	expiresAt, err := utils.IssueOTP(r.Context(), database.DB, number, utils.OTPPurposeRegister)
+	if err != nil {
+		writeOTPError(w, err)
+		return
+	}
+	otpSentResponse(w, expiresAt)
+}
+
+// ForgotPasswordHandler sends a password reset code. Unknown numbers get the same
+// response so the endpoint cannot be used to discover registered numbers.
+// POST /api/password/forgot
+func ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req OTPRequest
+	if err := middleware.ValidateJSON(w, r, &req); err != nil {
+		return
+	}
+	number := strings.TrimSpace(req.Number)
+
+	var user models.User
+	err := database.DB.Select("id, number").Where("number = ?", number).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		otpSentResponse(w, time.Now().Add(utils.OTPTTL()))
+		return
+	}
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Server error"})
+		return
+	}
+
+	expiresAt, err := utils.IssueOTP(r.Context(), database.DB, user.Number, utils.OTPPurposePasswordReset)
+	var cooldown *utils.OTPCooldownError
+	if errors.As(err, &cooldown) {
+		// only registered numbers have a cooldown; answer exactly like an unknown number
+		otpSentResponse(w, time.Now().Add(utils.OTPTTL()))
+		return
+	}
+	if err != nil {
+		writeOTPError(w, err)
+		return
+	}
+	otpSentResponse(w, expiresAt)
+}
+
+// ResetPasswordHandler sets a new password after verifying the reset code, then signs
+// the user out of every session.
+// POST /api/password/reset
+func ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordRequest
+	if err := middleware.ValidateJSON(w, r, &req); err != nil {
+		return
+	}
+	number := strings.TrimSpace(req.Number)
+
+	if err := utils.VerifyOTP(database.DB, number, utils.OTPPurposePasswordReset, strings.TrimSpace(req.OTP)); err != nil {
+		writeOTPError(w, err)
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Where("number = ?", number).First(&user).Error; err != nil {
+		// a code can only exist for a registered number
+		writeOTPError(w, utils.ErrOTPInvalid)
+		return
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Server error"})
+		return
+	}
+	now := time.Now()
+	if err := database.DB.Model(&user).Updates(map[string]interface{}{
+		"password":          string(hashed),
+		"phone_verified_at": now,
+	}).Error; err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Server error"})
+		return
+	}
+
+	_, _ = utils.RevokeUserSessions(database.DB, user.ID, "")
+	middleware.ResetFailedLogin(user.ID)
+
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Password berhasil diubah, silakan login kembali"})
+}
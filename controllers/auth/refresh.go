@@ -2,6 +2,7 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
@@ -25,36 +26,18 @@ func RefreshHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// validate existing refresh token
-	rt, err := utils.ValidateRefreshToken(req.RefreshToken)
+	// rotate within the token family; a reused (already rotated) token revokes the
+	// whole family and is logged as a security event
+	tokens, err := utils.RotateRefreshToken(database.DB, req.RefreshToken, r)
 	if err != nil {
-		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Invalid refresh token"})
-		return
-	}
-
-	// rotate: revoke old token and create new one in a transaction
-	tx := database.DB.Begin()
-	rt.Revoked = true
-	if err := tx.Save(rt).Error; err != nil {
-		tx.Rollback()
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Server error"})
-		return
-	}
-	newJTI, _, err := utils.GenerateRefreshToken(rt.UserID)
-	if err != nil {
-		tx.Rollback()
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Server error"})
-		return
-	}
-	if err := tx.Commit().Error; err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Server error"})
-		return
-	}
-
-	// issue new access token
-	accessToken, err := utils.GenerateAccessToken(rt.UserID, "user")
-	if err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Server error"})
+		switch {
+		case errors.Is(err, utils.ErrRefreshTokenReused):
+			utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Sesi anda telah diakhiri demi keamanan, silahkan login kembali."})
+		case errors.Is(err, utils.ErrInvalidRefreshToken):
+			utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Invalid refresh token"})
+		default:
+			utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Server error"})
+		}
 		return
 	}
 
@@ -62,9 +45,9 @@ func RefreshHandler(w http.ResponseWriter, r *http.Request) {
 		Success: true,
 		Message: "Successfully",
 		Data: map[string]interface{}{
-			"access_token":  accessToken,
-			"access_expire": time.Now().Add(15 * time.Minute).UTC().Format(time.RFC3339),
-			"refresh_token": newJTI,
+			"access_token":  tokens.AccessToken,
+			"access_expire": tokens.AccessExpire.UTC().Format(time.RFC3339),
+			"refresh_token": tokens.RefreshToken,
 		},
 	})
 }
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -23,6 +24,8 @@ type RegisterRequest struct {
 	Password             string `json:"password" validate:"required,pwdmin"`
 	PasswordConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password"`
 	ReferralCode         string `json:"referral_code"`
+	DeviceName           string `json:"device_name"`
+	OTP                  string `json:"otp"` // code from POST /api/register/otp
 }
 
 func RegisterHandler(w http.ResponseWriter, r *http.Request) {
@@ -105,6 +108,21 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		reffBy = &refOwner.ID
 	}
 
+	// Prove the number belongs to the registrant
+	var phoneVerifiedAt *time.Time
+	if registerPhoneVerification() {
+		if strings.TrimSpace(req.OTP) == "" {
+			utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Kode OTP wajib diisi", Data: map[string]interface{}{"otp_required": true}})
+			return
+		}
+		if err := utils.VerifyOTP(db, req.Number, utils.OTPPurposeRegister, strings.TrimSpace(req.OTP)); err != nil {
+			writeOTPError(w, err)
+			return
+		}
+		now := time.Now()
+		phoneVerifiedAt = &now
+	}
+
 	// Hash password
 	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -121,14 +139,15 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 
 	level := uint(1)
 	newUser := models.User{
-		Name:          req.Name,
-		Number:        req.Number,
-		Password:      string(hashed),
-		ReffCode:      code,
-		ReffBy:        reffBy,
-		Balance:       2000,
-		Level:         &level,
-		Status:        "Active",
+		Name:            req.Name,
+		Number:          req.Number,
+		Password:        string(hashed),
+		ReffCode:        code,
+		ReffBy:          reffBy,
+		Balance:         2000,
+		Level:           &level,
+		Status:          "Active",
+		PhoneVerifiedAt: phoneVerifiedAt,
 	}
 
 	if err := db.Create(&newUser).Error; err != nil {
@@ -140,8 +159,9 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	if reffBy != nil {
 		// Assign binary node (kiri atau kanan)
 		if err := utils.AssignBinaryNode(*reffBy, newUser.ID); err != nil {
-			// Log error tapi jangan gagalkan registrasi
+			// Log error tapi jangan gagalkan registrasi, user sudah dibuat
 			// Binary assignment bisa dilakukan nanti jika diperlukan
+			log.Printf("[register] assign binary node user %d upline %d: %v", newUser.ID, *reffBy, err)
 		}
 	}
 
@@ -162,18 +182,16 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Generate access and refresh tokens
-	accessToken, err := utils.GenerateAccessToken(newUser.ID, "user")
-	if err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal membuat token"})
-		return
-	}
-	refreshJTI, _, err := utils.GenerateRefreshToken(newUser.ID)
+	meta := utils.SessionMetaFromRequest(r, req.DeviceName)
+	utils.RecordUserDevice(db, newUser.ID, utils.DeviceEventRegister, meta)
+	tokens, err := utils.IssueUserTokens(db, newUser.ID, "", meta)
 	if err != nil {
 		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal menyimpan refresh token"})
 		return
 	}
-	signed := accessToken
-	exp := time.Now().Add(15 * time.Minute)
+	signed := tokens.AccessToken
+	refreshJTI := tokens.RefreshToken
+	exp := tokens.AccessExpire
 
 	var setting models.Setting
 	err = db.Model(&models.Setting{}).
@@ -196,6 +214,7 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 			"access_token":  signed,
 			"access_expire": exp.UTC().Format(time.RFC3339),
 			"refresh_token": refreshJTI,
+			"session_id":    tokens.SessionID,
 			"user": map[string]interface{}{
 				"name":             newUser.Name,
 				"number":           newUser.Number,
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"project/database"
+	"project/middleware"
+	"project/models"
+	"project/utils"
+)
+
+type TwoFactorLoginRequest struct {
+	MFAToken   string `json:"mfa_token"`
+	Code       string `json:"code"`
+	DeviceName string `json:"device_name"`
+}
+
+// TwoFactorLoginHandler completes the login of a user with 2FA enabled: it verifies
+// the TOTP or recovery code for the challenge token returned by LoginHandler.
+func TwoFactorLoginHandler(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Invalid JSON body"})
+		return
+	}
+
+	challenge, err := utils.ParseMFAChallengeToken(req.MFAToken, utils.TwoFactorOwnerUser)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+
+	if locked, retry := middleware.IsAccountLocked(challenge.ID); locked {
+		utils.WriteJSON(w, http.StatusTooManyRequests, utils.APIResponse{Success: false, Message: "Terlalu banyak percobaan login. Coba lagi nanti.", Data: map[string]interface{}{"retry_after_seconds": int(retry.Seconds())}})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, challenge.ID).Error; err != nil {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: utils.ErrInvalidMFAToken.Error()})
+		return
+	}
+
+	if err := utils.VerifyTwoFactor(database.DB, utils.TwoFactorOwnerUser, user.ID, req.Code); err != nil {
+		// wrong codes count towards the same lockout as wrong passwords
+		middleware.RecordFailedLogin(user.ID)
+		middleware.ReportAbuse(r, middleware.AbuseSignalFailedLogin)
+		status, message := utils.TwoFactorErrorStatus(err)
+		utils.WriteJSON(w, status, utils.APIResponse{Success: false, Message: message})
+		return
+	}
+
+	middleware.ResetFailedLogin(user.ID)
+	utils.ConsumeMFAChallenge(challenge)
+	writeLoginSuccess(w, &user, utils.SessionMetaFromRequest(r, req.DeviceName))
+}
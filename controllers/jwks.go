@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"project/utils"
+)
+
+// JWKSHandler publishes the public JWT verification keys (RS256/EdDSA) in the
+// standard JWKS shape, so it is not wrapped in utils.APIResponse.
+func JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := utils.JWKS()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Server error"})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+}
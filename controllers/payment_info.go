@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"net/http"
 
@@ -11,7 +12,12 @@ import (
 	"gorm.io/gorm"
 )
 
-const vlaKey = "VLA010124"
+// validPaymentInfoKey checks the X-VLA-KEY header against PAYMENT_INFO_KEY. With no
+// key configured the endpoints are closed.
+func validPaymentInfoKey(r *http.Request) bool {
+	key := utils.AppConfig().PaymentInfo.Key.Value()
+	return key != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("X-VLA-KEY")), []byte(key)) == 1
+}
 
 func getSingletonPaymentSettings(db *gorm.DB) (*models.PaymentSettings, error) {
 	var ps models.PaymentSettings
@@ -23,7 +29,7 @@ func getSingletonPaymentSettings(db *gorm.DB) (*models.PaymentSettings, error) {
 
 // GET /api/payment_info
 func GetPaymentInfo(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("X-VLA-KEY") != vlaKey {
+	if !validPaymentInfoKey(r) {
 		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
 		return
 	}
@@ -37,7 +43,7 @@ func GetPaymentInfo(w http.ResponseWriter, r *http.Request) {
 
 // PUT /api/payment_info
 func PutPaymentInfo(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("X-VLA-KEY") != vlaKey {
+	if !validPaymentInfoKey(r) {
 		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
 		return
 	}
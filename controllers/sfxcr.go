@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"project/models"
 	"project/utils"
@@ -9,6 +11,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SFXCRController struct {
@@ -19,17 +22,11 @@ func NewSFXCRController(db *gorm.DB) *SFXCRController {
 	return &SFXCRController{DB: db}
 }
 
+// Partner routes are authenticated by middleware.APIClientMiddleware with the
+// read-pending or callback scope, see routes/routes.go.
+
 // GetPendingWithdrawals - API untuk StoneForm mengambil pending withdrawals
 func (c *SFXCRController) GetPendingWithdrawals(w http.ResponseWriter, r *http.Request) {
-	// Verifikasi API key
-	if !c.verifyAPIKey(r) {
-		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{
-			Success: false,
-			Message: "Unauthorized",
-		})
-		return
-	}
-
 	var withdrawals []struct {
 		UserID        uint    `json:"user_id"`
 		UserName      string  `json:"user_name"`
@@ -55,7 +52,7 @@ func (c *SFXCRController) GetPendingWithdrawals(w http.ResponseWriter, r *http.R
 		Joins("JOIN users ON withdrawals.user_id = users.id").
 		Joins("JOIN bank_accounts ON withdrawals.bank_account_id = bank_accounts.id").
 		Joins("JOIN banks ON bank_accounts.bank_id = banks.id").
-		Where("withdrawals.status = ?", "Pending").
+		Where("withdrawals.status = ?", utils.WithdrawalRequested).
 		Order("withdrawals.created_at ASC").
 		Find(&withdrawals).Error
 
@@ -83,14 +80,6 @@ func (c *SFXCRController) GetPendingWithdrawals(w http.ResponseWriter, r *http.R
 
 // GetPendingWithdrawalByOrderID - API untuk mengambil data withdrawal spesifik
 func (c *SFXCRController) GetPendingWithdrawalByOrderID(w http.ResponseWriter, r *http.Request) {
-	if !c.verifyAPIKey(r) {
-		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{
-			Success: false,
-			Message: "Unauthorized",
-		})
-		return
-	}
-
 	vars := mux.Vars(r)
 	orderID := vars["order_id"]
 
@@ -118,7 +107,7 @@ func (c *SFXCRController) GetPendingWithdrawalByOrderID(w http.ResponseWriter, r
 		Joins("JOIN users ON withdrawals.user_id = users.id").
 		Joins("JOIN bank_accounts ON withdrawals.bank_account_id = bank_accounts.id").
 		Joins("JOIN banks ON bank_accounts.bank_id = banks.id").
-		Where("withdrawals.order_id = ? AND withdrawals.status = ?", orderID, "Pending").
+		Where("withdrawals.order_id = ? AND withdrawals.status = ?", orderID, utils.WithdrawalRequested).
 		First(&withdrawal).Error
 
 	if err != nil {
@@ -145,14 +134,6 @@ func (c *SFXCRController) GetPendingWithdrawalByOrderID(w http.ResponseWriter, r
 
 // WithdrawalCallback - API untuk menerima callback dari StoneForm
 func (c *SFXCRController) WithdrawalCallback(w http.ResponseWriter, r *http.Request) {
-	if !c.verifyAPIKey(r) {
-		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{
-			Success: false,
-			Message: "Unauthorized",
-		})
-		return
-	}
-
 	var callback struct {
 		OrderID string `json:"order_id"`
 		Status  string `json:"status"`
@@ -175,22 +156,8 @@ func (c *SFXCRController) WithdrawalCallback(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Untuk status Failed, hanya kirim response success tanpa update database
-	if callback.Status == "Failed" {
-		utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
-			Success: true,
-			Message: "Rejected berhasil diterima",
-		})
-		return
-	}
-
-	// Untuk status Success, update database
-	tx := c.DB.Begin()
-
-	// Update withdrawal
 	var withdrawal models.Withdrawal
-	if err := tx.Where("order_id = ?", callback.OrderID).First(&withdrawal).Error; err != nil {
-		tx.Rollback()
+	if err := c.DB.Where("order_id = ?", callback.OrderID).First(&withdrawal).Error; err != nil {
 		utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{
 			Success: false,
 			Message: "Withdrawal tidak ditemukan",
@@ -198,56 +165,72 @@ func (c *SFXCRController) WithdrawalCallback(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	withdrawal.Status = callback.Status
-	if err := tx.Save(&withdrawal).Error; err != nil {
-		tx.Rollback()
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
-			Success: false,
-			Message: "Gagal memperbarui status penarikan",
+	// Untuk status Failed, tandai gagal dan kembalikan dana ke income (idempotent).
+	// StoneForm hanya membayar penarikan Requested; penarikan yang sudah disetujui bisa
+	// masih dikirim lewat LinkQu, jadi menolaknya di sini mencegah pembayaran ganda.
+	if callback.Status == "Failed" {
+		refunded := false
+		err := c.DB.Transaction(func(tx *gorm.DB) error {
+			var current models.Withdrawal
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, withdrawal.ID).Error; err != nil {
+				return err
+			}
+			if current.Status != utils.WithdrawalRequested && current.Status != utils.WithdrawalFailed {
+				return fmt.Errorf("%w: %s -> %s", utils.ErrInvalidWithdrawalTransition, current.Status, utils.WithdrawalFailed)
+			}
+			var err error
+			refunded, err = utils.FailWithdrawal(tx, &withdrawal, utils.WithdrawalActorSFXCR, "callback Failed")
+			return err
 		})
-		return
-	}
-
-	// Update related transaction
-	if err := tx.Model(&models.Transaction{}).
-		Where("order_id = ?", callback.OrderID).
-		Update("status", callback.Status).Error; err != nil {
-		tx.Rollback()
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
-			Success: false,
-			Message: "Gagal memperbarui status transaksi",
+		if err != nil {
+			c.writeTransitionError(w, err)
+			return
+		}
+		if refunded {
+			utils.PublishWithdrawalEvent(withdrawal)
+			utils.PublishBalanceEvent(withdrawal.UserID)
+		}
+		utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+			Success: true,
+			Message: "Penarikan ditandai gagal dan dana dikembalikan",
 		})
 		return
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
-			Success: false,
-			Message: "Gagal menyimpan perubahan",
-		})
+	// Untuk status Success, update database
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		// StoneForm pays out Requested withdrawals directly, record it as processed by SFXCR
+		if withdrawal.Status == utils.WithdrawalRequested {
+			if err := utils.TransitionWithdrawal(tx, &withdrawal, utils.WithdrawalProcessing, utils.WithdrawalActorSFXCR, nil, ""); err != nil {
+				return err
+			}
+		}
+		return utils.TransitionWithdrawal(tx, &withdrawal, utils.WithdrawalSuccess, utils.WithdrawalActorSFXCR, nil, "callback Success")
+	})
+	if err != nil {
+		c.writeTransitionError(w, err)
 		return
 	}
 
+	utils.PublishWithdrawalEvent(withdrawal)
+
 	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
 		Success: true,
 		Message: "Penarikan berhasil diproses",
 	})
 }
 
-// verifyAPIKey - Verifikasi API key dari StoneForm
-func (c *SFXCRController) verifyAPIKey(r *http.Request) bool {
-	authHeader := r.Header.Get("Authorization")
-	expectedAPIKey := "pxloNUadKfHzjPVbSxdwjMHgUjlgVoPj" // Simpan di environment variable
-
-	if authHeader == "" {
-		return false
-	}
-
-	// Format: "Bearer {api_key}" atau langsung api_key
-	token := authHeader
-	if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-		token = authHeader[7:]
+// writeTransitionError maps state machine errors to 409, anything else to 500.
+func (c *SFXCRController) writeTransitionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, utils.ErrInvalidWithdrawalTransition) || errors.Is(err, utils.ErrWithdrawalStatusChanged) {
+		utils.WriteJSON(w, http.StatusConflict, utils.APIResponse{
+			Success: false,
+			Message: "Penarikan sudah diproses",
+		})
+		return
 	}
-
-	return token == expectedAPIKey
+	utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{
+		Success: false,
+		Message: "Gagal memperbarui status penarikan",
+	})
 }
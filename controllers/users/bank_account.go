@@ -2,9 +2,12 @@ package users
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 
 	"project/database"
 	"project/models"
@@ -100,11 +103,26 @@ func AddBankAccountHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var user models.User
+	if err := db.Select("id", "name").First(&user, uid).Error; err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Terjadi kesalahan sistem, silakan coba lagi"})
+		return
+	}
+
+	now := time.Now()
 	acc := models.BankAccount{
 		UserID:        uid,
 		BankID:        req.BankID,
 		AccountName:   req.AccountName,
 		AccountNumber: req.AccountNumber,
+		ReviewStatus:  utils.BankReviewNone,
+		ChangedAt:     &now,
+	}
+
+	// Verify ownership with the payout gateway before saving
+	if err := verifyBankAccount(&acc, bank.Code, user.Name); err != nil {
+		writeBankVerificationError(w, err)
+		return
 	}
 
 	if err := db.Create(&acc).Error; err != nil {
@@ -125,11 +143,77 @@ func AddBankAccountHandler(w http.ResponseWriter, r *http.Request) {
 				"bank_code":      bank.Code,
 				"account_name":   acc.AccountName,
 				"account_number": acc.AccountNumber,
+				"holder_name":    utils.GetStringValue(acc.HolderName),
+				"verified":       acc.Verified,
+				"review_status":  acc.ReviewStatus,
 			},
 		},
 	})
 }
 
+var (
+	errBankAccountInvalid          = errors.New("bank_account_invalid")
+	errBankVerificationUnavailable = errors.New("bank_verification_unavailable")
+)
+
+// verifyBankAccount looks up the holder name of acc through the LinkQu inquiry and
+// marks it verified. Without LinkQu credentials the account is saved unverified.
+func verifyBankAccount(acc *models.BankAccount, bankCode, registeredName string) error {
+	acc.HolderName = nil
+	acc.Verified = false
+	acc.VerifiedAt = nil
+	acc.NameMatchScore = 0
+	acc.ReviewStatus = utils.BankReviewNone
+	acc.ReviewedBy = nil
+	acc.ReviewedAt = nil
+
+	if !utils.LinkQuConfigured() {
+		return nil
+	}
+
+	holder, err := utils.InquiryAccountHolder(bankCode, acc.AccountNumber, acc.UserID)
+	if err != nil {
+		if utils.IsRetryableLinkQuError(err) {
+			log.Printf("[bank-verify] user %d inquiry %s: %v", acc.UserID, bankCode, err)
+			return errBankVerificationUnavailable
+		}
+		return errBankAccountInvalid
+	}
+
+	now := time.Now()
+	acc.HolderName = &holder
+	acc.Verified = true
+	acc.VerifiedAt = &now
+	applyBankNameMatch(acc, registeredName)
+	return nil
+}
+
+// applyBankNameMatch scores the verified holder name against both the entered account
+// name and the user's registered name; a low score sends the account to admin review.
+func applyBankNameMatch(acc *models.BankAccount, registeredName string) {
+	if acc.HolderName == nil {
+		return
+	}
+	holder := *acc.HolderName
+	score := min(utils.NameMatchScore(acc.AccountName, holder), utils.NameMatchScore(registeredName, holder))
+	acc.NameMatchScore = score
+	acc.ReviewedBy = nil
+	acc.ReviewedAt = nil
+	if score < utils.BankNameMatchThreshold() {
+		acc.ReviewStatus = utils.BankReviewPending
+	} else {
+		acc.ReviewStatus = utils.BankReviewNone
+	}
+}
+
+func writeBankVerificationError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errBankVerificationUnavailable) {
+		utils.WriteJSON(w, http.StatusServiceUnavailable, utils.APIResponse{Success: false, Message: "Verifikasi rekening sedang tidak tersedia, silakan coba lagi"})
+		return
+	}
+	utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Rekening tidak ditemukan, periksa kembali bank dan nomor rekening"})
+}
+
 // GET /api/users/bank or /api/users/bank/{id}
 func GetBankAccountHandler(w http.ResponseWriter, r *http.Request) {
 	uid, ok := utils.GetUserID(r)
@@ -161,6 +245,8 @@ func GetBankAccountHandler(w http.ResponseWriter, r *http.Request) {
 				"account_number": acc.AccountNumber,
 				"bank_id":        acc.BankID,
 				"bank_name":      bank.Name,
+				"verified":       acc.Verified,
+				"review_status":  acc.ReviewStatus,
 			})
 		}
 		utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
@@ -187,6 +273,8 @@ func GetBankAccountHandler(w http.ResponseWriter, r *http.Request) {
 			"account_number": acc.AccountNumber,
 			"bank_id":        acc.BankID,
 			"bank_name":      bank.Name,
+			"verified":       acc.Verified,
+			"review_status":  acc.ReviewStatus,
 		},
 	}
 	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
@@ -225,25 +313,87 @@ func EditBankAccountHandler(w http.ResponseWriter, r *http.Request) {
 		utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "Rekening tidak ditemukan"})
 		return
 	}
-	update := map[string]interface{}{}
-	if req.AccountName != "" {
-		update["account_name"] = req.AccountName
+	req.AccountName = strings.TrimSpace(req.AccountName)
+	req.AccountNumber = strings.TrimSpace(req.AccountNumber)
+
+	nameChanged := req.AccountName != "" && req.AccountName != acc.AccountName
+	numberChanged := req.AccountNumber != "" && req.AccountNumber != acc.AccountNumber
+	bankChanged := req.BankID != 0 && req.BankID != acc.BankID
+	if !nameChanged && !numberChanged && !bankChanged {
+		utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Rekening berhasil diupdate"})
+		return
+	}
+
+	if nameChanged {
+		if len(req.AccountName) < 3 || len(req.AccountName) > 100 {
+			utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Nama rekening harus 3-100 karakter dan hanya berisi huruf"})
+			return
+		}
+		if ok, _ := regexp.MatchString(`^[A-Za-z ]+$`, req.AccountName); !ok {
+			utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Nama rekening harus 3-100 karakter dan hanya berisi huruf"})
+			return
+		}
+		acc.AccountName = req.AccountName
 	}
-	if req.AccountNumber != "" {
-		update["account_number"] = req.AccountNumber
+	if numberChanged {
+		if len(req.AccountNumber) < 5 || len(req.AccountNumber) > 20 {
+			utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Nomor rekening tidak valid"})
+			return
+		}
+		if ok, _ := regexp.MatchString(`^[A-Za-z0-9]+$`, req.AccountNumber); !ok {
+			utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Nomor rekening tidak valid"})
+			return
+		}
+		acc.AccountNumber = req.AccountNumber
 	}
-	if req.BankID != 0 {
-		update["bank_id"] = req.BankID
+	if bankChanged {
+		acc.BankID = req.BankID
 	}
-	if len(update) == 0 {
-		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Minimum one field must be filled"})
+
+	var bank models.Bank
+	if err := db.First(&bank, acc.BankID).Error; err != nil || bank.Status != "Active" {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Bank yang dipilih tidak tersedia"})
 		return
 	}
-	if err := db.Model(&acc).Updates(update).Error; err != nil {
+
+	var user models.User
+	if err := db.Select("id", "name").First(&user, uid).Error; err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Terjadi kesalahan sistem, silakan coba lagi"})
+		return
+	}
+
+	if numberChanged || bankChanged {
+		// Duplicate check: user_id + bank_id + account_number
+		var dup int64
+		db.Model(&models.BankAccount{}).Where("user_id = ? AND bank_id = ? AND account_number = ? AND id <> ?", uid, acc.BankID, acc.AccountNumber, acc.ID).Count(&dup)
+		if dup > 0 {
+			utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Rekening ini sudah pernah didaftarkan"})
+			return
+		}
+		// A different account must be verified again
+		if err := verifyBankAccount(&acc, bank.Code, user.Name); err != nil {
+			writeBankVerificationError(w, err)
+			return
+		}
+		now := time.Now()
+		acc.ChangedAt = &now
+	} else {
+		applyBankNameMatch(&acc, user.Name)
+	}
+
+	if err := db.Model(&acc).Select("account_name", "account_number", "bank_id", "holder_name", "verified", "verified_at",
+		"name_match_score", "review_status", "reviewed_by", "reviewed_at", "changed_at").Updates(&acc).Error; err != nil {
 		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal mengupdate rekening"})
 		return
 	}
-	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Rekening berhasil diupdate"})
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Rekening berhasil diupdate",
+		Data: map[string]interface{}{
+			"verified":      acc.Verified,
+			"review_status": acc.ReviewStatus,
+		},
+	})
 }
 
 // DELETE /api/users/bank
@@ -154,77 +154,6 @@ func GetBinaryStructureHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// getMembersAtSpecificLevel mengumpulkan anggota di level tertentu saja (bukan semua level sampai target)
-func getMembersAtSpecificLevel(leftID, rightID *uint, db *gorm.DB, targetLevel int) []BinaryMember {
-	return getMembersAtLevelRecursive(leftID, rightID, db, 1, targetLevel)
-}
-
-// getMembersAtLevelRecursive helper rekursif untuk mengumpulkan anggota di level tertentu
-func getMembersAtLevelRecursive(leftID, rightID *uint, db *gorm.DB, currentLevel, targetLevel int) []BinaryMember {
-	if currentLevel > targetLevel {
-		return []BinaryMember{}
-	}
-
-	var members []BinaryMember
-
-	// Jika sudah sampai target level, ambil anggota di level ini saja
-	if currentLevel == targetLevel {
-		// Process left side
-		if leftID != nil {
-			var leftUser models.User
-			if err := db.Select("id, name, number").Where("id = ?", *leftID).First(&leftUser).Error; err == nil {
-				// Calculate omset for this member (hanya dari investasi user tersebut, tanpa downline)
-				memberOmset, _ := utils.CalculateUserOmsetOnly(leftUser.ID)
-				members = append(members, BinaryMember{
-					UserID:   leftUser.ID,
-					Name:     leftUser.Name,
-					Number:   leftUser.Number,
-					Omset:    memberOmset,
-					Position: "left",
-				})
-			}
-		}
-
-		// Process right side
-		if rightID != nil {
-			var rightUser models.User
-			if err := db.Select("id, name, number").Where("id = ?", *rightID).First(&rightUser).Error; err == nil {
-				// Calculate omset for this member (hanya dari investasi user tersebut, tanpa downline)
-				memberOmset, _ := utils.CalculateUserOmsetOnly(rightUser.ID)
-				members = append(members, BinaryMember{
-					UserID:   rightUser.ID,
-					Name:     rightUser.Name,
-					Number:   rightUser.Number,
-					Omset:    memberOmset,
-					Position: "right",
-				})
-			}
-		}
-		return members
-	}
-
-	// Jika belum sampai target level, recurse ke level berikutnya
-	// Process left side
-	if leftID != nil {
-		var leftNode models.BinaryNode
-		if err := db.Where("user_id = ?", *leftID).First(&leftNode).Error; err == nil {
-			leftMembers := getMembersAtLevelRecursive(leftNode.LeftID, leftNode.RightID, db, currentLevel+1, targetLevel)
-			members = append(members, leftMembers...)
-		}
-	}
-
-	// Process right side
-	if rightID != nil {
-		var rightNode models.BinaryNode
-		if err := db.Where("user_id = ?", *rightID).First(&rightNode).Error; err == nil {
-			rightMembers := getMembersAtLevelRecursive(rightNode.LeftID, rightNode.RightID, db, currentLevel+1, targetLevel)
-			members = append(members, rightMembers...)
-		}
-	}
-
-	return members
-}
-
 // GET /api/users/binary/omset
 func GetBinaryOmsetHandler(w http.ResponseWriter, r *http.Request) {
 	uid, ok := utils.GetUserID(r)
@@ -264,9 +193,11 @@ func GetBinaryOmsetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	level1Count := countMembersAtSpecificLevel(binaryNode.LeftID, binaryNode.RightID, db, 1)
-	level2Count := countMembersAtSpecificLevel(binaryNode.LeftID, binaryNode.RightID, db, 2)
-	level3Count := countMembersAtSpecificLevel(binaryNode.LeftID, binaryNode.RightID, db, 3)
+	levelCounts, err := utils.CountBinaryMembersByLevel(db, uid, 3)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Terjadi kesalahan"})
+		return
+	}
 
 	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
 		Success: true,
@@ -275,89 +206,13 @@ func GetBinaryOmsetHandler(w http.ResponseWriter, r *http.Request) {
 			OmsetLeft:   omsetLeft,
 			OmsetRight:  omsetRight,
 			TotalOmset:  totalOmset,
-			Level1Count: level1Count,
-			Level2Count: level2Count,
-			Level3Count: level3Count,
+			Level1Count: levelCounts[1],
+			Level2Count: levelCounts[2],
+			Level3Count: levelCounts[3],
 		},
 	})
 }
 
-// countMembersAtSpecificLevel menghitung jumlah member di level tertentu saja (bukan akumulasi)
-// Level 1 = 2 anggota (left, right dari root)
-// Level 2 = 4 anggota (anak dari level 1)
-// Level 3 = 8 anggota (anak dari level 2)
-func countMembersAtSpecificLevel(leftID, rightID *uint, db *gorm.DB, targetLevel int) int {
-	if targetLevel == 1 {
-		// Level 1: hitung left dan right dari root
-		count := 0
-		if leftID != nil {
-			count++
-		}
-		if rightID != nil {
-			count++
-		}
-		return count
-	}
-
-	// Untuk level 2 dan 3, kita perlu recurse ke level sebelumnya
-	// Level 2: hitung semua anak dari level 1
-	// Level 3: hitung semua anak dari level 2
-	return countMembersAtLevelRecursive(leftID, rightID, db, 1, targetLevel)
-}
-
-// countMembersAtLevelRecursive helper rekursif untuk menghitung member di level tertentu saja
-func countMembersAtLevelRecursive(leftID, rightID *uint, db *gorm.DB, currentLevel, targetLevel int) int {
-	if currentLevel >= targetLevel {
-		return 0
-	}
-
-	// Jika currentLevel + 1 == targetLevel, hitung anak-anak dari node ini
-	if currentLevel+1 == targetLevel {
-		count := 0
-		// Hitung anak dari left
-		if leftID != nil {
-			var leftNode models.BinaryNode
-			if err := db.Where("user_id = ?", *leftID).First(&leftNode).Error; err == nil {
-				if leftNode.LeftID != nil {
-					count++
-				}
-				if leftNode.RightID != nil {
-					count++
-				}
-			}
-		}
-		// Hitung anak dari right
-		if rightID != nil {
-			var rightNode models.BinaryNode
-			if err := db.Where("user_id = ?", *rightID).First(&rightNode).Error; err == nil {
-				if rightNode.LeftID != nil {
-					count++
-				}
-				if rightNode.RightID != nil {
-					count++
-				}
-			}
-		}
-		return count
-	}
-
-	// Jika belum sampai target level, recurse ke level berikutnya
-	count := 0
-	if leftID != nil {
-		var leftNode models.BinaryNode
-		if err := db.Where("user_id = ?", *leftID).First(&leftNode).Error; err == nil {
-			count += countMembersAtLevelRecursive(leftNode.LeftID, leftNode.RightID, db, currentLevel+1, targetLevel)
-		}
-	}
-	if rightID != nil {
-		var rightNode models.BinaryNode
-		if err := db.Where("user_id = ?", *rightID).First(&rightNode).Error; err == nil {
-			count += countMembersAtLevelRecursive(rightNode.LeftID, rightNode.RightID, db, currentLevel+1, targetLevel)
-		}
-	}
-	return count
-}
-
 // GET /api/users/rewards
 func GetRewardsHandler(w http.ResponseWriter, r *http.Request) {
 	uid, ok := utils.GetUserID(r)
@@ -6,12 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"project/database"
+	"project/middleware"
 	"project/models"
 	"project/utils"
 
@@ -337,15 +337,14 @@ func LinkQuCallbackHandler(w http.ResponseWriter, r *http.Request) {
 	// Validasi header client-id dan client-secret
 	headerClientID := strings.TrimSpace(r.Header.Get("client-id"))
 	headerClientSecret := strings.TrimSpace(r.Header.Get("client-secret"))
-	envClientID := strings.TrimSpace(os.Getenv("LINKQU_CLIENT_ID"))
-	envClientSecret := strings.TrimSpace(os.Getenv("LINKQU_CLIENT_SECRET"))
-
 	if headerClientID == "" || headerClientSecret == "" {
+		middleware.ReportAbuse(r, middleware.AbuseSignalCallbackAuth)
 		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Missing client-id or client-secret header"})
 		return
 	}
 
-	if headerClientID != envClientID || headerClientSecret != envClientSecret {
+	if !utils.LinkQuCallbackAuthorized(headerClientID, headerClientSecret) {
+		middleware.ReportAbuse(r, middleware.AbuseSignalCallbackAuth)
 		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Invalid client-id or client-secret"})
 		return
 	}
@@ -433,6 +432,10 @@ func LinkQuCallbackHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		deposit.Status = "Success"
+		utils.PublishDepositEvent(deposit)
+		utils.PublishBalanceEvent(deposit.UserID)
+
 		utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "OK"})
 		return
 	}
@@ -440,7 +443,10 @@ func LinkQuCallbackHandler(w http.ResponseWriter, r *http.Request) {
 	// Handle status FAILED
 	if status == "FAILED" {
 		if deposit.Status == "Pending" {
-			_ = db.Model(&models.Deposit{}).Where("id = ?", deposit.ID).Update("status", "Failed")
+			if err := db.Model(&models.Deposit{}).Where("id = ?", deposit.ID).Update("status", "Failed").Error; err == nil {
+				deposit.Status = "Failed"
+				utils.PublishDepositEvent(deposit)
+			}
 		}
 		utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "OK"})
 		return
@@ -467,12 +473,13 @@ type linkQuConfig struct {
 }
 
 func getLinkQuConfig() (*linkQuConfig, error) {
-	baseURL := strings.TrimRight(os.Getenv("LINKQU_BASE_URL"), "/")
-	username := os.Getenv("LINKQU_USERNAME")
-	pin := os.Getenv("LINKQU_PIN")
-	clientID := os.Getenv("LINKQU_CLIENT_ID")
-	clientSecret := os.Getenv("LINKQU_CLIENT_SECRET")
-	callbackURL := os.Getenv("LINKQU_CALLBACK_PAYMENT")
+	lq := utils.AppConfig().LinkQu
+	baseURL := strings.TrimRight(lq.BaseURL, "/")
+	username := lq.Username
+	pin := lq.PIN.Value()
+	clientID := lq.ClientID
+	clientSecret := lq.ClientSecret.Value()
+	callbackURL := lq.CallbackPayment
 
 	if baseURL == "" || username == "" || pin == "" || clientID == "" || clientSecret == "" || callbackURL == "" {
 		return nil, errors.New("Konfigurasi LinkQu belum lengkap")
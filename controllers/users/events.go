@@ -0,0 +1,68 @@
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"project/utils"
+)
+
+// sseHeartbeatInterval keeps proxies (nginx) from closing idle streams
+const sseHeartbeatInterval = 25 * time.Second
+
+// GET /api/users/events
+// Server-Sent Events stream for deposit, withdrawal and balance updates.
+func EventsHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := utils.GetUserID(r)
+	if !ok || uid == 0 {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// Lift the server WriteTimeout for this long-lived response
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	ctx := r.Context()
+	events, cancel := utils.SubscribeUserEvents(ctx, uid)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Tell EventSource clients how long to wait before reconnecting
+	fmt.Fprint(w, "retry: 5000\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case ev := <-events:
+			payload, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, payload); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
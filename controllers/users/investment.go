@@ -1,11 +1,11 @@
 package users
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -459,7 +459,8 @@ func GetInvestmentHandler(w http.ResponseWriter, r *http.Request) {
 // POST /api/cron/daily-returns
 func CronDailyReturnsHandler(w http.ResponseWriter, r *http.Request) {
 	key := r.Header.Get("X-CRON-KEY")
-	if key == "" || key != os.Getenv("CRON_KEY") {
+	expected := utils.AppConfig().Cron.Key.Value()
+	if key == "" || expected == "" || subtle.ConstantTimeCompare([]byte(key), []byte(expected)) != 1 {
 		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
 		return
 	}
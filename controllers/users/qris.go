@@ -0,0 +1,119 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"project/database"
+	"project/models"
+	"project/utils"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// GET /api/users/payments/{order_id}/qr.png
+// GET /api/users/payments/{order_id}/qr.svg
+// Renders the QRIS payload of a pending deposit with merchant name and amount caption.
+func DepositQRHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := utils.GetUserID(r)
+	if !ok || uid == 0 {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+		return
+	}
+
+	vars := mux.Vars(r)
+	orderID := strings.TrimSpace(vars["order_id"])
+	format := vars["format"]
+	if orderID == "" {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Order ID tidak valid"})
+		return
+	}
+
+	db := database.DB
+	var deposit models.Deposit
+	if err := db.Where("order_id = ? AND user_id = ?", orderID, uid).First(&deposit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "Data isi ulang tidak ditemukan"})
+			return
+		}
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Terjadi kesalahan"})
+		return
+	}
+
+	if deposit.PaymentMethod != "QRIS" || deposit.PaymentCode == nil || *deposit.PaymentCode == "" {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Isi ulang ini tidak menggunakan QRIS"})
+		return
+	}
+
+	ttl := time.Until(deposit.ExpiredAt)
+	if deposit.Status != "Pending" || ttl <= 0 {
+		utils.WriteJSON(w, http.StatusGone, utils.APIResponse{Success: false, Message: "QRIS sudah tidak berlaku"})
+		return
+	}
+
+	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+	size = utils.ClampQRSize(size)
+
+	contentType := "image/png"
+	if format == "svg" {
+		contentType = "image/svg+xml"
+	}
+	etag := fmt.Sprintf(`"%s-%s-%d"`, deposit.OrderID, format, size)
+
+	// Cache until the deposit expires; the QR content never changes for an order
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(ttl.Seconds())))
+	w.Header().Set("Expires", deposit.ExpiredAt.UTC().Format(http.TimeFormat))
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	cacheKey := fmt.Sprintf("qris:%s:%s:%d", deposit.OrderID, format, size)
+	if utils.RedisClient != nil {
+		if cached, err := utils.RedisClient.Get(context.Background(), cacheKey).Bytes(); err == nil && len(cached) > 0 {
+			w.Header().Set("Content-Type", contentType)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(cached)
+			return
+		}
+	}
+
+	merchant := utils.QRISMerchantName(*deposit.PaymentCode)
+	if merchant == "" {
+		var setting models.Setting
+		if err := db.Select("name").First(&setting).Error; err == nil {
+			merchant = setting.Name
+		}
+	}
+	caption := []string{utils.FormatRupiah(deposit.Amount)}
+	if merchant != "" {
+		caption = append([]string{merchant}, caption...)
+	}
+
+	var img []byte
+	var err error
+	if format == "svg" {
+		img, err = utils.RenderQRSVG(*deposit.PaymentCode, size, caption)
+	} else {
+		img, err = utils.RenderQRPNG(*deposit.PaymentCode, size, caption)
+	}
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Gagal membuat gambar QRIS"})
+		return
+	}
+
+	if utils.RedisClient != nil {
+		_ = utils.RedisClient.Set(context.Background(), cacheKey, img, ttl).Err()
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(img)
+}
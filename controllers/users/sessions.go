@@ -0,0 +1,78 @@
+package users
+
+import (
+	"net/http"
+	"time"
+
+	"project/database"
+	"project/models"
+	"project/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type UserSessionResponse struct {
+	ID         string     `json:"id"`
+	DeviceName string     `json:"device_name"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	Current    bool       `json:"current"`
+}
+
+// GET /api/users/sessions
+// Lists the devices currently signed in (one entry per active refresh token session).
+func ListSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := utils.GetUserID(r)
+	if !ok || uid == 0 {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+		return
+	}
+
+	var tokens []models.RefreshToken
+	if err := database.DB.
+		Where("user_id = ? AND revoked = ? AND expires_at > ?", uid, false, time.Now()).
+		Order("last_used_at DESC").
+		Find(&tokens).Error; err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Server error"})
+		return
+	}
+
+	currentJTI := utils.GetTokenJTI(r)
+	response := make([]UserSessionResponse, 0, len(tokens))
+	for _, t := range tokens {
+		response = append(response, UserSessionResponse{
+			ID:         t.SessionID,
+			DeviceName: t.DeviceName,
+			UserAgent:  t.UserAgent,
+			IP:         t.IP,
+			LastUsedAt: t.LastUsedAt,
+			ExpiresAt:  t.ExpiresAt,
+			Current:    currentJTI != "" && t.AccessJTI == currentJTI,
+		})
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Successfully", Data: response})
+}
+
+// DELETE /api/users/sessions/{id}
+// Signs one device out: revokes its refresh token and blacklists its access token.
+func RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := utils.GetUserID(r)
+	if !ok || uid == 0 {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+		return
+	}
+	sessionID := mux.Vars(r)["id"]
+
+	n, err := utils.RevokeUserSessions(database.DB, uid, sessionID)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Server error"})
+		return
+	}
+	if n == 0 {
+		utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "Sesi tidak ditemukan"})
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Sesi berhasil diakhiri"})
+}
@@ -0,0 +1,210 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"project/database"
+	"project/middleware"
+	"project/models"
+	"project/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type createPINRequest struct {
+	Password        string `json:"password"`
+	PIN             string `json:"pin"`
+	PINConfirmation string `json:"pin_confirmation"`
+}
+
+type changePINRequest struct {
+	CurrentPIN      string `json:"current_pin"`
+	PIN             string `json:"pin"`
+	PINConfirmation string `json:"pin_confirmation"`
+}
+
+type resetPINRequest struct {
+	OTP             string `json:"otp"`
+	PIN             string `json:"pin"`
+	PINConfirmation string `json:"pin_confirmation"`
+}
+
+func writePINError(w http.ResponseWriter, err error) {
+	status, message := utils.TransactionPINErrorStatus(err)
+	utils.WriteJSON(w, status, utils.APIResponse{Success: false, Message: message})
+}
+
+func writePINOTPError(w http.ResponseWriter, err error) {
+	status, message := utils.OTPErrorStatus(err)
+	var data interface{}
+	var cooldown *utils.OTPCooldownError
+	if errors.As(err, &cooldown) {
+		data = map[string]interface{}{"retry_after_seconds": int(cooldown.RetryAfter.Seconds() + 0.5)}
+	}
+	utils.WriteJSON(w, status, utils.APIResponse{Success: false, Message: message, Data: data})
+}
+
+// checkNewPIN validates a new PIN and its confirmation.
+func checkNewPIN(pin, confirmation string) error {
+	if err := utils.ValidateTransactionPIN(pin); err != nil {
+		return err
+	}
+	if pin != confirmation {
+		return utils.ErrPINMismatch
+	}
+	return nil
+}
+
+// GET /api/users/pin
+func TransactionPINStatusHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := utils.GetUserID(r)
+	if !ok || uid == 0 {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+		return
+	}
+	var user models.User
+	if err := database.DB.Select("id", "transaction_pin", "pin_updated_at").First(&user, uid).Error; err != nil {
+		utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "User not found"})
+		return
+	}
+	locked, retry := middleware.IsPINLocked(uid)
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Successfully",
+		Data: map[string]interface{}{
+			"has_pin":             user.TransactionPIN != nil && *user.TransactionPIN != "",
+			"updated_at":          user.PINUpdatedAt,
+			"locked":              locked,
+			"retry_after_seconds": int(retry.Seconds()),
+		},
+	})
+}
+
+// POST /api/users/pin
+// Creates the first PIN; the account password proves the caller is the owner.
+func CreateTransactionPINHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := utils.GetUserID(r)
+	if !ok || uid == 0 {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+		return
+	}
+	var req createPINRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Invalid request"})
+		return
+	}
+	var user models.User
+	if err := database.DB.Select("id", "password", "transaction_pin").First(&user, uid).Error; err != nil {
+		utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "User not found"})
+		return
+	}
+	if user.TransactionPIN != nil && *user.TransactionPIN != "" {
+		writePINError(w, utils.ErrPINAlreadySet)
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Kata sandi saat ini tidak cocok"})
+		return
+	}
+	if err := checkNewPIN(req.PIN, req.PINConfirmation); err != nil {
+		writePINError(w, err)
+		return
+	}
+	if err := utils.CreateTransactionPIN(database.DB, uid, req.PIN); err != nil {
+		writePINError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, utils.APIResponse{Success: true, Message: "PIN transaksi berhasil dibuat"})
+}
+
+// PUT /api/users/pin
+func ChangeTransactionPINHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := utils.GetUserID(r)
+	if !ok || uid == 0 {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+		return
+	}
+	var req changePINRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Invalid request"})
+		return
+	}
+	if err := checkNewPIN(req.PIN, req.PINConfirmation); err != nil {
+		writePINError(w, err)
+		return
+	}
+	if !middleware.CheckTransactionPIN(w, r, uid, req.CurrentPIN) {
+		return
+	}
+	if err := utils.SetTransactionPIN(database.DB, uid, req.PIN); err != nil {
+		writePINError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "PIN transaksi berhasil diubah"})
+}
+
+// POST /api/users/pin/otp
+// Sends a PIN reset code to the user's registered number.
+func TransactionPINOTPHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := utils.GetUserID(r)
+	if !ok || uid == 0 {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+		return
+	}
+	var user models.User
+	if err := database.DB.Select("id", "number").First(&user, uid).Error; err != nil {
+		utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "User not found"})
+		return
+	}
+	expiresAt, err := utils.IssueOTP(r.Context(), database.DB, user.Number, utils.OTPPurposePINReset)
+	if err != nil {
+		writePINOTPError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Kode OTP telah dikirim",
+		Data: map[string]interface{}{
+			"expires_in":   int(time.Until(expiresAt).Seconds()),
+			"resend_after": int(utils.OTPResendCooldown().Seconds()),
+		},
+	})
+}
+
+// POST /api/users/pin/reset
+// Sets a new PIN after verifying the code from /pin/otp and clears the PIN lockout.
+func ResetTransactionPINHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := utils.GetUserID(r)
+	if !ok || uid == 0 {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+		return
+	}
+	var req resetPINRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Invalid request"})
+		return
+	}
+	if err := checkNewPIN(req.PIN, req.PINConfirmation); err != nil {
+		writePINError(w, err)
+		return
+	}
+	var user models.User
+	if err := database.DB.Select("id", "number").First(&user, uid).Error; err != nil {
+		utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "User not found"})
+		return
+	}
+	if err := utils.VerifyOTP(database.DB, user.Number, utils.OTPPurposePINReset, strings.TrimSpace(req.OTP)); err != nil {
+		writePINOTPError(w, err)
+		return
+	}
+	if err := utils.SetTransactionPIN(database.DB, uid, req.PIN); err != nil {
+		writePINError(w, err)
+		return
+	}
+	middleware.ResetFailedPIN(uid)
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "PIN transaksi berhasil direset"})
+}
@@ -0,0 +1,143 @@
+package users
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"project/database"
+	"project/models"
+	"project/utils"
+)
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+func writeTwoFactorError(w http.ResponseWriter, err error) {
+	status, message := utils.TwoFactorErrorStatus(err)
+	utils.WriteJSON(w, status, utils.APIResponse{Success: false, Message: message})
+}
+
+// GET /api/users/2fa
+func TwoFactorStatusHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := utils.GetUserID(r)
+	if !ok || uid == 0 {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+		return
+	}
+	tf, err := utils.GetTwoFactor(database.DB, utils.TwoFactorOwnerUser, uid)
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+	data := map[string]interface{}{"enabled": false}
+	if tf != nil && tf.Enabled {
+		data["enabled"] = true
+		data["enabled_at"] = tf.EnabledAt
+		data["recovery_codes_remaining"] = utils.RemainingRecoveryCodes(database.DB, tf.ID)
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Successfully", Data: data})
+}
+
+// POST /api/users/2fa/setup
+// Returns a new secret and otpauth URI; 2FA is active only after /2fa/enable.
+func TwoFactorSetupHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := utils.GetUserID(r)
+	if !ok || uid == 0 {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+		return
+	}
+	var user models.User
+	if err := database.DB.Select("id, number").First(&user, uid).Error; err != nil {
+		utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "User not found"})
+		return
+	}
+	tf, err := utils.BeginTwoFactorSetup(database.DB, utils.TwoFactorOwnerUser, uid)
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Pindai kode QR lalu masukkan kode dari aplikasi autentikator",
+		Data: map[string]interface{}{
+			"secret":      tf.Secret,
+			"otpauth_uri": utils.TOTPProvisioningURI(utils.TOTPIssuer(), user.Number, tf.Secret),
+		},
+	})
+}
+
+// POST /api/users/2fa/enable
+func TwoFactorEnableHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := utils.GetUserID(r)
+	if !ok || uid == 0 {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+		return
+	}
+	var req twoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Invalid request"})
+		return
+	}
+	codes, err := utils.ConfirmTwoFactor(database.DB, utils.TwoFactorOwnerUser, uid, req.Code)
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Autentikasi dua faktor berhasil diaktifkan. Simpan kode pemulihan Anda.",
+		Data:    map[string]interface{}{"recovery_codes": codes},
+	})
+}
+
+// POST /api/users/2fa/disable
+func TwoFactorDisableHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := utils.GetUserID(r)
+	if !ok || uid == 0 {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+		return
+	}
+	var req twoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Invalid request"})
+		return
+	}
+	if err := utils.VerifyTwoFactor(database.DB, utils.TwoFactorOwnerUser, uid, req.Code); err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+	if err := utils.DisableTwoFactor(database.DB, utils.TwoFactorOwnerUser, uid); err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{Success: true, Message: "Autentikasi dua faktor dinonaktifkan"})
+}
+
+// POST /api/users/2fa/recovery-codes
+func TwoFactorRecoveryCodesHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := utils.GetUserID(r)
+	if !ok || uid == 0 {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+		return
+	}
+	var req twoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Invalid request"})
+		return
+	}
+	if err := utils.VerifyTwoFactor(database.DB, utils.TwoFactorOwnerUser, uid, req.Code); err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+	codes, err := utils.RegenerateRecoveryCodes(database.DB, utils.TwoFactorOwnerUser, uid)
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Kode pemulihan berhasil dibuat ulang",
+		Data:    map[string]interface{}{"recovery_codes": codes},
+	})
+}
@@ -5,13 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"project/database"
 	"project/models"
 	"project/utils"
 	"strconv"
 	"time"
 
+	"github.com/gorilla/mux"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -64,11 +64,11 @@ func WithdrawalHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if user has already made a withdrawal today
+	// Check if user has already made a withdrawal today (cancelled requests don't count)
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
 	endOfDay := startOfDay.Add(24 * time.Hour)
 	var todayWithdrawals int64
-	if err := db.Model(&models.Withdrawal{}).Where("user_id = ? AND created_at BETWEEN ? AND ?", uid, startOfDay, endOfDay).Count(&todayWithdrawals).Error; err != nil {
+	if err := db.Model(&models.Withdrawal{}).Where("user_id = ? AND status <> ? AND created_at BETWEEN ? AND ?", uid, utils.WithdrawalCancelled, startOfDay, endOfDay).Count(&todayWithdrawals).Error; err != nil {
 		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Terjadi kesalahan sistem, silakan coba lagi"})
 		return
 	}
@@ -87,6 +87,10 @@ func WithdrawalHandler(w http.ResponseWriter, r *http.Request) {
 		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Terjadi kesalahan sistem, silakan coba lagi"})
 		return
 	}
+	if acc.ReviewStatus == utils.BankReviewRejected {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Rekening ini tidak dapat digunakan, silakan gunakan rekening lain"})
+		return
+	}
 	if acc.Bank == nil || acc.Bank.Status != "Active" {
 		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Layanan bank ini sedang dalam pemeliharaan"})
 		return
@@ -97,6 +101,14 @@ func WithdrawalHandler(w http.ResponseWriter, r *http.Request) {
 	finalAmount := req.Amount - charge
 	orderID := utils.GenerateOrderID(uid)
 
+	// Score the request; risky ones are created on Hold for admin review
+	riskInput, err := utils.CollectWithdrawalRiskInput(db, uid, acc, req.Amount)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Terjadi kesalahan sistem, silakan coba lagi"})
+		return
+	}
+	risk := utils.ScoreWithdrawalRisk(riskInput, utils.LoadWithdrawalRiskConfig())
+
 	// Sentinel error for insufficient balance
 	var errInsufficientBalance = errors.New("insufficient_balance")
 
@@ -115,7 +127,7 @@ func WithdrawalHandler(w http.ResponseWriter, r *http.Request) {
 			return err
 		}
 
-		// Create withdrawal pending
+		// Create withdrawal request
 		wd = models.Withdrawal{
 			UserID:        uid,
 			BankAccountID: acc.ID,
@@ -123,11 +135,21 @@ func WithdrawalHandler(w http.ResponseWriter, r *http.Request) {
 			Charge:        charge,
 			FinalAmount:   finalAmount,
 			OrderID:       orderID,
-			Status:        "Pending",
+			Status:        utils.WithdrawalRequested,
+			RiskScore:     risk.Score,
+			RiskReasons:   utils.EncodeRiskReasons(risk.Reasons),
 		}
 		if err := tx.Create(&wd).Error; err != nil {
 			return err
 		}
+		if err := utils.RecordWithdrawalRequested(tx, &wd); err != nil {
+			return err
+		}
+		if risk.Hold {
+			if err := utils.TransitionWithdrawal(tx, &wd, utils.WithdrawalHold, utils.WithdrawalActorSystem, nil, utils.RiskReasonSummary(risk.Reasons)); err != nil {
+				return err
+			}
+		}
 
 		// Create corresponding debit transaction (Pending)
 		msg := fmt.Sprintf("Penarikan ke %s %s", acc.Bank.Name, MaskAccountNumber(acc.AccountNumber))
@@ -155,6 +177,9 @@ func WithdrawalHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	utils.PublishWithdrawalEvent(wd)
+	utils.PublishBalanceEvent(uid)
+
 	resp := map[string]interface{}{
 		"withdrawal": map[string]interface{}{
 			"id":             wd.ID,
@@ -166,6 +191,7 @@ func WithdrawalHandler(w http.ResponseWriter, r *http.Request) {
 			"account_name":   acc.AccountName,
 			"account_number": MaskAccountNumber(acc.AccountNumber),
 			"status":         wd.Status,
+			"status_label":   utils.WithdrawalStatusLabel(wd.Status),
 			"created_at":     wd.CreatedAt.Format("2006-01-02 15:04:05"),
 		},
 	}
@@ -202,18 +228,44 @@ func ListWithdrawalHandler(w http.ResponseWriter, r *http.Request) {
 		utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Failed to retrieve withdrawal data"})
 		return
 	}
+	// Load status history for the whole page in one query
+	ids := make([]uint, 0, len(withdrawals))
+	for _, wd := range withdrawals {
+		ids = append(ids, wd.ID)
+	}
+	historyByID := make(map[uint][]map[string]interface{})
+	if len(ids) > 0 {
+		var history []models.WithdrawalStatusHistory
+		db.Where("withdrawal_id IN ?", ids).Order("id ASC").Find(&history)
+		for _, h := range history {
+			historyByID[h.WithdrawalID] = append(historyByID[h.WithdrawalID], map[string]interface{}{
+				"status":       h.ToStatus,
+				"status_label": utils.WithdrawalStatusLabel(h.ToStatus),
+				"time":         h.CreatedAt.Format("2006-01-02 15:04:05"),
+			})
+		}
+	}
+
 	var resp []map[string]interface{}
 	for _, wd := range withdrawals {
 		var acc models.BankAccount
 		var bank models.Bank
 		db.First(&acc, wd.BankAccountID)
 		db.First(&bank, acc.BankID)
+		history := historyByID[wd.ID]
+		if history == nil {
+			history = []map[string]interface{}{}
+		}
 		resp = append(resp, map[string]interface{}{
+			"id":              wd.ID,
+			"cancellable":     utils.CanTransitionWithdrawal(wd.Status, utils.WithdrawalCancelled),
 			"amount":          wd.Amount,
 			"charge":          wd.Charge,
 			"final_amount":    wd.FinalAmount,
 			"order_id":        wd.OrderID,
 			"status":          wd.Status,
+			"status_label":    utils.WithdrawalStatusLabel(wd.Status),
+			"status_history":  history,
 			"withdrawal_time": wd.CreatedAt.Format("2006-01-02 15:04:05"),
 			"account_name":    acc.AccountName,
 			"account_number":  acc.AccountNumber,
@@ -227,23 +279,74 @@ func ListWithdrawalHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// Helpers
+// DELETE /api/users/withdrawal/{id}
+// Cancels the user's own withdrawal while it is still awaiting review and refunds the
+// amount to income.
+func CancelWithdrawalHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := utils.GetUserID(r)
+	if !ok || uid == 0 {
+		utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "ID penarikan tidak valid"})
+		return
+	}
 
-func CalculateWithdrawalCharge(amount float64) float64 {
-	percent := getWithdrawalChargePercent()
-	return round2(amount * (percent / 100.0))
-}
+	var errNotCancellable = errors.New("not_cancellable")
 
-func getWithdrawalChargePercent() float64 {
-	s := os.Getenv("WITHDRAWAL_CHARGE_PERCENT")
-	if s == "" {
-		return 10.0
-	}
-	v, err := strconv.ParseFloat(s, 64)
+	var wd models.Withdrawal
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the user row first, in the same order as WithdrawalHandler
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, uid).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", id, uid).First(&wd).Error; err != nil {
+			return err
+		}
+		if !utils.CanTransitionWithdrawal(wd.Status, utils.WithdrawalCancelled) {
+			return errNotCancellable
+		}
+		if err := utils.TransitionWithdrawal(tx, &wd, utils.WithdrawalCancelled, utils.WithdrawalActorUser, &uid, "dibatalkan pengguna"); err != nil {
+			return err
+		}
+		return tx.Model(&user).Update("income", round2(user.Income+wd.Amount)).Error
+	})
 	if err != nil {
-		return 10.0
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.WriteJSON(w, http.StatusNotFound, utils.APIResponse{Success: false, Message: "Penarikan tidak ditemukan"})
+		case errors.Is(err, errNotCancellable), errors.Is(err, utils.ErrWithdrawalStatusChanged):
+			utils.WriteJSON(w, http.StatusConflict, utils.APIResponse{Success: false, Message: "Penarikan sudah diproses dan tidak dapat dibatalkan"})
+		default:
+			utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Terjadi kesalahan sistem, silakan coba lagi"})
+		}
+		return
 	}
-	return v
+
+	utils.PublishWithdrawalEvent(wd)
+	utils.PublishBalanceEvent(uid)
+
+	utils.WriteJSON(w, http.StatusOK, utils.APIResponse{
+		Success: true,
+		Message: "Penarikan berhasil dibatalkan",
+		Data: map[string]interface{}{
+			"id":           wd.ID,
+			"order_id":     wd.OrderID,
+			"status":       wd.Status,
+			"status_label": utils.WithdrawalStatusLabel(wd.Status),
+		},
+	})
+}
+
+// Helpers
+
+func CalculateWithdrawalCharge(amount float64) float64 {
+	percent := utils.AppConfig().Withdrawal.ChargePercent
+	return round2(amount * (percent / 100.0))
 }
 
 func round2(v float64) float64 {
@@ -8,11 +8,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
-	"os"
-	"strconv"
 	"strings"
 	"time"
 
+	"project/config"
+
 	mysqldriver "github.com/go-sql-driver/mysql"
 	gormmysql "gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -22,30 +22,29 @@ import (
 var DB *gorm.DB
 
 // Connect connects to the database with secure defaults, pooling and retry.
-// It supports role-based connections by using DB_ROLE env var ("read" or "write").
-func Connect() (*gorm.DB, error) {
+// It supports role-based connections through cfg.Role ("read" or "write").
+// logSQL logs every statement, which main enables in development.
+func Connect(cfg config.Database, logSQL bool) (*gorm.DB, error) {
 	if DB != nil {
 		return DB, nil
 	}
 
-	host := getenv("DB_HOST", "127.0.0.1")
-	port := getenv("DB_PORT", "3306")
-	user := getenv("DB_USER", "root")
-	pass := getenv("DB_PASS", "")
-	name := getenv("DB_NAME", "v1")
-	params := getenv("DB_PARAMS", "charset=utf8mb4&parseTime=True&loc=Local")
+	host := cfg.Host
+	port := cfg.Port
+	user := cfg.User
+	pass := cfg.Pass.Value()
+	name := cfg.Name
+	params := cfg.Params
 
 	// Allow explicit DSN override
-	dsn := os.Getenv("DB_DSN")
+	dsn := cfg.DSN.Value()
 
 	// Allow role override: "read" will try DB_READ_USER/DB_READ_PASS, "write" uses DB_USER
-	role := strings.ToLower(getenv("DB_ROLE", "write"))
+	role := strings.ToLower(cfg.Role)
 	if role == "read" {
-		ruser := getenv("DB_READ_USER", "")
-		rpass := getenv("DB_READ_PASS", "")
-		if ruser != "" {
-			user = ruser
-			pass = rpass
+		if cfg.ReadUser != "" {
+			user = cfg.ReadUser
+			pass = cfg.ReadPass.Value()
 		}
 	}
 
@@ -53,11 +52,11 @@ func Connect() (*gorm.DB, error) {
 		// Ensure TLS/timeout params are present to enforce encrypted connections and timeouts
 		// Add defaults for timeouts and parseTime if not present
 		if !strings.Contains(params, "tls=") {
-			// Accept TLS mode via env DB_TLS (skip, preferred, true)
-			tlsMode := getenv("DB_TLS", "true")
+			// Accept TLS mode via DB_TLS (skip, preferred, true)
+			tlsMode := cfg.TLS
 			if tlsMode == "true" || tlsMode == "preferred" {
 				// use tls=true (requires server to support TLS). For strict verification, user can set DB_TLS=verify
-				if getenv("DB_TLS_VERIFY", "false") == "true" {
+				if cfg.TLSVerify == "true" {
 					// we'll register a custom TLS config below and reference it by name
 					params = params + "&tls=custom"
 				} else {
@@ -88,8 +87,8 @@ func Connect() (*gorm.DB, error) {
 
 	// Optionally register a custom TLS config named "custom" for strict certificate validation
 	if strings.Contains(dsn, "tls=custom") {
-		// Load CA bundle path from env
-		caPath := getenv("DB_TLS_CA_PATH", "")
+		// Load CA bundle path from config
+		caPath := cfg.TLSCAPath
 		tlsCfg := &tls.Config{}
 		if caPath != "" {
 			caCert, err := ioutil.ReadFile(caPath)
@@ -103,8 +102,8 @@ func Connect() (*gorm.DB, error) {
 			tlsCfg.RootCAs = pool
 		}
 		// Optionally load client cert/key
-		clientCert := getenv("DB_TLS_CLIENT_CERT", "")
-		clientKey := getenv("DB_TLS_CLIENT_KEY", "")
+		clientCert := cfg.TLSClientCert
+		clientKey := cfg.TLSClientKey
 		if clientCert != "" && clientKey != "" {
 			cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
 			if err != nil {
@@ -119,14 +118,14 @@ func Connect() (*gorm.DB, error) {
 
 	// GORM logger: verbose in development
 	var gormLogger logger.Interface
-	if strings.ToLower(getenv("ENV", "development")) == "development" {
+	if logSQL {
 		gormLogger = logger.Default.LogMode(logger.Info)
 	} else {
 		gormLogger = logger.Default.LogMode(logger.Silent)
 	}
 
 	// Retry connection with exponential backoff
-	maxRetries := atoi(getenv("DB_CONNECT_RETRIES", "5"))
+	maxRetries := cfg.ConnectRetries
 	var db *gorm.DB
 	var err error
 	backoff := time.Second
@@ -148,16 +147,12 @@ func Connect() (*gorm.DB, error) {
 		return nil, err
 	}
 
-	maxOpen := atoi(getenv("DB_MAX_OPEN_CONNS", "25"))
-	maxIdle := atoi(getenv("DB_MAX_IDLE_CONNS", "25"))
-	maxLifetimeSec := atoi(getenv("DB_CONN_MAX_LIFETIME", "3600"))
-
-	sqlDB.SetMaxOpenConns(maxOpen)
-	sqlDB.SetMaxIdleConns(maxIdle)
-	sqlDB.SetConnMaxLifetime(time.Duration(maxLifetimeSec) * time.Second)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetimeSec) * time.Second)
 
 	// Optional connection validation
-	if getenv("DB_PING_ON_CONNECT", "true") == "true" {
+	if strings.TrimSpace(cfg.PingOnConnect) == "true" {
 		if err := pingWithTimeout(sqlDB, 5*time.Second); err != nil {
 			return nil, fmt.Errorf("database ping failed: %w", err)
 		}
@@ -167,21 +162,6 @@ func Connect() (*gorm.DB, error) {
 	return DB, nil
 }
 
-func atoi(s string) int {
-	v, _ := strconv.Atoi(s)
-	if v <= 0 {
-		return 0
-	}
-	return v
-}
-
-func getenv(key, def string) string {
-	if v := os.Getenv(key); v != "" {
-		return strings.TrimSpace(v)
-	}
-	return def
-}
-
 func pingWithTimeout(db *sql.DB, timeout time.Duration) error {
 	type pinger interface {
 		Ping() error
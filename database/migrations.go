@@ -5,23 +5,26 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 	"time"
 
+	"project/config"
+
 	"gorm.io/gorm"
 )
 
 // BackupDatabase attempts to create a SQL dump using mysqldump if it's available on PATH.
-// It writes to the provided path and returns an error if the command fails.
-func BackupDatabase(dsn string, outPath string) error {
+// flags are passed to mysqldump as-is (DB_BACKUP_FLAGS). It writes to the provided path
+// and returns an error if the command fails.
+func BackupDatabase(flags string, outPath string) error {
 	// If mysqldump is not installed, return an informative error
 	if _, err := exec.LookPath("mysqldump"); err != nil {
 		return fmt.Errorf("mysqldump not found in PATH: %w", err)
 	}
 
-	// NOTE: dsn should be parsed by the caller to extract user/host/db or provide a secure wrapper
-	// For simplicity we expect caller to supply the appropriate flags in DB_BACKUP_FLAGS env or similar
-	args := []string{os.Getenv("DB_BACKUP_FLAGS")}
-	// Attempt simple invocation; this can be customized via env
+	// The caller supplies the connection flags (DB_BACKUP_FLAGS), e.g. --host/--user/database
+	args := strings.Fields(flags)
+	// Attempt simple invocation; this can be customized via DB_BACKUP_FLAGS
 	cmd := exec.CommandContext(context.Background(), "mysqldump", args...)
 	outFile, err := os.Create(outPath)
 	if err != nil {
@@ -38,13 +41,13 @@ func BackupDatabase(dsn string, outPath string) error {
 
 // RunMigrationsWithBackup runs AutoMigrate after attempting a backup (best-effort).
 // It accepts a list of models to migrate. The function attempts a mysqldump backup if
-// DB_BACKUP_PATH env is set. It runs migrations inside a transaction where possible.
-func RunMigrationsWithBackup(db *gorm.DB, models ...interface{}) error {
-	backupPath := os.Getenv("DB_BACKUP_PATH")
+// DB_BACKUP_PATH is set. It runs migrations inside a transaction where possible.
+func RunMigrationsWithBackup(db *gorm.DB, cfg config.Database, models ...interface{}) error {
+	backupPath := cfg.BackupPath
 	if backupPath != "" {
 		// Perform backup asynchronously but wait a short time
 		go func() {
-			_ = BackupDatabase(os.Getenv("DB_DSN"), backupPath)
+			_ = BackupDatabase(cfg.BackupFlags, backupPath)
 		}()
 		// allow a small window for the backup to start
 		time.Sleep(500 * time.Millisecond)
@@ -6,47 +6,81 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"strings"
 	"syscall"
 	"time"
 
+	"project/config"
+	"project/controllers/admins"
 	"project/database"
 	"project/middleware"
 	"project/models"
 	"project/routes"
+	"project/utils"
 
 	"github.com/joho/godotenv"
 )
 
 func main() {
-	// Load .env if present but do not overwrite already-set environment variables.
-	// Only load .env in development environment
-	if strings.ToLower(os.Getenv("ENV")) == "development" {
+	// Typed configuration from env, CONFIG_FILE and <VAR>_FILE secrets
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	// In development, fill unset variables from .env (never overwriting the
+	// environment) and load again so they take effect.
+	if cfg.Development() {
 		if envMap, err := godotenv.Read(); err == nil {
 			for k, v := range envMap {
 				if os.Getenv(k) == "" {
 					os.Setenv(k, v)
 				}
 			}
+			if cfg, err = config.Load(); err != nil {
+				log.Fatalf("failed to load config: %v", err)
+			}
 		}
 	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("invalid config:\n%v", err)
+	}
+	for _, line := range cfg.Summary() {
+		log.Printf("config: %s", line)
+	}
+	utils.SetConfig(cfg)
+	utils.InitRedis(cfg.Redis)
 
-	// Validate required environment variables
-	requiredEnvVars := []string{"DB_HOST", "DB_USER", "DB_PASS", "DB_NAME", "JWT_SECRET"}
-	for _, envVar := range requiredEnvVars {
-		if os.Getenv(envVar) == "" {
-			log.Fatalf("Required environment variable %s is not set", envVar)
-		}
+	// JWT signing keys and rate-limit policies; SIGHUP reloads both without a restart
+	if err := utils.LoadJWTKeys(cfg.JWT); err != nil {
+		log.Fatalf("failed to load JWT keys: %v", err)
 	}
+	if err := middleware.LoadRateLimitPolicies(); err != nil {
+		log.Fatalf("failed to load rate limit policies: %v", err)
+	}
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if err := utils.LoadJWTKeys(cfg.JWT); err != nil {
+				log.Printf("JWT key reload failed, keeping current keys: %v", err)
+			} else {
+				log.Println("JWT keys reloaded")
+			}
+			if err := middleware.LoadRateLimitPolicies(); err != nil {
+				log.Printf("rate limit policy reload failed, keeping current policies: %v", err)
+			} else {
+				log.Println("rate limit policies reloaded")
+			}
+		}
+	}()
 
 	// Connect to the database
-	db, err := database.Connect()
+	db, err := database.Connect(cfg.Database, cfg.Development())
 	if err != nil {
 		log.Fatalf("failed to connect database: %v", err)
 	}
 
 	// Auto-migrate only in development to avoid accidental production schema changes
-	if strings.ToLower(os.Getenv("ENV")) == "development" {
+	if cfg.Development() {
 		log.Println("Running in development mode - performing auto-migration")
 		if err := db.AutoMigrate(
 			&models.Admin{},
@@ -64,6 +98,22 @@ func main() {
 			&models.BinaryNode{},
 			&models.Reward{},
 			&models.RewardProgress{},
+			&models.PayoutJob{},
+			&models.PayoutAttempt{},
+			&models.WithdrawalStatusHistory{},
+			&models.WithdrawalBulkJob{},
+			&models.WithdrawalBulkItem{},
+			&models.APIClient{},
+			&models.APIClientKey{},
+			&models.AuditLog{},
+			&models.TwoFactor{},
+			&models.TwoFactorRecoveryCode{},
+			&models.AdminSession{},
+			&models.SecurityEvent{},
+			&models.OTPCode{},
+			&models.IPBan{},
+			&models.UserDeviceLog{},
+			&models.BinaryTreePath{},
 		); err != nil {
 			log.Fatalf("failed to migrate database: %v", err)
 		}
@@ -72,18 +122,25 @@ func main() {
 		log.Println("Running in production mode - skipping auto-migration")
 	}
 
+	// Fill the binary tree closure table on first start after its migration
+	if written, err := utils.EnsureBinaryTreePaths(db); err != nil {
+		log.Printf("binary_tree_paths check failed, run cmd/backfill-binary-paths: %v", err)
+	} else if written > 0 {
+		log.Printf("binary_tree_paths backfilled with %d paths", written)
+	}
+
 	// Initialize router
 	router := routes.InitRouter()
 
 	// Wrap router with global middleware in recommended order
-	// Security headers / CORS -> Request ID -> Max Body -> Timeout -> Recovery -> Metrics -> Suspicious Activity
+	// Security headers / CORS -> Request ID -> Max Body -> Timeout -> Recovery -> Metrics -> Abuse (IP bans)
 	handler := middleware.SecurityHeadersMiddleware(
 		middleware.RequestIDMiddleware(
 			middleware.MaxBodyMiddleware(
 				middleware.TimeoutMiddleware(
 					middleware.RecoveryMiddleware(
 						middleware.MetricsMiddleware(
-							middleware.SuspiciousActivityMiddleware(router),
+							middleware.AbuseMiddleware(router),
 						),
 					),
 				),
@@ -92,10 +149,7 @@ func main() {
 	)
 
 	// Create HTTP server with production-ready configuration
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	port := cfg.Port
 	addr := ":" + port
 
 	server := &http.Server{
@@ -106,6 +160,13 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Background payout worker for auto-approved withdrawals
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	utils.StartPayoutWorker(workerCtx)
+	go middleware.WatchRateLimitPolicies(workerCtx)
+	go middleware.SyncIPBans(workerCtx, db)
+	go admins.ResumeWithdrawalBulkJobs(workerCtx)
+
 	// Start server in a goroutine
 	go func() {
 		log.Printf("Server starting on port %s", port)
@@ -119,6 +180,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+	stopWorker()
 
 	// Give outstanding requests 30 seconds to complete
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -0,0 +1,299 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"project/database"
+	"project/models"
+	"project/utils"
+
+	"gorm.io/gorm"
+)
+
+// Abuse signals. Handlers and the auth middlewares report the ones only they can
+// see (failed logins and PINs, forged or malformed tokens, validation and callback
+// credential failures); AbuseMiddleware reports 404 responses.
+const (
+	AbuseSignalFailedLogin  = "failed_login"
+	AbuseSignalAuthError    = "auth_error"
+	AbuseSignalNotFound     = "not_found"
+	AbuseSignalValidation   = "validation"
+	AbuseSignalCallbackAuth = "callback_auth"
+)
+
+// IP ban sources
+const (
+	IPBanSourceAuto  = "auto"
+	IPBanSourceAdmin = "admin"
+)
+
+// SecurityEventIPBanned is recorded whenever the abuse engine bans an IP.
+const SecurityEventIPBanned = "ip_banned"
+
+type abuseRule struct {
+	Threshold int
+	Window    time.Duration
+}
+
+// abuseRules returns the per-signal thresholds; ABUSE_<SIGNAL>_MAX sets the count,
+// e.g. ABUSE_FAILED_LOGIN_MAX.
+func abuseRules() map[string]abuseRule {
+	cfg := utils.AppConfig().Abuse
+	return map[string]abuseRule{
+		AbuseSignalFailedLogin:  {Threshold: cfg.FailedLoginMax, Window: 10 * time.Minute},
+		AbuseSignalAuthError:    {Threshold: cfg.AuthErrorMax, Window: 5 * time.Minute},
+		AbuseSignalNotFound:     {Threshold: cfg.NotFoundMax, Window: 5 * time.Minute},
+		AbuseSignalValidation:   {Threshold: cfg.ValidationMax, Window: 5 * time.Minute},
+		AbuseSignalCallbackAuth: {Threshold: cfg.CallbackAuthMax, Window: 10 * time.Minute},
+	}
+}
+
+// autoBanDuration escalates automatic bans per strike: ABUSE_BAN_MINUTES (default
+// 15) times 4^(strike-1), capped at ABUSE_BAN_MAX_MINUTES (default 1440). Automatic
+// bans are always temporary; only an admin can ban permanently.
+func autoBanDuration(strike int64) time.Duration {
+	cfg := utils.AppConfig().Abuse
+	limit := time.Duration(cfg.BanMaxMinutes) * time.Minute
+	d := time.Duration(cfg.BanMinutes) * time.Minute
+	for i := int64(1); i < strike && d < limit; i++ {
+		d *= 4
+	}
+	if d > limit {
+		d = limit
+	}
+	return d
+}
+
+// reportInvalidToken counts a bearer token that failed validation as auth_error.
+// Expired and revoked tokens are normal client behaviour (a stale session, a
+// logout) and are not counted; forged, malformed or foreign tokens are.
+func reportInvalidToken(r *http.Request, err error) {
+	msg := err.Error()
+	if strings.Contains(msg, "expired") || strings.Contains(msg, "revoked") {
+		return
+	}
+	ReportAbuse(r, AbuseSignalAuthError)
+}
+
+// abuseWhitelisted reports whether ip is in ABUSE_WHITELIST (IPs or CIDRs). Loopback
+// is always exempt.
+func abuseWhitelisted(ip string) bool {
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.IsLoopback() {
+		return true
+	}
+	list := utils.AppConfig().Abuse.Whitelist
+	if len(list) == 0 {
+		return false
+	}
+	return utils.IPAllowed(list, ip)
+}
+
+// In-process view of the active bans, refreshed from ip_bans by SyncIPBans so bans
+// made on another replica apply here too. A zero time means permanent.
+var (
+	ipBansMu sync.RWMutex
+	ipBans   = make(map[string]time.Time)
+)
+
+// IsIPBanned reports whether ip is banned and, for temporary bans, for how long.
+func IsIPBanned(ip string) (bool, time.Duration) {
+	ipBansMu.RLock()
+	until, ok := ipBans[ip]
+	ipBansMu.RUnlock()
+	if !ok {
+		return false, 0
+	}
+	if until.IsZero() {
+		return true, 0
+	}
+	if left := time.Until(until); left > 0 {
+		return true, left
+	}
+	return false, 0
+}
+
+func cacheIPBan(ip string, until time.Time) {
+	ipBansMu.Lock()
+	defer ipBansMu.Unlock()
+	if cur, ok := ipBans[ip]; ok && (cur.IsZero() || (!until.IsZero() && cur.After(until))) {
+		return
+	}
+	ipBans[ip] = until
+}
+
+// loadIPBans replaces the cache with the active bans in db.
+func loadIPBans(db *gorm.DB) error {
+	var bans []models.IPBan
+	now := time.Now()
+	if err := db.Where("lifted_at IS NULL AND (permanent = ? OR expires_at > ?)", true, now).Find(&bans).Error; err != nil {
+		return err
+	}
+	next := make(map[string]time.Time, len(bans))
+	for _, b := range bans {
+		until := time.Time{}
+		if !b.Permanent {
+			until = *b.ExpiresAt
+		}
+		if cur, ok := next[b.IP]; ok && (cur.IsZero() || (!until.IsZero() && cur.After(until))) {
+			continue
+		}
+		next[b.IP] = until
+	}
+	ipBansMu.Lock()
+	ipBans = next
+	ipBansMu.Unlock()
+	return nil
+}
+
+// SyncIPBans loads the active bans now and then every ABUSE_BAN_SYNC_SEC seconds
+// (default 30) until ctx is done.
+func SyncIPBans(ctx context.Context, db *gorm.DB) {
+	if err := loadIPBans(db); err != nil {
+		log.Printf("ip bans: initial load failed: %v", err)
+	}
+	tick := time.NewTicker(time.Duration(utils.AppConfig().Abuse.BanSyncSec) * time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			if err := loadIPBans(db); err != nil {
+				log.Printf("ip bans: refresh failed: %v", err)
+			}
+		}
+	}
+}
+
+// BanIP stores a ban for ip and applies it immediately on this replica. A zero
+// duration bans permanently. db may be nil, in which case the ban is only cached.
+func BanIP(db *gorm.DB, ip, reason, signal, source string, duration time.Duration, adminID *uint) (*models.IPBan, error) {
+	ban := &models.IPBan{IP: ip, Reason: reason, Signal: signal, Source: source, AdminID: adminID, Permanent: duration <= 0}
+	until := time.Time{}
+	if !ban.Permanent {
+		until = time.Now().Add(duration)
+		ban.ExpiresAt = &until
+	}
+	if db != nil {
+		if err := db.Create(ban).Error; err != nil {
+			return nil, err
+		}
+	}
+	cacheIPBan(ip, until)
+	return ban, nil
+}
+
+// LiftIPBan lifts ban and every other active ban on the same IP.
+func LiftIPBan(db *gorm.DB, ban *models.IPBan, adminID uint) error {
+	now := time.Now()
+	if err := db.Model(&models.IPBan{}).
+		Where("ip = ? AND lifted_at IS NULL", ban.IP).
+		Updates(map[string]interface{}{"lifted_at": now, "lifted_by": adminID}).Error; err != nil {
+		return err
+	}
+	ban.LiftedAt, ban.LiftedBy = &now, &adminID
+	ipBansMu.Lock()
+	delete(ipBans, ban.IP)
+	ipBansMu.Unlock()
+	return nil
+}
+
+// requestClientIP returns the trusted-proxy client IP resolved by RequestIDMiddleware.
+func requestClientIP(r *http.Request) string {
+	if ip := utils.GetClientIP(r); ip != "" {
+		return ip
+	}
+	return clientIPGeneric(r, nil)
+}
+
+// ReportAbuse counts signal for the request's client IP and bans the IP once the
+// signal's threshold is reached within its window.
+func ReportAbuse(r *http.Request, signal string) {
+	recordAbuse(r, requestClientIP(r), signal)
+}
+
+func recordAbuse(r *http.Request, ip, signal string) {
+	rule, ok := abuseRules()[signal]
+	if !ok || rule.Threshold <= 0 || ip == "" || abuseWhitelisted(ip) {
+		return
+	}
+	if banned, _ := IsIPBanned(ip); banned {
+		return
+	}
+	ctx := context.Background()
+	store := DefaultLimiterStore()
+	key := "abuse:" + signal + ":" + ip
+	count, err := store.Hit(ctx, key, rule.Window)
+	// trigger exactly once when the window reaches the threshold
+	if err != nil || count != rule.Threshold {
+		return
+	}
+	_ = store.Del(ctx, key)
+
+	strike, _ := store.Incr(ctx, "abuse:strikes:"+ip, 30*24*time.Hour)
+	duration := autoBanDuration(strike)
+	reason := fmt.Sprintf("%d x %s dalam %s", count, signal, rule.Window)
+	if _, err := BanIP(database.DB, ip, reason, signal, IPBanSourceAuto, duration, nil); err != nil {
+		log.Printf("abuse: ban %s failed: %v", ip, err)
+		return
+	}
+	log.Printf("abuse: banned %s (%s, strike %d, duration %s)", ip, reason, strike, duration)
+	if database.DB != nil {
+		utils.RecordSecurityEvent(database.DB, r, SecurityEventIPBanned, 0, map[string]interface{}{
+			"ip": ip, "signal": signal, "count": count, "strike": strike, "duration": duration.String(),
+		})
+	}
+}
+
+// statusRecorder remembers the response status for AbuseMiddleware.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer (SSE flushes).
+func (s *statusRecorder) Unwrap() http.ResponseWriter { return s.ResponseWriter }
+
+// AbuseMiddleware rejects banned client IPs and reports 404 responses as abuse
+// signals. 401/403 are not counted here: most are expired sessions or PIN and 2FA
+// prompts, and the real credential failures are reported where they are detected.
+func AbuseMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := requestClientIP(r)
+		if banned, retry := IsIPBanned(ip); banned {
+			if retry > 0 {
+				w.Header().Set("Retry-After", fmt.Sprintf("%d", int(retry.Seconds())))
+			}
+			utils.WriteJSON(w, http.StatusForbidden, utils.APIResponse{Success: false, Message: "Akses dari alamat IP anda diblokir."})
+			return
+		}
+
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		if rec.status == http.StatusNotFound {
+			recordAbuse(r, ip, AbuseSignalNotFound)
+		}
+	})
+}
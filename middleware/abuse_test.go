@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"project/config"
+	"project/utils"
+)
+
+// useConfig installs the default configuration, changed by edit, for the test.
+func useConfig(t *testing.T, edit func(cfg *config.Config)) {
+	t.Helper()
+	t.Setenv("CONFIG_FILE", "")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	edit(cfg)
+	prev := utils.AppConfig()
+	utils.SetConfig(cfg)
+	t.Cleanup(func() { utils.SetConfig(prev) })
+}
+
+func useMemoryLimiterStore(t *testing.T) {
+	t.Helper()
+	prevStore := DefaultLimiterStore()
+	limiterStore = newMemoryLimiterStore(0)
+	ipBansMu.Lock()
+	prevBans := ipBans
+	ipBans = make(map[string]time.Time)
+	ipBansMu.Unlock()
+	t.Cleanup(func() {
+		limiterStore = prevStore
+		ipBansMu.Lock()
+		ipBans = prevBans
+		ipBansMu.Unlock()
+	})
+}
+
+func TestAutoBanDurationEscalates(t *testing.T) {
+	useConfig(t, func(cfg *config.Config) {
+		cfg.Abuse.BanMinutes = 10
+		cfg.Abuse.BanMaxMinutes = 120
+	})
+	if d := autoBanDuration(1); d != 10*time.Minute {
+		t.Fatalf("strike 1: got %s", d)
+	}
+	if d := autoBanDuration(2); d != 40*time.Minute {
+		t.Fatalf("strike 2: got %s", d)
+	}
+	for _, strike := range []int64{3, 4, 50} {
+		if d := autoBanDuration(strike); d != 120*time.Minute {
+			t.Fatalf("strike %d must be capped at the temporary maximum, got %s", strike, d)
+		}
+	}
+}
+
+func TestAbuseMiddlewareIgnoresAuthResponses(t *testing.T) {
+	useMemoryLimiterStore(t)
+	useConfig(t, func(cfg *config.Config) { cfg.Abuse.AuthErrorMax = 2 })
+	h := AbuseMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	for i := 0; i < 5; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/api/users/info", nil)
+		r.RemoteAddr = "192.0.2.10:1234"
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	if banned, _ := IsIPBanned("192.0.2.10"); banned {
+		t.Fatal("plain 401 responses (expired sessions, PIN prompts) must not ban")
+	}
+}
+
+func TestReportInvalidTokenSkipsExpiredAndRevoked(t *testing.T) {
+	useMemoryLimiterStore(t)
+	useConfig(t, func(cfg *config.Config) { cfg.Abuse.AuthErrorMax = 2 })
+	r := httptest.NewRequest(http.MethodGet, "/api/users/info", nil)
+	r.RemoteAddr = "192.0.2.11:1234"
+	for i := 0; i < 3; i++ {
+		reportInvalidToken(r, errors.New("token expired"))
+		reportInvalidToken(r, errors.New("token revoked"))
+	}
+	if banned, _ := IsIPBanned("192.0.2.11"); banned {
+		t.Fatal("expired or revoked tokens must not count")
+	}
+	reportInvalidToken(r, errors.New("invalid token"))
+	reportInvalidToken(r, errors.New("invalid token"))
+	if banned, _ := IsIPBanned("192.0.2.11"); !banned {
+		t.Fatal("forged tokens must count towards auth_error")
+	}
+}
+
+func TestRecordAbuseBansAtThreshold(t *testing.T) {
+	useMemoryLimiterStore(t)
+	useConfig(t, func(cfg *config.Config) {
+		cfg.Abuse.FailedLoginMax = 3
+		cfg.Abuse.Whitelist = []string{"10.0.0.0/8"}
+	})
+	r := httptest.NewRequest(http.MethodPost, "/api/login", nil)
+
+	for i := 0; i < 2; i++ {
+		recordAbuse(r, "203.0.113.7", AbuseSignalFailedLogin)
+	}
+	if banned, _ := IsIPBanned("203.0.113.7"); banned {
+		t.Fatal("banned before reaching the threshold")
+	}
+	recordAbuse(r, "203.0.113.7", AbuseSignalFailedLogin)
+	banned, left := IsIPBanned("203.0.113.7")
+	if !banned || left <= 0 || left > 15*time.Minute {
+		t.Fatalf("expected a first-strike temporary ban, got %v %s", banned, left)
+	}
+
+	for _, ip := range []string{"127.0.0.1", "10.1.2.3"} {
+		for i := 0; i < 5; i++ {
+			recordAbuse(r, ip, AbuseSignalFailedLogin)
+		}
+		if banned, _ := IsIPBanned(ip); banned {
+			t.Errorf("%s is whitelisted and must never be banned", ip)
+		}
+	}
+}
+
+func TestCacheIPBanKeepsLongest(t *testing.T) {
+	useMemoryLimiterStore(t)
+	cacheIPBan("198.51.100.1", time.Now().Add(time.Hour))
+	cacheIPBan("198.51.100.1", time.Now().Add(time.Minute))
+	if _, left := IsIPBanned("198.51.100.1"); left < 59*time.Minute {
+		t.Fatalf("shorter ban must not replace a longer one, %s left", left)
+	}
+	cacheIPBan("198.51.100.1", time.Time{})
+	cacheIPBan("198.51.100.1", time.Now().Add(time.Hour))
+	if banned, left := IsIPBanned("198.51.100.1"); !banned || left != 0 {
+		t.Fatalf("expected permanent ban, got %v %s", banned, left)
+	}
+}
+
+func TestAbuseMiddlewareBlocksBannedIP(t *testing.T) {
+	useMemoryLimiterStore(t)
+	useConfig(t, func(cfg *config.Config) { cfg.Abuse.NotFoundMax = 2 })
+	h := AbuseMiddleware(http.NotFoundHandler())
+
+	serve := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/nope", nil)
+		r.RemoteAddr = "192.0.2.9:1234"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	for i := 0; i < 2; i++ {
+		if w := serve(); w.Code != http.StatusNotFound {
+			t.Fatalf("request %d: got %d", i+1, w.Code)
+		}
+	}
+	w := serve()
+	if w.Code != http.StatusForbidden || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 403 with Retry-After, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+}
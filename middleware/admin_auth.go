@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"project/database"
@@ -28,6 +29,7 @@ func AdminAuthMiddleware(next http.Handler) http.Handler {
 		// Use centralized validation which checks aud/iss/exp/nbf and revocation
 		_, claims, err := utils.ValidateAccessToken(tokenString)
 		if err != nil {
+			reportInvalidToken(r, err)
 			utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{
 				Success: false,
 				Message: "Unauthorized: Invalid token",
@@ -45,6 +47,17 @@ func AdminAuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// Every admin token is bound to an admin_sessions row so logout and password
+		// resets can revoke it; legacy tokens without sid are no longer accepted
+		sid, _ := claims["sid"].(float64)
+		if sid <= 0 {
+			utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{
+				Success: false,
+				Message: "Unauthorized: Sesi tidak valid, silakan login kembali",
+			})
+			return
+		}
+
 		// Get admin ID (support float64 from JSON numbers)
 		var adminID int64
 		if rawID, ok := claims["id"]; ok {
@@ -82,6 +95,27 @@ func AdminAuthMiddleware(next http.Handler) http.Handler {
 		}
 
 		// Admin is authenticated, proceed
-		next.ServeHTTP(w, r)
+		ctx := context.WithValue(r.Context(), utils.AdminIDKey, uint(admin.ID))
+		ctx = context.WithValue(ctx, utils.AdminRoleKey, utils.NormalizeAdminRole(admin.Role))
+		ctx = context.WithValue(ctx, utils.AdminSessionKey, uint(sid))
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// RequirePermission allows the request only when the admin's role grants permission.
+// It must run after AdminAuthMiddleware.
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := utils.GetAdminRole(r)
+			if !utils.AdminHasPermission(role, permission) {
+				utils.WriteJSON(w, http.StatusForbidden, utils.APIResponse{
+					Success: false,
+					Message: "Forbidden: missing permission " + permission,
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
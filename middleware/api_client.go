@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"project/database"
+	"project/models"
+	"project/utils"
+)
+
+// How often last-used columns are written for a busy client
+const apiClientTouchInterval = time.Minute
+
+func apiClientKeyFromRequest(r *http.Request) string {
+	if k := strings.TrimSpace(r.Header.Get("X-API-Key")); k != "" {
+		return k
+	}
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return auth
+}
+
+// allowAPIClient records a request for the client and reports whether it is within
+// its per-minute limit, along with the remaining budget. The window is shared by
+// every partner route and, through the LimiterStore, by every replica.
+func allowAPIClient(ctx context.Context, clientID uint, limit int) (bool, int) {
+	count, err := DefaultLimiterStore().Hit(ctx, fmt.Sprintf("rl:client:%d", clientID), time.Minute)
+	if err != nil {
+		return true, limit
+	}
+	remaining := limit - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	return count <= limit, remaining
+}
+
+// touchAPIClient updates last-used tracking at most once per apiClientTouchInterval.
+func touchAPIClient(client *models.APIClient, key *models.APIClientKey, ip string) {
+	now := time.Now()
+	stale := now.Add(-apiClientTouchInterval)
+	db := database.DB
+	db.Model(&models.APIClient{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", client.ID, stale).
+		Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": ip})
+	db.Model(&models.APIClientKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", key.ID, stale).
+		Update("last_used_at", now)
+}
+
+// APIClientMiddleware authenticates partner integrations by API key (X-API-Key or
+// Authorization: Bearer) and requires the given scope. It enforces the client's IP
+// allowlist and per-minute rate limit and puts the client into the request context.
+func APIClientMiddleware(scope string) func(http.Handler) http.Handler {
+	trusted := utils.AppConfig().HTTP.TrustedProxies
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			plain := apiClientKeyFromRequest(r)
+			if plain == "" {
+				ReportAbuse(r, AbuseSignalCallbackAuth)
+				utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+				return
+			}
+
+			now := time.Now()
+			var key models.APIClientKey
+			if err := database.DB.
+				Where("key_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", utils.HashAPIKey(plain), now).
+				First(&key).Error; err != nil {
+				ReportAbuse(r, AbuseSignalCallbackAuth)
+				utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+				return
+			}
+
+			var client models.APIClient
+			if err := database.DB.First(&client, key.ClientID).Error; err != nil || !client.Active {
+				ReportAbuse(r, AbuseSignalCallbackAuth)
+				utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+				return
+			}
+
+			ip := clientIPGeneric(r, trusted)
+			if !utils.IPAllowed(client.AllowedIPList(), ip) {
+				utils.WriteJSON(w, http.StatusForbidden, utils.APIResponse{Success: false, Message: "Forbidden: IP not allowed"})
+				return
+			}
+			if !client.HasScope(scope) {
+				utils.WriteJSON(w, http.StatusForbidden, utils.APIResponse{Success: false, Message: "Forbidden: missing scope " + scope})
+				return
+			}
+
+			limit := client.RateLimitPerMinute
+			if limit <= 0 {
+				limit = utils.AppConfig().APIClients.DefaultRateLimit
+			}
+			ok, remaining := allowAPIClient(r.Context(), client.ID, limit)
+			w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", limit))
+			w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
+			if !ok {
+				w.Header().Set("Retry-After", "60")
+				utils.WriteJSON(w, http.StatusTooManyRequests, utils.APIResponse{Success: false, Message: "Too many requests (client)."})
+				return
+			}
+
+			touchAPIClient(&client, &key, ip)
+
+			ctx := context.WithValue(r.Context(), utils.APIClientKey, &client)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -30,6 +30,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		// Use shared validation which checks signature and registered claims
 		token, claims, err := utils.ValidateAccessToken(tokenStr)
 		if err != nil {
+			reportInvalidToken(r, err)
 			if strings.Contains(err.Error(), "expired") {
 				writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
 					"success": false,
@@ -67,8 +68,8 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			role = rStr
 		}
 
-		// block admin role from user endpoints (keep existing behavior)
-		if role == "admin" {
+		// block admin tokens and MFA challenge tokens from user endpoints
+		if role == "admin" || role == utils.MFAChallengeRole {
 			writeJSON(w, http.StatusForbidden, map[string]interface{}{
 				"success": false,
 				"message": "Access denied",
@@ -78,6 +79,9 @@ func AuthMiddleware(next http.Handler) http.Handler {
 
 		ctx := context.WithValue(r.Context(), utils.UserIDKey, userID)
 		ctx = context.WithValue(ctx, utils.UserRoleKey, role)
+		if jti, ok := claims["jti"].(string); ok {
+			ctx = context.WithValue(ctx, utils.TokenJTIKey, jti)
+		}
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -0,0 +1,349 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"project/utils"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// LimiterStore keeps the state behind the rate limiters and the login lockout. The
+// Redis implementation shares it between replicas and survives restarts; the
+// in-memory one is per process. Keys are namespaced by the caller.
+type LimiterStore interface {
+	// Hit records one request under key and returns how many requests fall inside
+	// the trailing window, this one included.
+	Hit(ctx context.Context, key string, window time.Duration) (int, error)
+	// Incr increments a counter and (re)sets its expiry to ttl.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Lock sets a flag that expires after d.
+	Lock(ctx context.Context, key string, d time.Duration) error
+	// LockTTL returns the remaining lifetime of a flag set by Lock, or 0.
+	LockTTL(ctx context.Context, key string) (time.Duration, error)
+	// Del removes windows, counters and flags.
+	Del(ctx context.Context, keys ...string) error
+	// IncrScore adds delta to member of a ranked set that expires after ttl.
+	IncrScore(ctx context.Context, set, member string, delta float64, ttl time.Duration) error
+	// TopScores returns the n highest-ranked members of a set.
+	TopScores(ctx context.Context, set string, n int) ([]ScoredMember, error)
+}
+
+// ScoredMember is one entry returned by LimiterStore.TopScores.
+type ScoredMember struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+var (
+	limiterStoreOnce sync.Once
+	limiterStore     LimiterStore
+)
+
+// DefaultLimiterStore returns the shared store: Redis when utils.RedisClient is
+// configured (unless RATE_LIMIT_STORE=memory), otherwise in-memory.
+func DefaultLimiterStore() LimiterStore {
+	limiterStoreOnce.Do(func() {
+		cfg := utils.AppConfig().RateLimit
+		mem := newMemoryLimiterStore(time.Duration(cfg.CleanupSec) * time.Second)
+		if utils.RedisClient != nil && strings.ToLower(cfg.Store) != "memory" {
+			limiterStore = &redisLimiterStore{client: utils.RedisClient, fallback: mem}
+			return
+		}
+		limiterStore = mem
+	})
+	return limiterStore
+}
+
+// --- in-memory ---
+
+type slidingWindow struct {
+	window time.Duration
+	hits   timestamps
+}
+
+type expiringValue struct {
+	n     int64
+	until int64 // unix nanos
+}
+
+type scoreSet struct {
+	members map[string]float64
+	until   int64 // unix nanos
+}
+
+type memoryLimiterStore struct {
+	mu      sync.Mutex
+	windows map[string]*slidingWindow
+	values  map[string]expiringValue
+	scores  map[string]*scoreSet
+}
+
+func newMemoryLimiterStore(cleanup time.Duration) *memoryLimiterStore {
+	s := &memoryLimiterStore{
+		windows: make(map[string]*slidingWindow),
+		values:  make(map[string]expiringValue),
+		scores:  make(map[string]*scoreSet),
+	}
+	if cleanup > 0 {
+		go s.cleanupLoop(cleanup)
+	}
+	return s
+}
+
+func prune(hits timestamps, cutoff int64) timestamps {
+	var filtered timestamps
+	for _, ts := range hits {
+		if ts >= cutoff {
+			filtered = append(filtered, ts)
+		}
+	}
+	return filtered
+}
+
+func (s *memoryLimiterStore) Hit(_ context.Context, key string, window time.Duration) (int, error) {
+	now := nowUnix()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sw := s.windows[key]
+	if sw == nil {
+		sw = &slidingWindow{}
+		s.windows[key] = sw
+	}
+	sw.window = window
+	sw.hits = append(prune(sw.hits, now-int64(window)), now)
+	return len(sw.hits), nil
+}
+
+func (s *memoryLimiterStore) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	now := nowUnix()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v := s.values[key]
+	if v.until <= now {
+		v.n = 0
+	}
+	v.n++
+	v.until = now + int64(ttl)
+	s.values[key] = v
+	return v.n, nil
+}
+
+func (s *memoryLimiterStore) Lock(_ context.Context, key string, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = expiringValue{n: 1, until: nowUnix() + int64(d)}
+	return nil
+}
+
+func (s *memoryLimiterStore) LockTTL(_ context.Context, key string) (time.Duration, error) {
+	now := nowUnix()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.values[key]
+	if !ok {
+		return 0, nil
+	}
+	if v.until <= now {
+		delete(s.values, key)
+		return 0, nil
+	}
+	return time.Duration(v.until - now), nil
+}
+
+func (s *memoryLimiterStore) Del(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range keys {
+		delete(s.windows, k)
+		delete(s.values, k)
+		delete(s.scores, k)
+	}
+	return nil
+}
+
+func (s *memoryLimiterStore) IncrScore(_ context.Context, set, member string, delta float64, ttl time.Duration) error {
+	now := nowUnix()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ss := s.scores[set]
+	if ss == nil || ss.until <= now {
+		ss = &scoreSet{members: make(map[string]float64)}
+		s.scores[set] = ss
+	}
+	ss.members[member] += delta
+	ss.until = now + int64(ttl)
+	return nil
+}
+
+func (s *memoryLimiterStore) TopScores(_ context.Context, set string, n int) ([]ScoredMember, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ss := s.scores[set]
+	if ss == nil || ss.until <= nowUnix() {
+		return []ScoredMember{}, nil
+	}
+	out := make([]ScoredMember, 0, len(ss.members))
+	for m, sc := range ss.members {
+		out = append(out, ScoredMember{Member: m, Score: sc})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Member < out[j].Member
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out, nil
+}
+
+func (s *memoryLimiterStore) cleanupLoop(every time.Duration) {
+	tick := time.NewTicker(every)
+	defer tick.Stop()
+	for range tick.C {
+		now := nowUnix()
+		s.mu.Lock()
+		for k, sw := range s.windows {
+			sw.hits = prune(sw.hits, now-int64(sw.window))
+			if len(sw.hits) == 0 {
+				delete(s.windows, k)
+			}
+		}
+		for k, v := range s.values {
+			if v.until <= now {
+				delete(s.values, k)
+			}
+		}
+		for k, ss := range s.scores {
+			if ss.until <= now {
+				delete(s.scores, k)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// --- Redis ---
+
+// slidingWindowScript trims a sorted set of request timestamps (microseconds, so
+// they stay exact as Lua doubles), adds the current one and returns the count.
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+redis.call('ZADD', KEYS[1], now, ARGV[3])
+redis.call('PEXPIRE', KEYS[1], math.ceil(window / 1000))
+return redis.call('ZCARD', KEYS[1])
+`)
+
+var incrExpireScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+return n
+`)
+
+// redisLimiterStore fails over to an in-memory store while Redis is unreachable, so
+// an outage degrades to per-replica limits instead of rejecting or ignoring traffic.
+type redisLimiterStore struct {
+	client   *redis.Client
+	fallback *memoryLimiterStore
+	lastWarn atomic.Int64
+}
+
+func (s *redisLimiterStore) failover(err error) {
+	now := time.Now().Unix()
+	if last := s.lastWarn.Load(); now-last >= 60 && s.lastWarn.CompareAndSwap(last, now) {
+		log.Printf("rate limiter: redis unavailable, using in-memory state: %v", err)
+	}
+}
+
+func (s *redisLimiterStore) Hit(ctx context.Context, key string, window time.Duration) (int, error) {
+	member := make([]byte, 8)
+	_, _ = rand.Read(member)
+	n, err := slidingWindowScript.Run(ctx, s.client, []string{key},
+		time.Now().UnixMicro(), window.Microseconds(), hex.EncodeToString(member)).Int64()
+	if err != nil {
+		s.failover(err)
+		return s.fallback.Hit(ctx, key, window)
+	}
+	return int(n), nil
+}
+
+func (s *redisLimiterStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	n, err := incrExpireScript.Run(ctx, s.client, []string{key}, ttl.Milliseconds()).Int64()
+	if err != nil {
+		s.failover(err)
+		return s.fallback.Incr(ctx, key, ttl)
+	}
+	return n, nil
+}
+
+func (s *redisLimiterStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	if err := s.client.Set(ctx, key, "1", d).Err(); err != nil {
+		s.failover(err)
+		return s.fallback.Lock(ctx, key, d)
+	}
+	return nil
+}
+
+func (s *redisLimiterStore) LockTTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(ctx, key).Result()
+	if err != nil {
+		s.failover(err)
+		return s.fallback.LockTTL(ctx, key)
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s *redisLimiterStore) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_ = s.fallback.Del(ctx, keys...)
+	if err := s.client.Del(ctx, keys...).Err(); err != nil {
+		s.failover(err)
+	}
+	return nil
+}
+
+func (s *redisLimiterStore) IncrScore(ctx context.Context, set, member string, delta float64, ttl time.Duration) error {
+	pipe := s.client.TxPipeline()
+	pipe.ZIncrBy(ctx, set, delta, member)
+	pipe.PExpire(ctx, set, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		s.failover(err)
+		return s.fallback.IncrScore(ctx, set, member, delta, ttl)
+	}
+	return nil
+}
+
+func (s *redisLimiterStore) TopScores(ctx context.Context, set string, n int) ([]ScoredMember, error) {
+	stop := int64(-1)
+	if n > 0 {
+		stop = int64(n - 1)
+	}
+	zs, err := s.client.ZRevRangeWithScores(ctx, set, 0, stop).Result()
+	if err != nil {
+		s.failover(err)
+		return s.fallback.TopScores(ctx, set, n)
+	}
+	out := make([]ScoredMember, 0, len(zs))
+	for _, z := range zs {
+		m, _ := z.Member.(string)
+		out = append(out, ScoredMember{Member: m, Score: z.Score})
+	}
+	return out, nil
+}
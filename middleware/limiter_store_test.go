@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiterStoreSlidingWindow(t *testing.T) {
+	s := newMemoryLimiterStore(0)
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		if n, _ := s.Hit(ctx, "k", time.Minute); n != i {
+			t.Fatalf("hit %d: got count %d", i, n)
+		}
+	}
+	if n, _ := s.Hit(ctx, "other", time.Minute); n != 1 {
+		t.Fatalf("keys must be independent, got %d", n)
+	}
+	if n, _ := s.Hit(ctx, "short", time.Nanosecond); n != 1 {
+		t.Fatalf("got %d", n)
+	}
+	time.Sleep(time.Millisecond)
+	if n, _ := s.Hit(ctx, "short", time.Nanosecond); n != 1 {
+		t.Fatalf("expired hits must leave the window, got %d", n)
+	}
+}
+
+func TestMemoryLimiterStoreCountersAndLocks(t *testing.T) {
+	s := newMemoryLimiterStore(0)
+	ctx := context.Background()
+	if n, _ := s.Incr(ctx, "c", time.Minute); n != 1 {
+		t.Fatalf("got %d", n)
+	}
+	if n, _ := s.Incr(ctx, "c", time.Minute); n != 2 {
+		t.Fatalf("got %d", n)
+	}
+	_, _ = s.Incr(ctx, "e", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if n, _ := s.Incr(ctx, "e", time.Minute); n != 1 {
+		t.Fatalf("expired counter must restart, got %d", n)
+	}
+
+	_ = s.Lock(ctx, "l", time.Minute)
+	if ttl, _ := s.LockTTL(ctx, "l"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("unexpected ttl %v", ttl)
+	}
+	_ = s.Del(ctx, "l", "c")
+	if ttl, _ := s.LockTTL(ctx, "l"); ttl != 0 {
+		t.Fatalf("lock survived Del: %v", ttl)
+	}
+	if n, _ := s.Incr(ctx, "c", time.Minute); n != 1 {
+		t.Fatalf("counter survived Del: %d", n)
+	}
+}
+
+func TestAccountLockout(t *testing.T) {
+	const uid = 987654
+	ResetFailedLogin(uid)
+	if locked, _ := IsAccountLocked(uid); locked {
+		t.Fatal("fresh account is locked")
+	}
+	RecordFailedLogin(uid)
+	locked, retry := IsAccountLocked(uid)
+	if !locked || retry <= 0 || retry > time.Minute {
+		t.Fatalf("expected 1 minute lock, got %v %v", locked, retry)
+	}
+	RecordFailedLogin(uid)
+	if _, retry := IsAccountLocked(uid); retry <= time.Minute {
+		t.Fatalf("second failure should lock longer, got %v", retry)
+	}
+	ResetFailedLogin(uid)
+	if locked, _ := IsAccountLocked(uid); locked {
+		t.Fatal("reset did not unlock")
+	}
+}
@@ -0,0 +1,386 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"project/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"gopkg.in/yaml.v3"
+)
+
+// Rate-limit policy keys: what a policy counts requests by.
+const (
+	RateLimitKeyIP        = "ip"
+	RateLimitKeyUser      = "user"       // authenticated user, IP for anonymous callers
+	RateLimitKeyUserRoute = "user_route" // user (or IP) per route template
+)
+
+// PolicyDuration is a time.Duration written as "30s", "1m" or "1h" in policy files.
+type PolicyDuration time.Duration
+
+func (d *PolicyDuration) UnmarshalYAML(n *yaml.Node) error {
+	v, err := time.ParseDuration(n.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", n.Line, err)
+	}
+	*d = PolicyDuration(v)
+	return nil
+}
+
+func (d PolicyDuration) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + time.Duration(d).String() + `"`), nil
+}
+
+// RateLimitPolicy limits requests whose path matches one of Paths (and whose method
+// is in Methods, when set). Patterns use path.Match syntax; a trailing "/**"
+// matches the prefix and everything below it.
+type RateLimitPolicy struct {
+	Name      string           `yaml:"name" json:"name"`
+	Paths     []string         `yaml:"paths" json:"paths"`
+	Methods   []string         `yaml:"methods" json:"methods,omitempty"`
+	Key       string           `yaml:"key" json:"key"`
+	Limit     int              `yaml:"limit" json:"limit"`
+	Window    PolicyDuration   `yaml:"window" json:"window"`
+	Penalties []PolicyDuration `yaml:"penalties" json:"penalties,omitempty"`
+	ExemptIPs []string         `yaml:"exempt_ips" json:"exempt_ips,omitempty"`
+}
+
+func matchPathPattern(pattern, p string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return p == prefix || strings.HasPrefix(p, prefix+"/")
+	}
+	ok, _ := path.Match(pattern, p)
+	return ok
+}
+
+func (p *RateLimitPolicy) matches(method, urlPath string) bool {
+	if len(p.Methods) > 0 {
+		found := false
+		for _, m := range p.Methods {
+			if strings.EqualFold(m, method) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, pat := range p.Paths {
+		if matchPathPattern(pat, urlPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// penalty returns the lockout for the level-th violation; the last entry repeats.
+func (p *RateLimitPolicy) penalty(level int64) time.Duration {
+	i := int(level) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(p.Penalties) {
+		i = len(p.Penalties) - 1
+	}
+	return time.Duration(p.Penalties[i])
+}
+
+func (p *RateLimitPolicy) validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("policy without name")
+	}
+	if len(p.Paths) == 0 {
+		return fmt.Errorf("policy %q: paths is required", p.Name)
+	}
+	for _, pat := range p.Paths {
+		if _, err := path.Match(strings.TrimSuffix(pat, "/**"), ""); err != nil {
+			return fmt.Errorf("policy %q: bad path pattern %q", p.Name, pat)
+		}
+	}
+	switch p.Key {
+	case RateLimitKeyIP, RateLimitKeyUser, RateLimitKeyUserRoute:
+	default:
+		return fmt.Errorf("policy %q: key must be ip, user or user_route", p.Name)
+	}
+	if p.Limit <= 0 || p.Window <= 0 {
+		return fmt.Errorf("policy %q: limit and window must be positive", p.Name)
+	}
+	for _, d := range p.Penalties {
+		if d <= 0 {
+			return fmt.Errorf("policy %q: penalties must be positive", p.Name)
+		}
+	}
+	return nil
+}
+
+// defaultRateLimitPolicies are used when RATE_LIMIT_POLICY_FILE is not set. They
+// carry the limits that used to be hardcoded in routes.
+func defaultRateLimitPolicies() []RateLimitPolicy {
+	m := func(d time.Duration) PolicyDuration { return PolicyDuration(d) }
+	penalties := []PolicyDuration{m(time.Minute), m(5 * time.Minute), m(15 * time.Minute), m(30 * time.Minute)}
+	return []RateLimitPolicy{
+		{Name: "webhook", Paths: []string{"/api/payments/linkqu/callback", "/api/payouts/linkqu/callback", "/api/payouts/kyta/webhook"},
+			Key: RateLimitKeyIP, Limit: 500, Window: m(time.Hour), ExemptIPs: []string{"127.0.0.1"}},
+		{Name: "cron", Paths: []string{"/api/cron/**"}, Key: RateLimitKeyIP, Limit: 1000, Window: m(time.Hour)},
+		{Name: "admin_login", Paths: []string{"/api/admin/login", "/api/admin/login/**", "/api/admin/refresh"},
+			Key: RateLimitKeyIP, Limit: 5, Window: m(time.Minute)},
+		{Name: "auth", Paths: []string{"/api/register", "/api/register/otp", "/api/login", "/api/login/2fa", "/api/password/**", "/api/refresh"},
+			Key: RateLimitKeyIP, Limit: 10, Window: m(time.Minute)},
+		{Name: "admin", Paths: []string{"/api/admin/**"}, Key: RateLimitKeyUser, Limit: 500, Window: m(time.Minute)},
+		{Name: "user_read", Paths: []string{"/api/users/**", "/api/bank", "/api/products", "/api/logout", "/api/logout-all"},
+			Methods: []string{http.MethodGet}, Key: RateLimitKeyUser, Limit: 120, Window: m(time.Minute), Penalties: penalties},
+		{Name: "user_write", Paths: []string{"/api/users/**", "/api/logout", "/api/logout-all"},
+			Methods: []string{http.MethodPost, http.MethodPut, http.MethodDelete}, Key: RateLimitKeyUser, Limit: 60, Window: m(time.Minute), Penalties: penalties},
+	}
+}
+
+// RateLimitPolicySet is the loaded policy list; the first matching policy applies.
+type RateLimitPolicySet struct {
+	Policies []RateLimitPolicy `json:"policies"`
+	Source   string            `json:"source"`
+	LoadedAt time.Time         `json:"loaded_at"`
+
+	modTime time.Time
+}
+
+func (s *RateLimitPolicySet) match(method, urlPath string) *RateLimitPolicy {
+	for i := range s.Policies {
+		if s.Policies[i].matches(method, urlPath) {
+			return &s.Policies[i]
+		}
+	}
+	return nil
+}
+
+var (
+	rateLimitPoliciesMu sync.RWMutex
+	rateLimitPolicies   *RateLimitPolicySet
+)
+
+// CurrentRateLimitPolicies returns the active policy set.
+func CurrentRateLimitPolicies() *RateLimitPolicySet {
+	rateLimitPoliciesMu.RLock()
+	set := rateLimitPolicies
+	rateLimitPoliciesMu.RUnlock()
+	if set != nil {
+		return set
+	}
+	return &RateLimitPolicySet{Policies: defaultRateLimitPolicies(), Source: "default"}
+}
+
+// parseRateLimitPolicies reads a YAML (or JSON, which YAML accepts) document of the
+// form {policies: [...]}.
+func parseRateLimitPolicies(raw []byte) ([]RateLimitPolicy, error) {
+	var doc struct {
+		Policies []RateLimitPolicy `yaml:"policies"`
+	}
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for i := range doc.Policies {
+		if err := doc.Policies[i].validate(); err != nil {
+			return nil, err
+		}
+		if seen[doc.Policies[i].Name] {
+			return nil, fmt.Errorf("duplicate policy name %q", doc.Policies[i].Name)
+		}
+		seen[doc.Policies[i].Name] = true
+	}
+	return doc.Policies, nil
+}
+
+// LoadRateLimitPolicies (re)loads RATE_LIMIT_POLICY_FILE, or installs the built-in
+// defaults when it is not set. A file that fails to parse keeps the current set.
+func LoadRateLimitPolicies() error {
+	file := strings.TrimSpace(utils.AppConfig().RateLimit.PolicyFile)
+	set := &RateLimitPolicySet{Policies: defaultRateLimitPolicies(), Source: "default", LoadedAt: time.Now()}
+	if file != "" {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		policies, err := parseRateLimitPolicies(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		set = &RateLimitPolicySet{Policies: policies, Source: file, LoadedAt: time.Now(), modTime: info.ModTime()}
+	}
+	rateLimitPoliciesMu.Lock()
+	rateLimitPolicies = set
+	rateLimitPoliciesMu.Unlock()
+	return nil
+}
+
+// WatchRateLimitPolicies reloads the policy file whenever its modification time
+// changes, polling every RATE_LIMIT_POLICY_RELOAD_SEC seconds (default 10).
+func WatchRateLimitPolicies(ctx context.Context) {
+	cfg := utils.AppConfig().RateLimit
+	file := strings.TrimSpace(cfg.PolicyFile)
+	if file == "" {
+		return
+	}
+	tick := time.NewTicker(time.Duration(cfg.PolicyReloadSec) * time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			info, err := os.Stat(file)
+			if err != nil || info.ModTime().Equal(CurrentRateLimitPolicies().modTime) {
+				continue
+			}
+			if err := LoadRateLimitPolicies(); err != nil {
+				log.Printf("rate limit policy reload failed, keeping current policies: %v", err)
+				continue
+			}
+			log.Printf("rate limit policies reloaded from %s", file)
+		}
+	}
+}
+
+// rateLimitSubject identifies the caller for user-keyed policies. AuthMiddleware
+// runs after the limiter, so the bearer token is checked here (signature and
+// expiry only); callers without a valid token are keyed by IP.
+func rateLimitSubject(r *http.Request, ip string) string {
+	if uid, ok := utils.GetUserID(r); ok {
+		return fmt.Sprintf("u:%d", uid)
+	}
+	if authz := r.Header.Get("Authorization"); strings.HasPrefix(authz, "Bearer ") {
+		if tkn, err := utils.ValidateToken(strings.TrimSpace(strings.TrimPrefix(authz, "Bearer "))); err == nil {
+			if claims, ok := tkn.Claims.(jwt.MapClaims); ok {
+				if id, ok := claims["id"].(float64); ok && id > 0 {
+					if role, _ := claims["role"].(string); role == "admin" {
+						return fmt.Sprintf("a:%d", int64(id))
+					}
+					return fmt.Sprintf("u:%d", int64(id))
+				}
+			}
+		}
+	}
+	return "ip:" + ip
+}
+
+const rateLimitOffenderTTL = 48 * time.Hour
+
+func rateLimitOffenderSet(t time.Time) string {
+	return "rl:offenders:" + t.Format("20060102")
+}
+
+// RateLimitOffender is a caller that was rejected by a policy today.
+type RateLimitOffender struct {
+	Policy  string `json:"policy"`
+	Subject string `json:"subject"`
+	Blocked int64  `json:"blocked"`
+}
+
+// TopRateLimitOffenders returns the callers rejected most often today.
+func TopRateLimitOffenders(ctx context.Context, n int) ([]RateLimitOffender, error) {
+	top, err := DefaultLimiterStore().TopScores(ctx, rateLimitOffenderSet(time.Now()), n)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]RateLimitOffender, 0, len(top))
+	for _, m := range top {
+		policy, subject, _ := strings.Cut(m.Member, "|")
+		out = append(out, RateLimitOffender{Policy: policy, Subject: subject, Blocked: int64(m.Score)})
+	}
+	return out, nil
+}
+
+// RateLimitMiddleware applies the first policy matching the request. It is meant
+// for router.Use so the matched route template is available for user_route keys.
+func RateLimitMiddleware(next http.Handler) http.Handler {
+	trusted := utils.AppConfig().HTTP.TrustedProxies
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		p := CurrentRateLimitPolicies().match(r.Method, r.URL.Path)
+		if p == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ip := clientIPGeneric(r, trusted)
+		for _, ex := range p.ExemptIPs {
+			if strings.TrimSpace(ex) == ip {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		subject := "ip:" + ip
+		if p.Key != RateLimitKeyIP {
+			subject = rateLimitSubject(r, ip)
+		}
+		if p.Key == RateLimitKeyUserRoute {
+			route := r.URL.Path
+			if cr := mux.CurrentRoute(r); cr != nil {
+				if tpl, err := cr.GetPathTemplate(); err == nil {
+					route = tpl
+				}
+			}
+			subject += ":" + r.Method + " " + route
+		}
+
+		ctx := r.Context()
+		store := DefaultLimiterStore()
+		key := "rl:p:" + p.Name + ":" + subject
+		reject := func(retry time.Duration) {
+			_ = store.IncrScore(ctx, rateLimitOffenderSet(time.Now()), p.Name+"|"+subject, 1, rateLimitOffenderTTL)
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(retry.Seconds())))
+			utils.WriteJSON(w, http.StatusTooManyRequests, utils.APIResponse{Success: false, Message: "Terlalu banyak permintaan, silakan coba lagi nanti."})
+		}
+
+		if len(p.Penalties) > 0 {
+			if retry, _ := store.LockTTL(ctx, key+":penalty"); retry > 0 {
+				reject(retry)
+				return
+			}
+		}
+		count, err := store.Hit(ctx, key, time.Duration(p.Window))
+		if err != nil {
+			// fail open: a limiter outage must not take the API down
+			next.ServeHTTP(w, r)
+			return
+		}
+		remaining := p.Limit - count
+		if remaining < 0 {
+			remaining = 0
+		}
+		w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", p.Limit))
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
+		w.Header().Set("X-RateLimit-Policy", p.Name)
+
+		if count > p.Limit {
+			retry := time.Duration(p.Window)
+			if len(p.Penalties) > 0 {
+				// progressive penalty; the level is forgotten an hour after the last one
+				level, _ := store.Incr(ctx, key+":level", time.Hour)
+				retry = p.penalty(level)
+				_ = store.Lock(ctx, key+":penalty", retry)
+			}
+			reject(retry)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
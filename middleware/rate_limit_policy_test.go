@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func useRateLimitPolicies(t *testing.T, policies []RateLimitPolicy) {
+	t.Helper()
+	rateLimitPoliciesMu.Lock()
+	prev := rateLimitPolicies
+	rateLimitPolicies = &RateLimitPolicySet{Policies: policies, Source: "test"}
+	rateLimitPoliciesMu.Unlock()
+	t.Cleanup(func() {
+		rateLimitPoliciesMu.Lock()
+		rateLimitPolicies = prev
+		rateLimitPoliciesMu.Unlock()
+	})
+}
+
+func serveFrom(h http.Handler, method, target, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.RemoteAddr = ip + ":1234"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMatchPathPattern(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/api/login", "/api/login", true},
+		{"/api/login", "/api/login/2fa", false},
+		{"/api/users/**", "/api/users", true},
+		{"/api/users/**", "/api/users/bank/1", true},
+		{"/api/users/**", "/api/usersx", false},
+		{"/api/users/*/qr", "/api/users/12/qr", true},
+		{"/api/users/*/qr", "/api/users/1/2/qr", false},
+	}
+	for _, c := range cases {
+		if got := matchPathPattern(c.pattern, c.path); got != c.want {
+			t.Errorf("%s ~ %s: got %v", c.pattern, c.path, got)
+		}
+	}
+}
+
+func TestParseRateLimitPolicies(t *testing.T) {
+	policies, err := parseRateLimitPolicies([]byte(`
+policies:
+  - name: login
+    paths: ["/api/login"]
+    methods: [POST]
+    key: ip
+    limit: 5
+    window: 1m
+    penalties: [30s, 5m]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policies) != 1 || policies[0].Limit != 5 || time.Duration(policies[0].Window) != time.Minute ||
+		policies[0].penalty(1) != 30*time.Second || policies[0].penalty(9) != 5*time.Minute {
+		t.Fatalf("unexpected policies %+v", policies)
+	}
+
+	// JSON is accepted too
+	if _, err := parseRateLimitPolicies([]byte(`{"policies":[{"name":"a","paths":["/x"],"key":"user","limit":1,"window":"1s"}]}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	bad := []string{
+		`policies: [{name: a, paths: ["/x"], key: device, limit: 1, window: 1s}]`,
+		`policies: [{name: a, paths: ["/x"], key: ip, limit: 0, window: 1s}]`,
+		`policies: [{name: a, paths: ["/x"], key: ip, limit: 1, window: soon}]`,
+		`policies: [{name: a, paths: ["/x["], key: ip, limit: 1, window: 1s}]`,
+		`policies: [{name: a, paths: ["/x"], key: ip, limit: 1, window: 1s}, {name: a, paths: ["/y"], key: ip, limit: 1, window: 1s}]`,
+	}
+	for _, b := range bad {
+		if _, err := parseRateLimitPolicies([]byte(b)); err == nil {
+			t.Errorf("expected error for %s", b)
+		}
+	}
+}
+
+func TestDefaultRateLimitPoliciesAreValid(t *testing.T) {
+	for _, p := range defaultRateLimitPolicies() {
+		if err := p.validate(); err != nil {
+			t.Error(err)
+		}
+	}
+	set := &RateLimitPolicySet{Policies: defaultRateLimitPolicies()}
+	for path, want := range map[string]string{
+		"/api/login":             "auth",
+		"/api/admin/login/2fa":   "admin_login",
+		"/api/admin/users":       "admin",
+		"/api/users/withdrawal":  "user_read",
+		"/api/cron/daily-return": "cron",
+	} {
+		if p := set.match(http.MethodGet, path); p == nil || p.Name != want {
+			t.Errorf("%s: expected policy %s, got %+v", path, want, p)
+		}
+	}
+	if p := set.match(http.MethodGet, "/api/health"); p != nil {
+		t.Errorf("health must not be limited, got %s", p.Name)
+	}
+}
+
+func TestRateLimitMiddlewarePenaltyAndOffenders(t *testing.T) {
+	limiterStoreOnce.Do(func() {})
+	prevStore := limiterStore
+	limiterStore = newMemoryLimiterStore(0)
+	t.Cleanup(func() { limiterStore = prevStore })
+
+	useRateLimitPolicies(t, []RateLimitPolicy{{
+		Name: "tight", Paths: []string{"/api/t/**"}, Key: RateLimitKeyIP, Limit: 2,
+		Window: PolicyDuration(time.Minute), Penalties: []PolicyDuration{PolicyDuration(time.Minute)},
+		ExemptIPs: []string{"127.0.0.1"},
+	}})
+	h := RateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for i, want := range []int{200, 200, 429, 429} {
+		if rec := serveFrom(h, http.MethodGet, "/api/t/x", "203.0.113.20"); rec.Code != want {
+			t.Fatalf("request %d: got %d want %d", i+1, rec.Code, want)
+		}
+	}
+	if rec := serveFrom(h, http.MethodGet, "/api/t/x", "127.0.0.1"); rec.Code != 200 {
+		t.Errorf("exempt ip limited: %d", rec.Code)
+	}
+	if rec := serveFrom(h, http.MethodGet, "/api/other", "203.0.113.20"); rec.Code != 200 {
+		t.Errorf("unmatched path limited: %d", rec.Code)
+	}
+
+	top, err := TopRateLimitOffenders(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 1 || top[0].Policy != "tight" || top[0].Subject != "ip:203.0.113.20" || top[0].Blocked != 2 {
+		t.Fatalf("unexpected offenders %+v", top)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -25,20 +24,14 @@ func generateRequestID() string {
 	return hex.EncodeToString(b)
 }
 
-func getenv(key, def string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
-	}
-	return def
-}
-
-// SecurityHeadersMiddleware sets CORS and security headers. Behavior is env-driven.
+// SecurityHeadersMiddleware sets CORS and security headers from the HTTP settings.
 func SecurityHeadersMiddleware(next http.Handler) http.Handler {
 	// Configurable values
-	env := strings.ToLower(getenv("ENV", "development"))
-	allowedOrigins := getenv("CORS_ALLOWED_ORIGINS", "*")
-	hsts := getenv("SEC_HSTS", "false")
-	csp := getenv("SEC_CSP", "default-src 'none'; frame-ancestors 'none'; base-uri 'self';")
+	cfg := utils.AppConfig()
+	development := cfg.Development()
+	allowedOrigins := cfg.HTTP.CORSAllowedOrigins
+	hsts := cfg.HTTP.HSTS
+	csp := cfg.HTTP.CSP
 
 	// Build a list for CORS matches
 	origins := strings.Split(allowedOrigins, ",")
@@ -68,7 +61,7 @@ func SecurityHeadersMiddleware(next http.Handler) http.Handler {
 		w.Header().Set("X-Frame-Options", "DENY")
 		w.Header().Set("X-Content-Type-Options", "nosniff")
 		w.Header().Set("X-XSS-Protection", "1; mode=block")
-		if env != "development" {
+		if !development {
 			w.Header().Set("Content-Security-Policy", csp)
 		}
 		if hsts == "true" {
@@ -86,8 +79,10 @@ func SecurityHeadersMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// RequestIDMiddleware injects a request id into context and response headers
+// RequestIDMiddleware injects a request id into context and response headers, along
+// with the client IP (X-Forwarded-For is honoured only from TRUSTED_PROXIES)
 func RequestIDMiddleware(next http.Handler) http.Handler {
+	trusted := utils.AppConfig().HTTP.TrustedProxies
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		rid := r.Header.Get("X-Request-ID")
 		if rid == "" {
@@ -95,14 +90,31 @@ func RequestIDMiddleware(next http.Handler) http.Handler {
 		}
 		w.Header().Set("X-Request-ID", rid)
 		ctx := context.WithValue(r.Context(), utils.RequestIDKey, rid)
+		ctx = context.WithValue(ctx, utils.ClientIPKey, clientIPGeneric(r, trusted))
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-// TimeoutMiddleware cancels the request context after a configured timeout
+// eventStreamPaths are the SSE routes that stay open past the request timeout. The
+// exemption is by route, never by request header, so clients cannot opt out of it.
+var eventStreamPaths = map[string]bool{
+	"/api/users/events": true,
+}
+
+// isEventStream reports whether r targets a long-lived SSE route
+func isEventStream(r *http.Request) bool {
+	return r.Method == http.MethodGet && eventStreamPaths[r.URL.Path]
+}
+
+// TimeoutMiddleware cancels the request context after a configured timeout.
+// SSE routes are exempt since they are expected to stay open.
 func TimeoutMiddleware(next http.Handler) http.Handler {
-	timeoutSec := atoi(getenv("REQ_TIMEOUT_SEC", "10"))
+	timeoutSec := utils.AppConfig().HTTP.RequestTimeoutSec
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isEventStream(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
 		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(timeoutSec)*time.Second)
 		defer cancel()
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -131,19 +143,15 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// Simple in-memory metrics and suspicious activity tracker
+// Simple in-memory metrics
 var (
 	metricsMu sync.Mutex
 	// track last N response times per route
 	routeTimes = make(map[string][]time.Duration)
-	// track recent suspicious activity per ip
-	suspiciousMu sync.Mutex
-	suspicious   = make(map[string]int)
 )
 
-// MetricsMiddleware measures response time and tracks slow responses
+// MetricsMiddleware measures response time per route
 func MetricsMiddleware(next http.Handler) http.Handler {
-	slowThresholdMs := atoi(getenv("METRIC_SLOW_MS", "800"))
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		next.ServeHTTP(w, r)
@@ -159,40 +167,5 @@ func MetricsMiddleware(next http.Handler) http.Handler {
 		routeTimes[key] = arr
 		metricsMu.Unlock()
 
-		if elapsed > time.Duration(slowThresholdMs)*time.Millisecond {
-			// increment suspicious counter for the IP
-			ip := r.RemoteAddr
-			suspiciousMu.Lock()
-			suspicious[ip] = suspicious[ip] + 1
-			suspiciousMu.Unlock()
-		}
-	})
-}
-
-// SuspiciousActivityMiddleware flags IPs with repeated slow responses or other signals
-func SuspiciousActivityMiddleware(next http.Handler) http.Handler {
-	threshold := atoi(getenv("SUSPICIOUS_THRESHOLD", "10"))
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ip := r.RemoteAddr
-		suspiciousMu.Lock()
-		count := suspicious[ip]
-		suspiciousMu.Unlock()
-		if count >= threshold {
-			// Return a generic 429 to slow down potential enumeration
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusTooManyRequests)
-			_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "message": "Too many requests"})
-			return
-		}
-		next.ServeHTTP(w, r)
 	})
 }
-
-// Helper: atoi with default
-func atoi(s string) int {
-	v, _ := strconv.Atoi(s)
-	if v <= 0 {
-		return 0
-	}
-	return v
-}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTimeoutMiddlewareExemptsOnlySSERoute(t *testing.T) {
+	hasDeadline := func(path string) bool {
+		var ok bool
+		h := TimeoutMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, ok = r.Context().Deadline()
+		}))
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "text/event-stream")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		return ok
+	}
+	if hasDeadline("/api/users/events") {
+		t.Error("the SSE route must not get a deadline")
+	}
+	if !hasDeadline("/api/users/transactions") {
+		t.Error("an Accept: text/event-stream header must not remove the timeout")
+	}
+}
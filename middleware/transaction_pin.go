@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"project/database"
+	"project/utils"
+)
+
+// SecurityEventPINLocked is recorded when repeated wrong PINs lock the transaction PIN.
+const SecurityEventPINLocked = "transaction_pin_locked"
+
+// Transaction PIN lockout, kept in the shared LimiterStore like the login lockout.
+// PIN_MAX_ATTEMPTS (default 5) wrong PINs within 30 minutes lock the PIN for
+// PIN_LOCK_MINUTES (default 30). While locked no PIN is checked at all, so the lock
+// runs out after PIN_LOCK_MINUTES however often the user retries.
+
+func pinKeys(userID uint) (failKey, lockKey string) {
+	return fmt.Sprintf("pin:fail:u:%d", userID), fmt.Sprintf("pin:lock:u:%d", userID)
+}
+
+// IsPINLocked reports whether the user's transaction PIN is locked and for how long.
+func IsPINLocked(userID uint) (bool, time.Duration) {
+	_, lockKey := pinKeys(userID)
+	ttl, err := DefaultLimiterStore().LockTTL(context.Background(), lockKey)
+	if err != nil || ttl <= 0 {
+		return false, 0
+	}
+	return true, ttl
+}
+
+// RecordFailedPIN counts a wrong PIN and reports whether it locked the PIN.
+func RecordFailedPIN(userID uint) bool {
+	ctx := context.Background()
+	store := DefaultLimiterStore()
+	failKey, lockKey := pinKeys(userID)
+	failures, err := store.Incr(ctx, failKey, 30*time.Minute)
+	cfg := utils.AppConfig().PIN
+	if err != nil || failures < int64(cfg.MaxAttempts) {
+		return false
+	}
+	_ = store.Lock(ctx, lockKey, time.Duration(cfg.LockMinutes)*time.Minute)
+	return true
+}
+
+// ResetFailedPIN clears the failure counter and lock, e.g. after a correct PIN or an
+// OTP reset.
+func ResetFailedPIN(userID uint) {
+	failKey, lockKey := pinKeys(userID)
+	_ = DefaultLimiterStore().Del(context.Background(), failKey, lockKey)
+}
+
+// CheckTransactionPIN verifies pin for userID with lockout and writes the error
+// response when it fails. It returns true when the PIN is correct.
+func CheckTransactionPIN(w http.ResponseWriter, r *http.Request, userID uint, pin string) bool {
+	if locked, retry := IsPINLocked(userID); locked {
+		utils.WriteJSON(w, http.StatusTooManyRequests, utils.APIResponse{
+			Success: false,
+			Message: "PIN transaksi terkunci karena terlalu banyak percobaan. Coba lagi nanti atau reset PIN melalui OTP.",
+			Data:    map[string]interface{}{"pin_locked": true, "retry_after_seconds": int(retry.Seconds())},
+		})
+		return false
+	}
+	if pin == "" {
+		utils.WriteJSON(w, http.StatusForbidden, utils.APIResponse{
+			Success: false,
+			Message: "Masukkan PIN transaksi",
+			Data:    map[string]interface{}{"pin_required": true},
+		})
+		return false
+	}
+
+	err := utils.VerifyTransactionPIN(database.DB, userID, pin)
+	switch {
+	case err == nil:
+		ResetFailedPIN(userID)
+		return true
+	case errors.Is(err, utils.ErrPINNotSet):
+		utils.WriteJSON(w, http.StatusForbidden, utils.APIResponse{
+			Success: false,
+			Message: "Silakan buat PIN transaksi terlebih dahulu",
+			Data:    map[string]interface{}{"pin_setup_required": true},
+		})
+	case errors.Is(err, utils.ErrPINInvalid):
+		ReportAbuse(r, AbuseSignalFailedLogin)
+		if RecordFailedPIN(userID) {
+			utils.RecordSecurityEvent(database.DB, r, SecurityEventPINLocked, userID, nil)
+		}
+		utils.WriteJSON(w, http.StatusForbidden, utils.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    map[string]interface{}{"pin_required": true},
+		})
+	default:
+		status, message := utils.TransactionPINErrorStatus(err)
+		utils.WriteJSON(w, status, utils.APIResponse{Success: false, Message: message})
+	}
+	return false
+}
+
+// RequireTransactionPIN guards withdrawals, bank account changes and password changes
+// with the user's transaction PIN, sent in the X-Transaction-PIN header. Users without
+// a PIN get 403 with pin_setup_required. It must run after AuthMiddleware.
+func RequireTransactionPIN(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, ok := utils.GetUserID(r)
+		if !ok || uid == 0 {
+			utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+			return
+		}
+		if !CheckTransactionPIN(w, r, uid, r.Header.Get(utils.TransactionPINHeader)) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
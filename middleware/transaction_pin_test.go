@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"project/config"
+)
+
+func TestTransactionPINLockout(t *testing.T) {
+	useMemoryLimiterStore(t)
+	useConfig(t, func(cfg *config.Config) {
+		cfg.PIN.MaxAttempts = 3
+		cfg.PIN.LockMinutes = 10
+	})
+	const uid = 424242
+
+	for i := 1; i < 3; i++ {
+		if RecordFailedPIN(uid) {
+			t.Fatalf("failure %d must not lock yet", i)
+		}
+	}
+	if locked, _ := IsPINLocked(uid); locked {
+		t.Fatal("locked before reaching PIN_MAX_ATTEMPTS")
+	}
+	if !RecordFailedPIN(uid) {
+		t.Fatal("third failure must lock")
+	}
+	locked, retry := IsPINLocked(uid)
+	if !locked || retry <= 9*time.Minute || retry > 10*time.Minute {
+		t.Fatalf("expected a 10 minute lock, got %v %s", locked, retry)
+	}
+	if locked, _ := IsAccountLocked(uid); locked {
+		t.Fatal("PIN lockout must not lock the login")
+	}
+	ResetFailedPIN(uid)
+	if locked, _ := IsPINLocked(uid); locked {
+		t.Fatal("reset did not unlock")
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+
+	"project/database"
+	"project/utils"
+)
+
+// RequireTwoFactor is the step-up check for sensitive user actions (withdrawals, bank
+// account changes). Users who enabled 2FA must send a current TOTP or recovery code
+// in the X-2FA-Code header; users without 2FA pass through. Wrong codes count towards
+// the login lockout. It must run after AuthMiddleware.
+func RequireTwoFactor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, ok := utils.GetUserID(r)
+		if !ok || uid == 0 {
+			utils.WriteJSON(w, http.StatusUnauthorized, utils.APIResponse{Success: false, Message: "Unauthorized"})
+			return
+		}
+
+		enabled, err := utils.TwoFactorEnabled(database.DB, utils.TwoFactorOwnerUser, uid)
+		if err != nil {
+			utils.WriteJSON(w, http.StatusInternalServerError, utils.APIResponse{Success: false, Message: "Server error"})
+			return
+		}
+		if !enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if locked, retry := IsAccountLocked(uid); locked {
+			utils.WriteJSON(w, http.StatusTooManyRequests, utils.APIResponse{Success: false, Message: "Terlalu banyak percobaan. Coba lagi nanti.", Data: map[string]interface{}{"retry_after_seconds": int(retry.Seconds())}})
+			return
+		}
+
+		code := r.Header.Get(utils.TwoFactorHeader)
+		if code == "" {
+			utils.WriteJSON(w, http.StatusForbidden, utils.APIResponse{
+				Success: false,
+				Message: "Masukkan kode autentikasi dua faktor",
+				Data:    map[string]interface{}{"two_factor_required": true},
+			})
+			return
+		}
+		if err := utils.VerifyTwoFactor(database.DB, utils.TwoFactorOwnerUser, uid, code); err != nil {
+			RecordFailedLogin(uid)
+			ReportAbuse(r, AbuseSignalFailedLogin)
+			status, message := utils.TwoFactorErrorStatus(err)
+			if status == http.StatusUnauthorized {
+				// the session itself is fine; only the step-up code is wrong
+				status = http.StatusForbidden
+			}
+			utils.WriteJSON(w, status, utils.APIResponse{
+				Success: false,
+				Message: message,
+				Data:    map[string]interface{}{"two_factor_required": true},
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -23,10 +23,12 @@ func ValidateJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error
 	dec := json.NewDecoder(r.Body)
 	dec.DisallowUnknownFields()
 	if err := dec.Decode(dst); err != nil {
+		ReportAbuse(r, AbuseSignalValidation)
 		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Invalid JSON body"})
 		return err
 	}
 	if err := utils.ValidateStruct(dst); err != nil {
+		ReportAbuse(r, AbuseSignalValidation)
 		utils.WriteJSON(w, http.StatusBadRequest, utils.APIResponse{Success: false, Message: "Validation failed", Data: err.Error()})
 		return err
 	}
@@ -0,0 +1,6 @@
+-- Role-based access control for admins (see utils/rbac.go)
+-- Existing admins had full access, keep it by making them superadmins.
+UPDATE admins SET role = 'superadmin' WHERE role = 'admin' OR role IS NULL OR role = '';
+
+ALTER TABLE admins
+  MODIFY role VARCHAR(20) NOT NULL DEFAULT 'support' COMMENT 'superadmin, finance, support, content';
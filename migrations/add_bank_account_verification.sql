@@ -0,0 +1,13 @@
+-- Bank account ownership verification through the LinkQu inquiry API.
+-- review_status = 'Pending' marks accounts whose holder name does not match the
+-- entered or registered name and waits for an admin decision.
+
+ALTER TABLE bank_accounts
+  ADD COLUMN holder_name VARCHAR(100) DEFAULT NULL AFTER account_number,
+  ADD COLUMN verified TINYINT(1) NOT NULL DEFAULT 0 AFTER holder_name,
+  ADD COLUMN verified_at DATETIME DEFAULT NULL AFTER verified,
+  ADD COLUMN name_match_score DECIMAL(3,2) NOT NULL DEFAULT 0 AFTER verified_at,
+  ADD COLUMN review_status ENUM('None','Pending','Approved','Rejected') NOT NULL DEFAULT 'None' AFTER name_match_score,
+  ADD COLUMN reviewed_by INT UNSIGNED DEFAULT NULL AFTER review_status,
+  ADD COLUMN reviewed_at DATETIME DEFAULT NULL AFTER reviewed_by,
+  ADD INDEX idx_review_status (review_status);
@@ -0,0 +1,6 @@
+-- Payout jobs whose transfer outcome is unknown (timeout or 5xx after the transfer
+-- request was sent) wait in NeedsReview for the LinkQu callback or an admin decision
+-- instead of being retried
+
+ALTER TABLE payout_jobs
+  MODIFY status ENUM('Queued','Running','Submitted','NeedsReview','Succeeded','Failed') NOT NULL DEFAULT 'Queued' COMMENT 'Submitted = transfer sent, waiting for LinkQu callback; NeedsReview = transfer outcome unknown';
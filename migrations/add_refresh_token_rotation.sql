@@ -0,0 +1,18 @@
+-- Refresh-token families: rotated tokens are kept so reuse can be detected
+
+ALTER TABLE refresh_tokens
+  ADD COLUMN rotated_at DATETIME DEFAULT NULL COMMENT 'Set when exchanged for a new token; presenting it again revokes the family' AFTER revoked;
+
+CREATE TABLE IF NOT EXISTS security_events (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id INT UNSIGNED DEFAULT NULL,
+  type VARCHAR(64) NOT NULL,
+  ip VARCHAR(45) DEFAULT NULL,
+  user_agent VARCHAR(255) DEFAULT NULL,
+  details TEXT,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  INDEX idx_user_id (user_id),
+  INDEX idx_type (type),
+  INDEX idx_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Suspicious authentication events';
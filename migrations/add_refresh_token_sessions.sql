@@ -0,0 +1,13 @@
+-- Device/session details on user refresh tokens (GET /api/users/sessions)
+
+ALTER TABLE refresh_tokens
+  ADD COLUMN session_id VARCHAR(40) DEFAULT NULL COMMENT 'Public session ID, kept across rotations' AFTER user_id,
+  ADD COLUMN device_name VARCHAR(100) DEFAULT NULL AFTER session_id,
+  ADD COLUMN user_agent VARCHAR(255) DEFAULT NULL AFTER device_name,
+  ADD COLUMN ip VARCHAR(45) DEFAULT NULL AFTER user_agent,
+  ADD COLUMN access_jti VARCHAR(64) DEFAULT NULL COMMENT 'jti of the access token issued with this refresh token' AFTER ip,
+  ADD COLUMN last_used_at DATETIME DEFAULT NULL AFTER access_jti,
+  ADD INDEX idx_refresh_session (session_id);
+
+-- Existing tokens become one session each
+UPDATE refresh_tokens SET session_id = LEFT(SHA2(id, 256), 32) WHERE session_id IS NULL;
@@ -0,0 +1,3 @@
+-- Withdrawals cancelled by the user mark their transaction Cancelled
+ALTER TABLE transactions
+  MODIFY status ENUM('Success','Pending','Failed','Cancelled') NOT NULL DEFAULT 'Pending';
@@ -0,0 +1,5 @@
+-- Transaction PIN required for withdrawals, bank account changes and password changes
+
+ALTER TABLE users
+  ADD COLUMN transaction_pin VARCHAR(255) NULL COMMENT 'bcrypt hash of the 6-digit transaction PIN' AFTER phone_verified_at,
+  ADD COLUMN pin_updated_at DATETIME NULL AFTER transaction_pin;
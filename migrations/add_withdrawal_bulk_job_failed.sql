@@ -0,0 +1,4 @@
+-- Bulk withdrawal jobs that cannot run at all (e.g. settings unavailable) end as Failed
+
+ALTER TABLE withdrawal_bulk_jobs
+  MODIFY status ENUM('Queued','Running','Completed','Failed') NOT NULL DEFAULT 'Queued';
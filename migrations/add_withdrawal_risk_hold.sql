@@ -0,0 +1,12 @@
+-- Withdrawal risk rules: risky requests are created in the Hold state with the
+-- triggered rules stored as JSON in risk_reasons.
+
+ALTER TABLE withdrawals
+  MODIFY status ENUM('Requested','Hold','Approved','Processing','Success','Failed','Rejected','Cancelled') NOT NULL DEFAULT 'Requested',
+  ADD COLUMN risk_score INT NOT NULL DEFAULT 0 AFTER status,
+  ADD COLUMN risk_reasons TEXT AFTER risk_score;
+
+-- Time the account number or bank was last set; drives the recent_bank_change rule.
+-- Existing accounts stay NULL (not recently changed).
+ALTER TABLE bank_accounts
+  ADD COLUMN changed_at DATETIME DEFAULT NULL AFTER reviewed_at;
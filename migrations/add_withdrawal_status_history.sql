@@ -0,0 +1,24 @@
+-- Withdrawal state machine: Requested -> Approved -> Processing -> Success/Failed,
+-- plus Rejected and Cancelled. Existing Pending rows become Requested.
+
+ALTER TABLE withdrawals
+  MODIFY status ENUM('Pending','Requested','Approved','Processing','Success','Failed','Rejected','Cancelled') NOT NULL DEFAULT 'Requested';
+
+UPDATE withdrawals SET status = 'Requested' WHERE status = 'Pending';
+
+ALTER TABLE withdrawals
+  MODIFY status ENUM('Requested','Approved','Processing','Success','Failed','Rejected','Cancelled') NOT NULL DEFAULT 'Requested';
+
+CREATE TABLE IF NOT EXISTS withdrawal_status_history (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  withdrawal_id INT UNSIGNED NOT NULL,
+  from_status VARCHAR(20) NOT NULL,
+  to_status VARCHAR(20) NOT NULL,
+  actor VARCHAR(20) NOT NULL COMMENT 'admin, user, system, linkqu, sfxcr, kyta',
+  actor_id INT UNSIGNED DEFAULT NULL,
+  note VARCHAR(255) DEFAULT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  INDEX idx_withdrawal_id (withdrawal_id),
+  INDEX idx_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Audit trail of withdrawal status changes';
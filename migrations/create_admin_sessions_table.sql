@@ -0,0 +1,18 @@
+-- Admin sessions with rotating refresh tokens
+
+CREATE TABLE IF NOT EXISTS admin_sessions (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  admin_id INT UNSIGNED NOT NULL,
+  token_hash CHAR(64) NOT NULL COMMENT 'SHA-256 of the current refresh token',
+  access_jti VARCHAR(64) DEFAULT NULL COMMENT 'jti of the latest access token',
+  ip VARCHAR(45) DEFAULT NULL,
+  user_agent VARCHAR(255) DEFAULT NULL,
+  expires_at DATETIME NOT NULL,
+  last_used_at DATETIME DEFAULT NULL,
+  revoked_at DATETIME DEFAULT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uniq_token_hash (token_hash),
+  INDEX idx_admin_id (admin_id),
+  INDEX idx_revoked_at (revoked_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Admin login sessions';
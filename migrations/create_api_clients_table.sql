@@ -0,0 +1,43 @@
+-- Managed partner API clients (SFXCR and future integrations)
+
+CREATE TABLE IF NOT EXISTS api_clients (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  name VARCHAR(100) NOT NULL,
+  scopes VARCHAR(255) NOT NULL COMMENT 'Comma separated: read-pending, callback',
+  allowed_ips TEXT COMMENT 'Comma separated IPs/CIDRs, NULL = any',
+  rate_limit_per_minute INT NOT NULL DEFAULT 60,
+  active TINYINT(1) NOT NULL DEFAULT 1,
+  last_used_at DATETIME DEFAULT NULL,
+  last_used_ip VARCHAR(45) DEFAULT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uniq_name (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Partner API clients';
+
+CREATE TABLE IF NOT EXISTS api_client_keys (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  client_id INT UNSIGNED NOT NULL,
+  prefix VARCHAR(16) NOT NULL,
+  key_hash CHAR(64) NOT NULL COMMENT 'SHA-256 of the API key',
+  expires_at DATETIME DEFAULT NULL COMMENT 'Set when rotated; old key valid until then',
+  revoked_at DATETIME DEFAULT NULL,
+  last_used_at DATETIME DEFAULT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uniq_key_hash (key_hash),
+  INDEX idx_client_id (client_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Hashed partner API keys';
+
+-- Seed the SFXCR client with the key previously hardcoded in controllers/sfxcr.go so the
+-- integration keeps working after deploy. That key is in the git history: rotate it with
+-- POST /api/admin/api-clients/{id}/rotate and set allowed_ips as soon as possible.
+INSERT INTO api_clients (name, scopes, rate_limit_per_minute)
+SELECT 'sfxcr', 'read-pending,callback', 60
+WHERE NOT EXISTS (SELECT 1 FROM api_clients WHERE name = 'sfxcr');
+
+INSERT INTO api_client_keys (client_id, prefix, key_hash)
+SELECT id, 'pxloNUadKfH', '58ad31896f703d2d44de22d1342661978a51db11b3888241c84a04677a87dcc7'
+FROM api_clients
+WHERE name = 'sfxcr'
+  AND NOT EXISTS (SELECT 1 FROM api_client_keys WHERE key_hash = '58ad31896f703d2d44de22d1342661978a51db11b3888241c84a04677a87dcc7');
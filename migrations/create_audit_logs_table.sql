@@ -0,0 +1,23 @@
+-- Hash-chained audit log of admin actions
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  admin_id INT UNSIGNED DEFAULT NULL,
+  action VARCHAR(64) NOT NULL,
+  target_type VARCHAR(64) NOT NULL,
+  target_id VARCHAR(64) NOT NULL,
+  `before` TEXT,
+  `after` TEXT,
+  diff TEXT,
+  ip VARCHAR(45) DEFAULT NULL,
+  request_id VARCHAR(64) DEFAULT NULL,
+  prev_hash CHAR(64) NOT NULL COMMENT 'Hash of the previous row, zeros for the first row',
+  hash CHAR(64) NOT NULL COMMENT 'SHA-256 over the row fields and prev_hash',
+  created_at DATETIME NOT NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY uniq_hash (hash),
+  INDEX idx_admin_id (admin_id),
+  INDEX idx_action (action),
+  INDEX idx_audit_target (target_type, target_id),
+  INDEX idx_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Admin audit log';
@@ -0,0 +1,14 @@
+-- Closure table for binary_nodes: one row per (ancestor, descendant) pair, including
+-- the depth-0 self row. Maintained by utils.AssignBinaryNode; fill it for existing
+-- trees with: go run ./cmd/backfill-binary-paths
+
+CREATE TABLE IF NOT EXISTS binary_tree_paths (
+  ancestor INT UNSIGNED NOT NULL,
+  descendant INT UNSIGNED NOT NULL,
+  depth INT NOT NULL,
+  leg VARCHAR(5) NOT NULL DEFAULT '' COMMENT 'left, right; empty for depth 0',
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (ancestor, descendant),
+  INDEX idx_ancestor_depth (ancestor, depth),
+  INDEX idx_descendant (descendant)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Binary tree closure table';
@@ -0,0 +1,19 @@
+-- IP bans from the abuse engine (source=auto) and admins (source=admin)
+
+CREATE TABLE IF NOT EXISTS ip_bans (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  ip VARCHAR(45) NOT NULL,
+  reason VARCHAR(255) DEFAULT NULL,
+  `signal` VARCHAR(32) DEFAULT NULL COMMENT 'failed_login, auth_error, not_found, validation, callback_auth',
+  source VARCHAR(16) NOT NULL COMMENT 'auto, admin',
+  permanent TINYINT(1) NOT NULL DEFAULT 0,
+  expires_at DATETIME DEFAULT NULL,
+  admin_id INT UNSIGNED DEFAULT NULL,
+  lifted_at DATETIME DEFAULT NULL,
+  lifted_by INT UNSIGNED DEFAULT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  INDEX idx_ip (ip),
+  INDEX idx_expires_at (expires_at),
+  INDEX idx_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Blocked client IPs';
@@ -0,0 +1,16 @@
+-- One-time codes for password reset and phone verification
+
+CREATE TABLE IF NOT EXISTS otp_codes (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  phone VARCHAR(20) NOT NULL,
+  purpose VARCHAR(32) NOT NULL COMMENT 'password_reset, register',
+  code_hash CHAR(64) NOT NULL COMMENT 'HMAC-SHA256 of purpose|phone|code',
+  attempts INT NOT NULL DEFAULT 0,
+  expires_at DATETIME NOT NULL,
+  consumed_at DATETIME DEFAULT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  INDEX idx_otp_phone_purpose (phone, purpose)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='OTP codes';
+
+ALTER TABLE users ADD COLUMN phone_verified_at DATETIME DEFAULT NULL AFTER investment_status;
@@ -0,0 +1,38 @@
+-- Asynchronous payout queue for approved withdrawals
+
+-- Withdrawals awaiting the payout worker are marked Processing
+ALTER TABLE withdrawals
+  MODIFY status ENUM('Success','Pending','Processing','Failed') NOT NULL DEFAULT 'Pending';
+
+CREATE TABLE IF NOT EXISTS payout_jobs (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  withdrawal_id INT UNSIGNED NOT NULL,
+  status ENUM('Queued','Running','Submitted','Succeeded','Failed') NOT NULL DEFAULT 'Queued' COMMENT 'Submitted = transfer accepted, waiting for LinkQu callback',
+  attempts INT NOT NULL DEFAULT 0,
+  max_attempts INT NOT NULL DEFAULT 5,
+  next_run_at DATETIME NOT NULL,
+  locked_at DATETIME DEFAULT NULL,
+  last_error TEXT,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uniq_withdrawal_id (withdrawal_id),
+  INDEX idx_status_next_run (status, next_run_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Queued LinkQu payouts';
+
+CREATE TABLE IF NOT EXISTS payout_attempts (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  payout_job_id INT UNSIGNED NOT NULL,
+  withdrawal_id INT UNSIGNED NOT NULL,
+  attempt INT NOT NULL,
+  stage ENUM('inquiry','transfer') NOT NULL,
+  success TINYINT(1) NOT NULL DEFAULT 0,
+  retryable TINYINT(1) NOT NULL DEFAULT 0,
+  response_code VARCHAR(20) DEFAULT NULL,
+  message TEXT,
+  duration_ms BIGINT NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  INDEX idx_payout_job_id (payout_job_id),
+  INDEX idx_withdrawal_id (withdrawal_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Payout provider call log per withdrawal';
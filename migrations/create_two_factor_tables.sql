@@ -0,0 +1,25 @@
+-- TOTP two-factor authentication for admins and users
+
+CREATE TABLE IF NOT EXISTS two_factors (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  owner_type VARCHAR(10) NOT NULL COMMENT 'admin or user',
+  owner_id INT UNSIGNED NOT NULL,
+  secret VARCHAR(64) NOT NULL COMMENT 'Base32 TOTP secret',
+  enabled TINYINT(1) NOT NULL DEFAULT 0 COMMENT '0 = setup pending confirmation',
+  last_used_step BIGINT NOT NULL DEFAULT 0 COMMENT 'Last accepted TOTP step, blocks replay',
+  enabled_at DATETIME DEFAULT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uniq_two_factor_owner (owner_type, owner_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='TOTP enrolments';
+
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  two_factor_id INT UNSIGNED NOT NULL,
+  code_hash CHAR(64) NOT NULL COMMENT 'SHA-256 of the normalised code',
+  used_at DATETIME DEFAULT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  INDEX idx_two_factor_id (two_factor_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Single-use 2FA recovery codes';
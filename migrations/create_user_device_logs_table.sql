@@ -0,0 +1,17 @@
+-- Device fingerprint and IP of every registration and login, used by the
+-- multi-account detector (GET /api/admin/multi-accounts)
+
+CREATE TABLE IF NOT EXISTS user_device_logs (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id INT UNSIGNED NOT NULL,
+  event VARCHAR(16) NOT NULL COMMENT 'register, login',
+  fingerprint VARCHAR(64) DEFAULT NULL COMMENT 'sha256 of the X-Device-Fingerprint header',
+  ip VARCHAR(45) DEFAULT NULL,
+  user_agent VARCHAR(255) DEFAULT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  INDEX idx_user_id (user_id),
+  INDEX idx_fingerprint (fingerprint),
+  INDEX idx_ip (ip),
+  INDEX idx_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Registration and login devices';
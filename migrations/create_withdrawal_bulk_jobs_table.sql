@@ -0,0 +1,32 @@
+-- Bulk approve/reject jobs for withdrawals (POST /api/admin/withdrawals/bulk)
+
+CREATE TABLE IF NOT EXISTS withdrawal_bulk_jobs (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  admin_id INT UNSIGNED NOT NULL,
+  action ENUM('approve','reject') NOT NULL,
+  reason VARCHAR(255) DEFAULT NULL,
+  criteria TEXT COMMENT 'JSON of the ids/filter the job was started with',
+  status ENUM('Queued','Running','Completed') NOT NULL DEFAULT 'Queued',
+  total INT NOT NULL DEFAULT 0,
+  succeeded INT NOT NULL DEFAULT 0,
+  failed INT NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  done_at DATETIME DEFAULT NULL,
+  PRIMARY KEY (id),
+  INDEX idx_admin_id (admin_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS withdrawal_bulk_items (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  bulk_job_id INT UNSIGNED NOT NULL,
+  withdrawal_id INT UNSIGNED NOT NULL,
+  order_id VARCHAR(191) DEFAULT NULL,
+  result ENUM('Pending','Success','Failed') NOT NULL DEFAULT 'Pending',
+  status VARCHAR(20) DEFAULT NULL COMMENT 'withdrawal status after processing',
+  message VARCHAR(255) DEFAULT NULL,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  INDEX idx_bulk_job_id (bulk_job_id),
+  INDEX idx_withdrawal_id (withdrawal_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
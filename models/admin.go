@@ -14,7 +14,7 @@ type Admin struct {
 	Password  string    `json:"-" gorm:"not null"` // Password won't be included in JSON responses
 	Name      string    `json:"name" gorm:"not null"`
 	Email     string    `json:"email" gorm:"unique"`
-	Role      string    `json:"role" gorm:"default:admin"`
+	Role      string    `json:"role" gorm:"type:varchar(20);default:support"` // superadmin, finance, support, content
 	IsActive  bool      `json:"is_active" gorm:"default:true"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// AdminSession is one admin login. The refresh token is stored as a SHA-256 hash and
+// rotated in place on every refresh; AccessJTI is the jti of the latest access token
+// so revoking the session can blacklist it.
+type AdminSession struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	AdminID    uint       `gorm:"not null;index" json:"admin_id"`
+	TokenHash  string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	AccessJTI  string     `gorm:"type:varchar(64)" json:"-"`
+	IP         string     `gorm:"type:varchar(45)" json:"ip"`
+	UserAgent  string     `gorm:"type:varchar(255)" json:"user_agent"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time `gorm:"index" json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (AdminSession) TableName() string {
+	return "admin_sessions"
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// APIClient is a partner integration (e.g. SFXCR) allowed to call the partner API
+type APIClient struct {
+	ID                 uint           `gorm:"primaryKey" json:"id"`
+	Name               string         `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"`
+	Scopes             string         `gorm:"type:varchar(255);not null" json:"-"`
+	AllowedIPs         *string        `gorm:"type:text" json:"-"`
+	RateLimitPerMinute int            `gorm:"not null;default:60" json:"rate_limit_per_minute"`
+	Active             bool           `gorm:"not null;default:1" json:"active"`
+	LastUsedAt         *time.Time     `json:"last_used_at"`
+	LastUsedIP         *string        `gorm:"type:varchar(45)" json:"last_used_ip"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	Keys               []APIClientKey `gorm:"foreignKey:ClientID" json:"keys,omitempty"`
+}
+
+func (APIClient) TableName() string {
+	return "api_clients"
+}
+
+// ScopeList returns the comma separated scopes as a slice
+func (c APIClient) ScopeList() []string {
+	return splitList(c.Scopes)
+}
+
+// HasScope reports whether the client was granted scope
+func (c APIClient) HasScope(scope string) bool {
+	for _, s := range c.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedIPList returns the IP/CIDR allowlist; empty means any IP
+func (c APIClient) AllowedIPList() []string {
+	if c.AllowedIPs == nil {
+		return nil
+	}
+	return splitList(*c.AllowedIPs)
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// APIClientKey is one API key of a client. Only the SHA-256 hash is stored; during
+// rotation the previous key stays valid until ExpiresAt.
+type APIClientKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	ClientID   uint       `gorm:"not null;index" json:"client_id"`
+	Prefix     string     `gorm:"type:varchar(16);not null" json:"prefix"`
+	KeyHash    string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (APIClientKey) TableName() string {
+	return "api_client_keys"
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// AuditLog is one admin action. Rows are hash chained: Hash covers the row fields and
+// PrevHash, so editing or deleting a row breaks every following hash.
+type AuditLog struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	AdminID    *uint     `gorm:"index" json:"admin_id"`
+	Action     string    `gorm:"type:varchar(64);not null;index" json:"action"`
+	TargetType string    `gorm:"type:varchar(64);not null;index:idx_audit_target" json:"target_type"`
+	TargetID   string    `gorm:"type:varchar(64);not null;index:idx_audit_target" json:"target_id"`
+	Before     *string   `gorm:"type:text" json:"before"`
+	After      *string   `gorm:"type:text" json:"after"`
+	Diff       *string   `gorm:"type:text" json:"diff"`
+	IP         string    `gorm:"type:varchar(45)" json:"ip"`
+	RequestID  string    `gorm:"type:varchar(64)" json:"request_id"`
+	PrevHash   string    `gorm:"type:char(64);not null" json:"prev_hash"`
+	Hash       string    `gorm:"type:char(64);not null;uniqueIndex" json:"hash"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
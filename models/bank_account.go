@@ -1,12 +1,22 @@
 package models
 
+import "time"
+
 type BankAccount struct {
-	ID            uint   `gorm:"primaryKey" json:"id"`
-	UserID        uint   `gorm:"not null;index" json:"user_id"`
-	BankID        uint   `gorm:"not null;index" json:"bank_id"`
-	AccountName   string `gorm:"size:100;not null" json:"account_name"`
-	AccountNumber string `gorm:"size:50;not null" json:"account_number"`
-	Bank          *Bank  `gorm:"foreignKey:BankID" json:"bank,omitempty"`
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	UserID         uint       `gorm:"not null;index" json:"user_id"`
+	BankID         uint       `gorm:"not null;index" json:"bank_id"`
+	AccountName    string     `gorm:"size:100;not null" json:"account_name"`
+	AccountNumber  string     `gorm:"size:50;not null" json:"account_number"`
+	HolderName     *string    `gorm:"size:100" json:"holder_name,omitempty"`
+	Verified       bool       `gorm:"not null;default:false" json:"verified"`
+	VerifiedAt     *time.Time `json:"verified_at,omitempty"`
+	NameMatchScore float64    `gorm:"type:decimal(3,2);not null;default:0" json:"name_match_score"`
+	ReviewStatus   string     `gorm:"type:enum('None','Pending','Approved','Rejected');not null;default:'None'" json:"review_status"`
+	ReviewedBy     *uint      `json:"reviewed_by,omitempty"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty"`
+	ChangedAt      *time.Time `json:"changed_at,omitempty"`
+	Bank           *Bank      `gorm:"foreignKey:BankID" json:"bank,omitempty"`
 }
 
 func (BankAccount) TableName() string {
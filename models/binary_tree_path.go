@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// BinaryTreePath adalah closure table dari binary_nodes: satu baris untuk setiap
+// pasangan ancestor-descendant (termasuk dirinya sendiri dengan depth 0).
+// Leg adalah sisi ancestor ("left"/"right") tempat descendant berada, kosong untuk depth 0.
+type BinaryTreePath struct {
+	Ancestor   uint      `gorm:"primaryKey;autoIncrement:false;index:idx_ancestor_depth,priority:1" json:"ancestor"`
+	Descendant uint      `gorm:"primaryKey;autoIncrement:false;index" json:"descendant"`
+	Depth      int       `gorm:"not null;index:idx_ancestor_depth,priority:2" json:"depth"`
+	Leg        string    `gorm:"type:varchar(5);not null;default:''" json:"leg"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (BinaryTreePath) TableName() string {
+	return "binary_tree_paths"
+}
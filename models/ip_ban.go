@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// IPBan blocks a client IP, either temporarily (ExpiresAt) or permanently. Bans are
+// created automatically by the abuse engine or by an admin, and lifted by setting
+// LiftedAt.
+type IPBan struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	IP        string     `gorm:"type:varchar(45);not null;index" json:"ip"`
+	Reason    string     `gorm:"type:varchar(255)" json:"reason"`
+	Signal    string     `gorm:"type:varchar(32)" json:"signal,omitempty"` // abuse signal for automatic bans
+	Source    string     `gorm:"type:varchar(16);not null" json:"source"`  // auto, admin
+	Permanent bool       `gorm:"not null;default:false" json:"permanent"`
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at"`
+	AdminID   *uint      `json:"admin_id,omitempty"`
+	LiftedAt  *time.Time `json:"lifted_at,omitempty"`
+	LiftedBy  *uint      `json:"lifted_by,omitempty"`
+	CreatedAt time.Time  `gorm:"index" json:"created_at"`
+}
+
+func (IPBan) TableName() string {
+	return "ip_bans"
+}
+
+// Active reports whether the ban still blocks at t.
+func (b *IPBan) Active(t time.Time) bool {
+	if b.LiftedAt != nil {
+		return false
+	}
+	return b.Permanent || (b.ExpiresAt != nil && b.ExpiresAt.After(t))
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// OTPCode is a one-time code sent to a phone number. Only an HMAC of the code is
+// stored; a row is dead once consumed, expired or out of attempts.
+type OTPCode struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Phone      string     `gorm:"type:varchar(20);not null;index:idx_otp_phone_purpose" json:"phone"`
+	Purpose    string     `gorm:"type:varchar(32);not null;index:idx_otp_phone_purpose" json:"purpose"`
+	CodeHash   string     `gorm:"type:char(64);not null" json:"-"`
+	Attempts   int        `gorm:"not null;default:0" json:"attempts"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	ConsumedAt *time.Time `json:"consumed_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (OTPCode) TableName() string {
+	return "otp_codes"
+}
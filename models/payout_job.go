@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// PayoutJob is a queued LinkQu transfer for an approved withdrawal
+type PayoutJob struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	WithdrawalID uint       `gorm:"not null;uniqueIndex" json:"withdrawal_id"`
+	Status       string     `gorm:"type:enum('Queued','Running','Submitted','NeedsReview','Succeeded','Failed');not null;default:'Queued';index" json:"status"`
+	Attempts     int        `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts  int        `gorm:"not null;default:5" json:"max_attempts"`
+	NextRunAt    time.Time  `gorm:"not null;index" json:"next_run_at"`
+	LockedAt     *time.Time `json:"locked_at,omitempty"`
+	LastError    *string    `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (PayoutJob) TableName() string {
+	return "payout_jobs"
+}
+
+// PayoutAttempt is one provider call (inquiry or transfer) made for a payout job
+type PayoutAttempt struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	PayoutJobID  uint      `gorm:"not null;index" json:"payout_job_id"`
+	WithdrawalID uint      `gorm:"not null;index" json:"withdrawal_id"`
+	Attempt      int       `gorm:"not null" json:"attempt"`
+	Stage        string    `gorm:"type:enum('inquiry','transfer');not null" json:"stage"`
+	Success      bool      `gorm:"not null;default:0" json:"success"`
+	Retryable    bool      `gorm:"not null;default:0" json:"retryable"`
+	ResponseCode string    `gorm:"type:varchar(20)" json:"response_code"`
+	Message      string    `gorm:"type:text" json:"message"`
+	DurationMs   int64     `gorm:"not null;default:0" json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (PayoutAttempt) TableName() string {
+	return "payout_attempts"
+}
@@ -6,12 +6,23 @@ import (
 	"time"
 )
 
+// RefreshToken is one issued refresh token; ID is the opaque token itself. Rotation
+// creates a new row with the same SessionID (the token family), which is also the
+// public identifier of the login (device) shown in the session list. RotatedAt marks
+// tokens that were exchanged, so presenting one again reveals a stolen token.
 type RefreshToken struct {
-	ID        string    `gorm:"primaryKey;type:char(36)" json:"id"`
-	UserID    uint      `gorm:"index" json:"user_id"`
-	ExpiresAt time.Time `json:"expires_at"`
-	Revoked   bool      `json:"revoked"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         string     `gorm:"primaryKey;type:char(64)" json:"-"`
+	UserID     uint       `gorm:"index" json:"user_id"`
+	SessionID  string     `gorm:"type:varchar(40);index" json:"session_id"`
+	DeviceName string     `gorm:"type:varchar(100)" json:"device_name"`
+	UserAgent  string     `gorm:"type:varchar(255)" json:"user_agent"`
+	IP         string     `gorm:"type:varchar(45)" json:"ip"`
+	AccessJTI  string     `gorm:"type:varchar(64)" json:"-"` // jti of the access token issued with this refresh token
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	Revoked    bool       `json:"revoked"`
+	RotatedAt  *time.Time `json:"rotated_at"`
+	CreatedAt  time.Time  `json:"created_at"`
 }
 
 func NewRefreshToken(userID uint, ttlDays int) (*RefreshToken, error) {
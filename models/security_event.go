@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// SecurityEvent records a suspicious authentication event, e.g. a refresh token that
+// was presented again after it had been rotated.
+type SecurityEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    *uint     `gorm:"index" json:"user_id"`
+	Type      string    `gorm:"type:varchar(64);not null;index" json:"type"`
+	IP        string    `gorm:"type:varchar(45)" json:"ip"`
+	UserAgent string    `gorm:"type:varchar(255)" json:"user_agent"`
+	Details   *string   `gorm:"type:text" json:"details"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (SecurityEvent) TableName() string {
+	return "security_events"
+}
@@ -11,7 +11,7 @@ type Transaction struct {
 	TransactionFlow  string    `gorm:"type:enum('debit','credit');not null" json:"transaction_flow"`
 	TransactionType  string    `gorm:"type:varchar(50);not null" json:"transaction_type"`
 	Message          *string   `gorm:"type:text" json:"message,omitempty"`
-	Status           string    `gorm:"type:enum('Success','Pending','Failed');not null;default:'Pending'" json:"status"`
+	Status           string    `gorm:"type:enum('Success','Pending','Failed','Cancelled');not null;default:'Pending'" json:"status"`
 	CreatedAt        time.Time `json:"-"`
 	UpdatedAt        time.Time `json:"-"`
 }
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// TwoFactor is the TOTP enrolment of an admin or a user. A row with Enabled=false is a
+// pending setup waiting for the first valid code.
+type TwoFactor struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	OwnerType    string     `gorm:"type:varchar(10);not null;uniqueIndex:uniq_two_factor_owner" json:"owner_type"` // admin, user
+	OwnerID      uint       `gorm:"not null;uniqueIndex:uniq_two_factor_owner" json:"owner_id"`
+	Secret       string     `gorm:"type:varchar(64);not null" json:"-"`
+	Enabled      bool       `gorm:"not null;default:false" json:"enabled"`
+	LastUsedStep int64      `gorm:"not null;default:0" json:"-"` // last accepted TOTP step, blocks replay
+	EnabledAt    *time.Time `json:"enabled_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (TwoFactor) TableName() string {
+	return "two_factors"
+}
+
+// TwoFactorRecoveryCode is a hashed single-use recovery code.
+type TwoFactorRecoveryCode struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	TwoFactorID uint       `gorm:"not null;index" json:"two_factor_id"`
+	CodeHash    string     `gorm:"type:char(64);not null" json:"-"`
+	UsedAt      *time.Time `json:"used_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (TwoFactorRecoveryCode) TableName() string {
+	return "two_factor_recovery_codes"
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// UserDeviceLog records the device fingerprint and client IP of a registration or
+// login. The multi-account detector clusters users by these values.
+type UserDeviceLog struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"not null;index" json:"user_id"`
+	Event       string    `gorm:"type:varchar(16);not null" json:"event"`    // register, login
+	Fingerprint string    `gorm:"type:varchar(64);index" json:"fingerprint"` // sha256 of X-Device-Fingerprint, empty if not sent
+	IP          string    `gorm:"type:varchar(45);index" json:"ip"`
+	UserAgent   string    `gorm:"type:varchar(255)" json:"user_agent"`
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+}
+
+func (UserDeviceLog) TableName() string {
+	return "user_device_logs"
+}
@@ -10,7 +10,9 @@ type Withdrawal struct {
 	Charge        float64      `gorm:"type:decimal(15,2);not null;default:0.00" json:"charge"`
 	FinalAmount   float64      `gorm:"type:decimal(15,2);not null" json:"final_amount"`
 	OrderID       string       `gorm:"type:varchar(191);not null;uniqueIndex" json:"order_id"`
-	Status        string       `gorm:"type:enum('Success','Pending','Failed');not null;default:'Pending'" json:"status"`
+	Status        string       `gorm:"type:enum('Requested','Hold','Approved','Processing','Success','Failed','Rejected','Cancelled');not null;default:'Requested'" json:"status"`
+	RiskScore     int          `gorm:"not null;default:0" json:"risk_score"`
+	RiskReasons   *string      `gorm:"type:text" json:"-"`
 	CreatedAt     time.Time    `json:"created_at"`
 	UpdatedAt     time.Time    `json:"updated_at"`
 	BankAccount   *BankAccount `gorm:"foreignKey:BankAccountID" json:"bank_account,omitempty"`
@@ -19,3 +21,19 @@ type Withdrawal struct {
 func (Withdrawal) TableName() string {
 	return "withdrawals"
 }
+
+// WithdrawalStatusHistory records every status change made through utils.TransitionWithdrawal
+type WithdrawalStatusHistory struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	WithdrawalID uint      `gorm:"not null;index" json:"withdrawal_id"`
+	FromStatus   string    `gorm:"type:varchar(20);not null" json:"from_status"`
+	ToStatus     string    `gorm:"type:varchar(20);not null" json:"to_status"`
+	Actor        string    `gorm:"type:varchar(20);not null" json:"actor"`
+	ActorID      *uint     `json:"actor_id,omitempty"`
+	Note         string    `gorm:"type:varchar(255)" json:"note,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (WithdrawalStatusHistory) TableName() string {
+	return "withdrawal_status_history"
+}
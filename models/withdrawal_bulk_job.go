@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// WithdrawalBulkJob is an asynchronous bulk approve/reject run started by an admin
+type WithdrawalBulkJob struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	AdminID   uint       `gorm:"not null;index" json:"admin_id"`
+	Action    string     `gorm:"type:enum('approve','reject');not null" json:"action"`
+	Reason    string     `gorm:"type:varchar(255)" json:"reason,omitempty"`
+	Criteria  string     `gorm:"type:text" json:"criteria"`
+	Status    string     `gorm:"type:enum('Queued','Running','Completed','Failed');not null;default:'Queued'" json:"status"`
+	Total     int        `gorm:"not null;default:0" json:"total"`
+	Succeeded int        `gorm:"not null;default:0" json:"succeeded"`
+	Failed    int        `gorm:"not null;default:0" json:"failed"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DoneAt    *time.Time `json:"done_at,omitempty"`
+}
+
+func (WithdrawalBulkJob) TableName() string {
+	return "withdrawal_bulk_jobs"
+}
+
+// WithdrawalBulkItem is the result of one withdrawal in a bulk job
+type WithdrawalBulkItem struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	BulkJobID    uint      `gorm:"not null;index" json:"bulk_job_id"`
+	WithdrawalID uint      `gorm:"not null;index" json:"withdrawal_id"`
+	OrderID      string    `gorm:"type:varchar(191)" json:"order_id"`
+	Result       string    `gorm:"type:enum('Pending','Success','Failed');not null;default:'Pending'" json:"result"`
+	Status       string    `gorm:"type:varchar(20)" json:"status"`
+	Message      string    `gorm:"type:varchar(255)" json:"message,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (WithdrawalBulkItem) TableName() string {
+	return "withdrawal_bulk_items"
+}
@@ -2,122 +2,162 @@ package routes
 
 import (
 	"net/http"
-	"time"
 
 	"project/controllers/admins"
 	"project/middleware"
+	"project/utils"
 
 	"github.com/gorilla/mux"
 )
 
 func SetAdminRoutes(api *mux.Router) {
-	// Rate limiter for admin login: 5 attempts per IP per minute
-	adminLoginLimiter := middleware.NewIPRateLimiter(5, time.Minute)
-
 	// Public admin routes
-	api.Handle("/admin/login", adminLoginLimiter.Middleware(http.HandlerFunc(admins.Login))).Methods(http.MethodPost)
+	api.Handle("/admin/login", http.HandlerFunc(admins.Login)).Methods(http.MethodPost)
+	api.Handle("/admin/login/2fa", http.HandlerFunc(admins.AdminTwoFactorLogin)).Methods(http.MethodPost)
+	api.Handle("/admin/login/2fa/setup", http.HandlerFunc(admins.AdminTwoFactorSetup)).Methods(http.MethodPost)
+	api.Handle("/admin/refresh", http.HandlerFunc(admins.RefreshAdminToken)).Methods(http.MethodPost)
 
 	// Protected admin routes
 	adminRouter := api.PathPrefix("/admin").Subrouter()
 	adminRouter.Use(middleware.AdminAuthMiddleware)
 
+	// can declares the permission a route requires (see utils/rbac.go for role grants)
+	can := func(permission string, h http.HandlerFunc) http.Handler {
+		return middleware.RequirePermission(permission)(h)
+	}
+
 	// Dashboard stats
-	adminRouter.Handle("/dashboard", http.HandlerFunc(admins.GetDashboardStats)).Methods(http.MethodGet)
+	adminRouter.Handle("/dashboard", can(utils.PermDashboardView, admins.GetDashboardStats)).Methods(http.MethodGet)
 
-	// Admin info
+	// Admin info and own profile: any authenticated admin
 	adminRouter.Handle("/info", http.HandlerFunc(admins.GetAdminInfo)).Methods(http.MethodGet)
 
-	// Admin profile
 	adminRouter.Handle("/profile", http.HandlerFunc(admins.GetAdminProfile)).Methods(http.MethodGet)
 	adminRouter.Handle("/profile", http.HandlerFunc(admins.UpdateAdminProfile)).Methods(http.MethodPut)
 	adminRouter.Handle("/password", http.HandlerFunc(admins.UpdateAdminPassword)).Methods(http.MethodPut)
+	adminRouter.Handle("/2fa/recovery-codes", http.HandlerFunc(admins.RegenerateAdminRecoveryCodes)).Methods(http.MethodPost)
+	adminRouter.Handle("/logout", http.HandlerFunc(admins.AdminLogout)).Methods(http.MethodPost)
+	adminRouter.Handle("/logout-all", http.HandlerFunc(admins.AdminLogoutAll)).Methods(http.MethodPost)
+
+	// Admin management
+	adminRouter.Handle("/admins", can(utils.PermAdminsManage, admins.ListAdmins)).Methods(http.MethodGet)
+	adminRouter.Handle("/admins", can(utils.PermAdminsManage, admins.CreateAdmin)).Methods(http.MethodPost)
+	adminRouter.Handle("/admins/{id:[0-9]+}", can(utils.PermAdminsManage, admins.UpdateAdmin)).Methods(http.MethodPut)
+	adminRouter.Handle("/admins/{id:[0-9]+}/2fa", can(utils.PermAdminsManage, admins.ResetAdminTwoFactor)).Methods(http.MethodDelete)
+	adminRouter.Handle("/roles", can(utils.PermAdminsManage, admins.ListAdminRoles)).Methods(http.MethodGet)
+	adminRouter.Handle("/sessions", can(utils.PermAdminsManage, admins.ListAdminSessions)).Methods(http.MethodGet)
+	adminRouter.Handle("/sessions/{id:[0-9]+}", can(utils.PermAdminsManage, admins.RevokeAdminSession)).Methods(http.MethodDelete)
+
+	// Audit log
+	adminRouter.Handle("/audit-logs", can(utils.PermAuditView, admins.GetAuditLogs)).Methods(http.MethodGet)
+	adminRouter.Handle("/audit-logs/verify", can(utils.PermAuditView, admins.VerifyAuditLogs)).Methods(http.MethodGet)
+	adminRouter.Handle("/security-events", can(utils.PermAuditView, admins.GetSecurityEvents)).Methods(http.MethodGet)
+	adminRouter.Handle("/multi-accounts", can(utils.PermAuditView, admins.GetMultiAccountClusters)).Methods(http.MethodGet)
+	adminRouter.Handle("/rate-limits", can(utils.PermAuditView, admins.GetRateLimits)).Methods(http.MethodGet)
+	adminRouter.Handle("/ip-bans", can(utils.PermAuditView, admins.ListIPBans)).Methods(http.MethodGet)
+	adminRouter.Handle("/ip-bans", can(utils.PermSecurityManage, admins.CreateIPBan)).Methods(http.MethodPost)
+	adminRouter.Handle("/ip-bans/{id:[0-9]+}", can(utils.PermSecurityManage, admins.DeleteIPBan)).Methods(http.MethodDelete)
 
 	// User management
-	adminRouter.Handle("/users", http.HandlerFunc(admins.GetUsers)).Methods(http.MethodGet)
-	adminRouter.Handle("/users/{id:[0-9]+}", http.HandlerFunc(admins.GetUserDetail)).Methods(http.MethodGet)
-	adminRouter.Handle("/users/{id:[0-9]+}", http.HandlerFunc(admins.UpdateUser)).Methods(http.MethodPut)
-	adminRouter.Handle("/users/balance/{id:[0-9]+}", http.HandlerFunc(admins.UpdateUserBalance)).Methods(http.MethodPut)
-	adminRouter.Handle("/users/password/{id:[0-9]+}", http.HandlerFunc(admins.UpdateUserPassword)).Methods(http.MethodPut)
+	adminRouter.Handle("/users", can(utils.PermUsersView, admins.GetUsers)).Methods(http.MethodGet)
+	adminRouter.Handle("/users/{id:[0-9]+}", can(utils.PermUsersView, admins.GetUserDetail)).Methods(http.MethodGet)
+	adminRouter.Handle("/users/{id:[0-9]+}", can(utils.PermUsersEdit, admins.UpdateUser)).Methods(http.MethodPut)
+	adminRouter.Handle("/users/balance/{id:[0-9]+}", can(utils.PermUsersBalance, admins.UpdateUserBalance)).Methods(http.MethodPut)
+	adminRouter.Handle("/users/password/{id:[0-9]+}", can(utils.PermUsersPassword, admins.UpdateUserPassword)).Methods(http.MethodPut)
 
 	// Investment management
-	adminRouter.Handle("/investments", http.HandlerFunc(admins.GetInvestments)).Methods(http.MethodGet)
-	adminRouter.Handle("/investments/{id:[0-9]+}", http.HandlerFunc(admins.GetInvestmentDetail)).Methods(http.MethodGet)
-	adminRouter.Handle("/investments/{id:[0-9]+}/status", http.HandlerFunc(admins.UpdateInvestmentStatus)).Methods(http.MethodPut)
+	adminRouter.Handle("/investments", can(utils.PermInvestmentsView, admins.GetInvestments)).Methods(http.MethodGet)
+	adminRouter.Handle("/investments/{id:[0-9]+}", can(utils.PermInvestmentsView, admins.GetInvestmentDetail)).Methods(http.MethodGet)
+	adminRouter.Handle("/investments/{id:[0-9]+}/status", can(utils.PermInvestmentsEdit, admins.UpdateInvestmentStatus)).Methods(http.MethodPut)
 
 	// Category management
-	adminRouter.Handle("/categories", http.HandlerFunc(admins.ListCategoriesHandler)).Methods(http.MethodGet)
-	adminRouter.Handle("/categories", http.HandlerFunc(admins.CreateCategoryHandler)).Methods(http.MethodPost)
-	adminRouter.Handle("/categories/{id:[0-9]+}", http.HandlerFunc(admins.GetCategoryHandler)).Methods(http.MethodGet)
-	adminRouter.Handle("/categories/{id:[0-9]+}", http.HandlerFunc(admins.UpdateCategoryHandler)).Methods(http.MethodPut)
-	adminRouter.Handle("/categories/{id:[0-9]+}", http.HandlerFunc(admins.DeleteCategoryHandler)).Methods(http.MethodDelete)
+	adminRouter.Handle("/categories", can(utils.PermCatalogManage, admins.ListCategoriesHandler)).Methods(http.MethodGet)
+	adminRouter.Handle("/categories", can(utils.PermCatalogManage, admins.CreateCategoryHandler)).Methods(http.MethodPost)
+	adminRouter.Handle("/categories/{id:[0-9]+}", can(utils.PermCatalogManage, admins.GetCategoryHandler)).Methods(http.MethodGet)
+	adminRouter.Handle("/categories/{id:[0-9]+}", can(utils.PermCatalogManage, admins.UpdateCategoryHandler)).Methods(http.MethodPut)
+	adminRouter.Handle("/categories/{id:[0-9]+}", can(utils.PermCatalogManage, admins.DeleteCategoryHandler)).Methods(http.MethodDelete)
 
 	// Product management
-	adminRouter.Handle("/products", http.HandlerFunc(admins.ListProductsHandler)).Methods(http.MethodGet)
-	adminRouter.Handle("/products", http.HandlerFunc(admins.CreateProductHandler)).Methods(http.MethodPost)
-	adminRouter.Handle("/products/{id:[0-9]+}", http.HandlerFunc(admins.GetProductHandler)).Methods(http.MethodGet)
-	adminRouter.Handle("/products/{id:[0-9]+}", http.HandlerFunc(admins.UpdateProductHandler)).Methods(http.MethodPut)
-	adminRouter.Handle("/products/{id:[0-9]+}", http.HandlerFunc(admins.DeleteProductHandler)).Methods(http.MethodDelete)
+	adminRouter.Handle("/products", can(utils.PermCatalogManage, admins.ListProductsHandler)).Methods(http.MethodGet)
+	adminRouter.Handle("/products", can(utils.PermCatalogManage, admins.CreateProductHandler)).Methods(http.MethodPost)
+	adminRouter.Handle("/products/{id:[0-9]+}", can(utils.PermCatalogManage, admins.GetProductHandler)).Methods(http.MethodGet)
+	adminRouter.Handle("/products/{id:[0-9]+}", can(utils.PermCatalogManage, admins.UpdateProductHandler)).Methods(http.MethodPut)
+	adminRouter.Handle("/products/{id:[0-9]+}", can(utils.PermCatalogManage, admins.DeleteProductHandler)).Methods(http.MethodDelete)
 
 	//Withdrawal management
-	adminRouter.Handle("/withdrawals", http.HandlerFunc(admins.GetWithdrawals)).Methods(http.MethodGet)
-	adminRouter.Handle("/withdrawals/{id:[0-9]+}/approve", http.HandlerFunc(admins.ApproveWithdrawal)).Methods(http.MethodPut)
-	adminRouter.Handle("/withdrawals/{id:[0-9]+}/reject", http.HandlerFunc(admins.RejectWithdrawal)).Methods(http.MethodPut)
+	adminRouter.Handle("/withdrawals", can(utils.PermWithdrawalsView, admins.GetWithdrawals)).Methods(http.MethodGet)
+	adminRouter.Handle("/withdrawals/bulk", can(utils.PermWithdrawalsApprove, admins.BulkWithdrawalHandler)).Methods(http.MethodPost)
+	adminRouter.Handle("/withdrawals/bulk/{id:[0-9]+}", can(utils.PermWithdrawalsView, admins.GetBulkWithdrawalJob)).Methods(http.MethodGet)
+	adminRouter.Handle("/withdrawals/{id:[0-9]+}/approve", can(utils.PermWithdrawalsApprove, admins.ApproveWithdrawal)).Methods(http.MethodPut)
+	adminRouter.Handle("/withdrawals/{id:[0-9]+}/reject", can(utils.PermWithdrawalsApprove, admins.RejectWithdrawal)).Methods(http.MethodPut)
+	adminRouter.Handle("/withdrawals/{id:[0-9]+}/release", can(utils.PermWithdrawalsApprove, admins.ReleaseWithdrawal)).Methods(http.MethodPut)
+	adminRouter.Handle("/withdrawals/{id:[0-9]+}/history", can(utils.PermWithdrawalsView, admins.GetWithdrawalStatusHistory)).Methods(http.MethodGet)
+	adminRouter.Handle("/withdrawals/{id:[0-9]+}/payout-attempts", can(utils.PermWithdrawalsView, admins.GetWithdrawalPayoutAttempts)).Methods(http.MethodGet)
+	adminRouter.Handle("/withdrawals/{id:[0-9]+}/payout-result", can(utils.PermWithdrawalsApprove, admins.ResolveWithdrawalPayout)).Methods(http.MethodPut)
+
+	// Partner API clients
+	adminRouter.Handle("/api-clients", can(utils.PermAPIClientsManage, admins.GetAPIClients)).Methods(http.MethodGet)
+	adminRouter.Handle("/api-clients", can(utils.PermAPIClientsManage, admins.CreateAPIClient)).Methods(http.MethodPost)
+	adminRouter.Handle("/api-clients/{id:[0-9]+}", can(utils.PermAPIClientsManage, admins.UpdateAPIClient)).Methods(http.MethodPut)
+	adminRouter.Handle("/api-clients/{id:[0-9]+}/rotate", can(utils.PermAPIClientsManage, admins.RotateAPIClientKey)).Methods(http.MethodPost)
+	adminRouter.Handle("/api-clients/{id:[0-9]+}/keys/{key_id:[0-9]+}", can(utils.PermAPIClientsManage, admins.RevokeAPIClientKey)).Methods(http.MethodDelete)
 
 	// Bank management
-	adminRouter.Handle("/banks", http.HandlerFunc(admins.GetBanks)).Methods(http.MethodGet)
-	adminRouter.Handle("/banks", http.HandlerFunc(admins.CreateBank)).Methods(http.MethodPost)
-	adminRouter.Handle("/banks/{id:[0-9]+}", http.HandlerFunc(admins.UpdateBank)).Methods(http.MethodPut)
+	adminRouter.Handle("/banks", can(utils.PermBankAccountsView, admins.GetBanks)).Methods(http.MethodGet)
+	adminRouter.Handle("/banks", can(utils.PermBanksManage, admins.CreateBank)).Methods(http.MethodPost)
+	adminRouter.Handle("/banks/{id:[0-9]+}", can(utils.PermBanksManage, admins.UpdateBank)).Methods(http.MethodPut)
 
 	// Bank accounts management
-	adminRouter.Handle("/bank-accounts", http.HandlerFunc(admins.GetBankAccounts)).Methods(http.MethodGet)
+	adminRouter.Handle("/bank-accounts", can(utils.PermBankAccountsView, admins.GetBankAccounts)).Methods(http.MethodGet)
+	adminRouter.Handle("/bank-accounts/{id:[0-9]+}/review", can(utils.PermBankAccountsReview, admins.ReviewBankAccount)).Methods(http.MethodPut)
 
 	// Transaction management
-	adminRouter.Handle("/transactions", http.HandlerFunc(admins.GetTransactions)).Methods(http.MethodGet)
+	adminRouter.Handle("/transactions", can(utils.PermTransactionsView, admins.GetTransactions)).Methods(http.MethodGet)
 
 	// Payment management
-	adminRouter.Handle("/payments", http.HandlerFunc(admins.GetPayments)).Methods(http.MethodGet)
+	adminRouter.Handle("/payments", can(utils.PermTransactionsView, admins.GetPayments)).Methods(http.MethodGet)
 
 	// Spin prize management
-	adminRouter.Handle("/spin-prizes", http.HandlerFunc(admins.GetSpinPrizes)).Methods(http.MethodGet)
-	adminRouter.Handle("/spin-prizes/{id:[0-9]+}", http.HandlerFunc(admins.UpdateSpinPrize)).Methods(http.MethodPut)
+	adminRouter.Handle("/spin-prizes", can(utils.PermRewardsManage, admins.GetSpinPrizes)).Methods(http.MethodGet)
+	adminRouter.Handle("/spin-prizes/{id:[0-9]+}", can(utils.PermRewardsManage, admins.UpdateSpinPrize)).Methods(http.MethodPut)
 
 	// Task management
-	adminRouter.Handle("/tasks", http.HandlerFunc(admins.TaskListHandler)).Methods(http.MethodGet)
-	adminRouter.Handle("/tasks", http.HandlerFunc(admins.CreateTaskHandler)).Methods(http.MethodPost)
-	adminRouter.Handle("/tasks/{id:[0-9]+}", http.HandlerFunc(admins.UpdateTaskHandler)).Methods(http.MethodPut)
+	adminRouter.Handle("/tasks", can(utils.PermContentManage, admins.TaskListHandler)).Methods(http.MethodGet)
+	adminRouter.Handle("/tasks", can(utils.PermContentManage, admins.CreateTaskHandler)).Methods(http.MethodPost)
+	adminRouter.Handle("/tasks/{id:[0-9]+}", can(utils.PermContentManage, admins.UpdateTaskHandler)).Methods(http.MethodPut)
 
-	adminRouter.Handle("/user-tasks", http.HandlerFunc(admins.UserTasksHandler)).Methods(http.MethodGet)
-	adminRouter.Handle("/user-spins", http.HandlerFunc(admins.UserSpinsHandler)).Methods(http.MethodGet)
+	adminRouter.Handle("/user-tasks", can(utils.PermContentManage, admins.UserTasksHandler)).Methods(http.MethodGet)
+	adminRouter.Handle("/user-spins", can(utils.PermContentManage, admins.UserSpinsHandler)).Methods(http.MethodGet)
 
 	// Forum management
-	adminRouter.Handle("/forums", http.HandlerFunc(admins.GetForumsHandler)).Methods(http.MethodGet)
-	adminRouter.Handle("/forums/{id:[0-9]+}/approve", http.HandlerFunc(admins.ApproveForumHandler)).Methods(http.MethodPut)
-	adminRouter.Handle("/forums/{id:[0-9]+}/reject", http.HandlerFunc(admins.RejectForumHandler)).Methods(http.MethodPut)
+	adminRouter.Handle("/forums", can(utils.PermContentManage, admins.GetForumsHandler)).Methods(http.MethodGet)
+	adminRouter.Handle("/forums/{id:[0-9]+}/approve", can(utils.PermContentManage, admins.ApproveForumHandler)).Methods(http.MethodPut)
+	adminRouter.Handle("/forums/{id:[0-9]+}/reject", can(utils.PermContentManage, admins.RejectForumHandler)).Methods(http.MethodPut)
 
 	// Settings management
-	adminRouter.Handle("/settings", http.HandlerFunc(admins.GetSettingsHandler)).Methods(http.MethodGet)
-	adminRouter.Handle("/settings", http.HandlerFunc(admins.UpdateSettingsHandler)).Methods(http.MethodPut)
+	adminRouter.Handle("/settings", can(utils.PermSettingsView, admins.GetSettingsHandler)).Methods(http.MethodGet)
+	adminRouter.Handle("/settings", can(utils.PermSettingsEdit, admins.UpdateSettingsHandler)).Methods(http.MethodPut)
 
 	// Tutorial management
-	adminRouter.Handle("/tutorials", http.HandlerFunc(admins.CreateTutorialHandler)).Methods(http.MethodPost)
-	adminRouter.Handle("/tutorials", http.HandlerFunc(admins.ListTutorialsHandler)).Methods(http.MethodGet)
-	adminRouter.Handle("/tutorials", http.HandlerFunc(admins.UpdateTutorialHandler)).Methods(http.MethodPut)
-	adminRouter.Handle("/tutorials/{id:[0-9]+}", http.HandlerFunc(admins.DeleteTutorialHandler)).Methods(http.MethodDelete)
+	adminRouter.Handle("/tutorials", can(utils.PermContentManage, admins.CreateTutorialHandler)).Methods(http.MethodPost)
+	adminRouter.Handle("/tutorials", can(utils.PermContentManage, admins.ListTutorialsHandler)).Methods(http.MethodGet)
+	adminRouter.Handle("/tutorials", can(utils.PermContentManage, admins.UpdateTutorialHandler)).Methods(http.MethodPut)
+	adminRouter.Handle("/tutorials/{id:[0-9]+}", can(utils.PermContentManage, admins.DeleteTutorialHandler)).Methods(http.MethodDelete)
 
 	// Binary system management
-	adminRouter.Handle("/binary", http.HandlerFunc(admins.GetBinaryStructureAdminHandler)).Methods(http.MethodGet)
-	adminRouter.Handle("/binary/details/{id:[0-9]+}", http.HandlerFunc(admins.GetBinaryDetailsAdminHandler)).Methods(http.MethodGet)
-	adminRouter.Handle("/binary/claim", http.HandlerFunc(admins.ClaimRewardAdminHandler)).Methods(http.MethodPost)
-	adminRouter.Handle("/binary/rewards", http.HandlerFunc(admins.GetBinaryRewardsAdminHandler)).Methods(http.MethodGet)
+	adminRouter.Handle("/binary", can(utils.PermBinaryView, admins.GetBinaryStructureAdminHandler)).Methods(http.MethodGet)
+	adminRouter.Handle("/binary/details/{id:[0-9]+}", can(utils.PermBinaryView, admins.GetBinaryDetailsAdminHandler)).Methods(http.MethodGet)
+	adminRouter.Handle("/binary/claim", can(utils.PermBinaryClaim, admins.ClaimRewardAdminHandler)).Methods(http.MethodPost)
+	adminRouter.Handle("/binary/rewards", can(utils.PermBinaryView, admins.GetBinaryRewardsAdminHandler)).Methods(http.MethodGet)
 
 	// Reward management
-	adminRouter.Handle("/rewards", http.HandlerFunc(admins.ListRewardsHandler)).Methods(http.MethodGet)
-	adminRouter.Handle("/rewards", http.HandlerFunc(admins.CreateRewardHandler)).Methods(http.MethodPost)
-	adminRouter.Handle("/rewards", http.HandlerFunc(admins.UpdateRewardHandler)).Methods(http.MethodPut)
-	adminRouter.Handle("/rewards/{id:[0-9]+}", http.HandlerFunc(admins.DeleteRewardHandler)).Methods(http.MethodDelete)
+	adminRouter.Handle("/rewards", can(utils.PermRewardsManage, admins.ListRewardsHandler)).Methods(http.MethodGet)
+	adminRouter.Handle("/rewards", can(utils.PermRewardsManage, admins.CreateRewardHandler)).Methods(http.MethodPost)
+	adminRouter.Handle("/rewards", can(utils.PermRewardsManage, admins.UpdateRewardHandler)).Methods(http.MethodPut)
+	adminRouter.Handle("/rewards/{id:[0-9]+}", can(utils.PermRewardsManage, admins.DeleteRewardHandler)).Methods(http.MethodDelete)
 
 	// Deposit management
-	adminRouter.Handle("/deposits", http.HandlerFunc(admins.GetDeposits)).Methods(http.MethodGet)
-	adminRouter.Handle("/deposits/{id:[0-9]+}/approve", http.HandlerFunc(admins.ApproveDeposit)).Methods(http.MethodPut)
-	adminRouter.Handle("/deposits/{id:[0-9]+}/reject", http.HandlerFunc(admins.RejectDeposit)).Methods(http.MethodPut)
+	adminRouter.Handle("/deposits", can(utils.PermDepositsView, admins.GetDeposits)).Methods(http.MethodGet)
+	adminRouter.Handle("/deposits/{id:[0-9]+}/approve", can(utils.PermDepositsApprove, admins.ApproveDeposit)).Methods(http.MethodPut)
+	adminRouter.Handle("/deposits/{id:[0-9]+}/reject", can(utils.PermDepositsApprove, admins.RejectDeposit)).Methods(http.MethodPut)
 }
@@ -10,6 +10,7 @@ import (
 	"project/controllers/admins"
 	"project/controllers/users"
 	"project/middleware"
+	"project/utils"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -27,7 +28,7 @@ func InitRouter() *mux.Router {
 		return handlers.CORS(
 			handlers.AllowedOrigins([]string{"https://ciroos.ca", "https://stoneform.co.id", "https://api.stoneform.co.id", "http://localhost:3000"}),
 			handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-			handlers.AllowedHeaders([]string{"Content-Type", "Authorization", "X-VLA-KEY", "X-CRON-KEY"}),
+			handlers.AllowedHeaders([]string{"Content-Type", "Authorization", "X-VLA-KEY", "X-CRON-KEY", "X-API-Key", "X-2FA-Code", "X-Device-Name", "X-Device-Fingerprint", "X-Transaction-PIN"}),
 			handlers.AllowCredentials(),
 		)(next)
 	})
@@ -37,27 +38,30 @@ func InitRouter() *mux.Router {
 	// Add catch-all OPTIONS handler for CORS preflight
 	api.PathPrefix("/").HandlerFunc(optionsHandler).Methods(http.MethodOptions)
 
-	// Rate limiter untuk cron: 1000/jam
-	cronLimiter := middleware.NewIPRateLimiter(1000, time.Hour)
-	// Rate limiter untuk webhook: 500/ip, whitelist, sliding window
-	webhookLimiter := middleware.NewWebhookLimiter(500, time.Hour, []string{"127.0.0.1" /* tambahkan IP whitelist di sini */})
+	// Rate limits per route pattern (cron, webhook, login, user, admin); see
+	// RATE_LIMIT_POLICY_FILE and middleware/rate_limit_policy.go
+	api.Use(middleware.RateLimitMiddleware)
 
 	sfxcrController := controllers.NewSFXCRController(database.DB)
 
-	api.Handle("/sfxcr/withdrawals/pending", http.HandlerFunc(sfxcrController.GetPendingWithdrawals)).Methods(http.MethodGet)
-	api.Handle("/sfxcr/withdrawals/pending/{order_id}", http.HandlerFunc(sfxcrController.GetPendingWithdrawalByOrderID)).Methods(http.MethodGet)
-	api.Handle("/sfxcr/withdrawals/callback", http.HandlerFunc(sfxcrController.WithdrawalCallback)).Methods(http.MethodPost)
+	// Partner API, authenticated per client (api_clients) with scopes
+	readPending := middleware.APIClientMiddleware(utils.APIScopeReadPending)
+	partnerCallback := middleware.APIClientMiddleware(utils.APIScopeCallback)
+
+	api.Handle("/sfxcr/withdrawals/pending", readPending(http.HandlerFunc(sfxcrController.GetPendingWithdrawals))).Methods(http.MethodGet)
+	api.Handle("/sfxcr/withdrawals/pending/{order_id}", readPending(http.HandlerFunc(sfxcrController.GetPendingWithdrawalByOrderID))).Methods(http.MethodGet)
+	api.Handle("/sfxcr/withdrawals/callback", partnerCallback(http.HandlerFunc(sfxcrController.WithdrawalCallback))).Methods(http.MethodPost)
 
 	// Cron endpoint for daily returns (protected via X-CRON-KEY header)
-	api.Handle("/cron/daily-returns", cronLimiter.Middleware(http.HandlerFunc(users.CronDailyReturnsHandler))).Methods(http.MethodPost)
+	api.Handle("/cron/daily-returns", http.HandlerFunc(users.CronDailyReturnsHandler)).Methods(http.MethodPost)
 
 	// LinkQu payment callback (no auth, whitelist, sliding window)
-	api.Handle("/payments/linkqu/callback", webhookLimiter.Middleware(http.HandlerFunc(users.LinkQuCallbackHandler))).Methods(http.MethodPost)
+	api.Handle("/payments/linkqu/callback", http.HandlerFunc(users.LinkQuCallbackHandler)).Methods(http.MethodPost)
 
 	// LinkQu payout callback (withdrawal)
-	api.Handle("/payouts/linkqu/callback", webhookLimiter.Middleware(http.HandlerFunc(admins.LinkQuPayoutCallbackHandler))).Methods(http.MethodPost)
+	api.Handle("/payouts/linkqu/callback", http.HandlerFunc(admins.LinkQuPayoutCallbackHandler)).Methods(http.MethodPost)
 
-	api.Handle("/payouts/kyta/webhook", webhookLimiter.Middleware(http.HandlerFunc(admins.KytaPayoutWebhookHandler))).Methods(http.MethodPost)
+	api.Handle("/payouts/kyta/webhook", http.HandlerFunc(admins.KytaPayoutWebhookHandler)).Methods(http.MethodPost)
 
 	// Example protected endpoint using JWT middleware
 	api.Handle("/ping", middleware.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -82,6 +86,10 @@ func InitRouter() *mux.Router {
 		})
 	})).Methods(http.MethodGet)
 
+	// Public JWT verification keys
+	r.Handle("/.well-known/jwks.json", http.HandlerFunc(controllers.JWKSHandler)).Methods(http.MethodGet)
+	api.Handle("/.well-known/jwks.json", http.HandlerFunc(controllers.JWKSHandler)).Methods(http.MethodGet)
+
 	// Payment settings endpoints (protected by static header)
 	api.Handle("/payment_info", http.HandlerFunc(controllers.GetPaymentInfo)).Methods(http.MethodGet)
 	api.Handle("/payment_info", http.HandlerFunc(controllers.PutPaymentInfo)).Methods(http.MethodPut)
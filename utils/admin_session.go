@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"project/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AdminSessionKey is the context key holding the session ID of the admin access token
+const AdminSessionKey = contextKey("adminSession")
+
+var ErrInvalidAdminSession = errors.New("sesi admin tidak valid atau sudah berakhir")
+
+// AdminAccessTTL is the lifetime of admin access tokens (ADMIN_ACCESS_TTL_MINUTES, default 15).
+func AdminAccessTTL() time.Duration {
+	return time.Duration(AppConfig().AdminSession.AccessTTLMinutes) * time.Minute
+}
+
+// AdminRefreshTTL is the lifetime of an admin session (ADMIN_REFRESH_TTL_HOURS, default 12).
+// Refreshing does not extend it, so admins log in again at least this often.
+func AdminRefreshTTL() time.Duration {
+	return time.Duration(AppConfig().AdminSession.RefreshTTLHours) * time.Hour
+}
+
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func requestUserAgent(r *http.Request) string {
+	ua := r.UserAgent()
+	if len(ua) > 255 {
+		ua = ua[:255]
+	}
+	return ua
+}
+
+// generateAdminAccessToken signs a short-lived admin token bound to session sid.
+func generateAdminAccessToken(admin *models.Admin, sid uint) (string, string, time.Time, error) {
+	jti, err := generateJTI(32)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	now := time.Now()
+	exp := now.Add(AdminAccessTTL())
+	claims := jwt.MapClaims{
+		"id":       admin.ID,
+		"username": admin.Username,
+		"role":     "admin",
+		"sid":      sid,
+		"exp":      exp.Unix(),
+		"iat":      now.Unix(),
+		"nbf":      now.Unix(),
+		"jti":      jti,
+		"aud":      AppConfig().JWT.Audience,
+		"iss":      AppConfig().JWT.Issuer,
+	}
+	signed, err := signJWT(claims)
+	return signed, jti, exp, err
+}
+
+// AdminTokens is what login and refresh return to the admin panel.
+type AdminTokens struct {
+	AccessToken  string
+	AccessExpire time.Time
+	RefreshToken string
+	Session      *models.AdminSession
+}
+
+// CreateAdminSession starts a session for admin and issues its first token pair.
+func CreateAdminSession(db *gorm.DB, admin *models.Admin, r *http.Request) (*AdminTokens, error) {
+	refresh, err := generateJTI(48)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := models.AdminSession{
+		AdminID:    uint(admin.ID),
+		TokenHash:  hashSessionToken("ars_" + refresh),
+		IP:         GetClientIP(r),
+		UserAgent:  requestUserAgent(r),
+		ExpiresAt:  now.Add(AdminRefreshTTL()),
+		LastUsedAt: now,
+	}
+	if err := db.Create(&session).Error; err != nil {
+		return nil, err
+	}
+	access, jti, exp, err := generateAdminAccessToken(admin, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Model(&session).Update("access_jti", jti).Error; err != nil {
+		return nil, err
+	}
+	session.AccessJTI = jti
+	return &AdminTokens{AccessToken: access, AccessExpire: exp, RefreshToken: "ars_" + refresh, Session: &session}, nil
+}
+
+// RotateAdminSession exchanges a refresh token for a new token pair. The presented
+// token stops working and the previous access token is blacklisted.
+func RotateAdminSession(db *gorm.DB, refreshToken string, r *http.Request) (*AdminTokens, error) {
+	var tokens *AdminTokens
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var session models.AdminSession
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", hashSessionToken(refreshToken)).First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidAdminSession
+			}
+			return err
+		}
+		if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+			return ErrInvalidAdminSession
+		}
+
+		var admin models.Admin
+		if err := tx.Where("id = ? AND is_active = ?", session.AdminID, true).First(&admin).Error; err != nil {
+			return ErrInvalidAdminSession
+		}
+
+		refresh, err := generateJTI(48)
+		if err != nil {
+			return err
+		}
+		access, jti, exp, err := generateAdminAccessToken(&admin, session.ID)
+		if err != nil {
+			return err
+		}
+		previousJTI := session.AccessJTI
+		session.TokenHash = hashSessionToken("ars_" + refresh)
+		session.AccessJTI = jti
+		session.IP = GetClientIP(r)
+		session.UserAgent = requestUserAgent(r)
+		session.LastUsedAt = time.Now()
+		if err := tx.Save(&session).Error; err != nil {
+			return err
+		}
+		if previousJTI != "" {
+			_ = RevokeJTI(previousJTI, AdminAccessTTL())
+		}
+		tokens = &AdminTokens{AccessToken: access, AccessExpire: exp, RefreshToken: "ars_" + refresh, Session: &session}
+		return nil
+	})
+	return tokens, err
+}
+
+// RevokeAdminSessions revokes the matching active sessions and blacklists their
+// current access tokens. It returns the number of sessions revoked.
+func RevokeAdminSessions(db *gorm.DB, where string, args ...interface{}) (int, error) {
+	var sessions []models.AdminSession
+	if err := db.Where(where, args...).Where("revoked_at IS NULL").Find(&sessions).Error; err != nil {
+		return 0, err
+	}
+	if len(sessions) == 0 {
+		return 0, nil
+	}
+	ids := make([]uint, 0, len(sessions))
+	for _, s := range sessions {
+		ids = append(ids, s.ID)
+	}
+	if err := db.Model(&models.AdminSession{}).Where("id IN ?", ids).Update("revoked_at", time.Now()).Error; err != nil {
+		return 0, err
+	}
+	for _, s := range sessions {
+		if s.AccessJTI != "" {
+			_ = RevokeJTI(s.AccessJTI, AdminAccessTTL())
+		}
+	}
+	return len(sessions), nil
+}
+
+// GetAdminSessionID returns the session of the authenticated admin token (set by AdminAuthMiddleware).
+func GetAdminSessionID(r *http.Request) (uint, bool) {
+	id, ok := r.Context().Value(AdminSessionKey).(uint)
+	return id, ok
+}
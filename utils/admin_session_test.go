@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"project/config"
+	"project/models"
+)
+
+func TestAdminSessionTTL(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("ADMIN_ACCESS_TTL_MINUTES", "")
+	t.Setenv("ADMIN_REFRESH_TTL_HOURS", "")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	useAppConfig(t, cfg)
+	if AdminAccessTTL() != 15*time.Minute || AdminRefreshTTL() != 12*time.Hour {
+		t.Fatalf("unexpected defaults %v %v", AdminAccessTTL(), AdminRefreshTTL())
+	}
+
+	cfg.AdminSession.AccessTTLMinutes = 5
+	if AdminAccessTTL() != 5*time.Minute {
+		t.Errorf("override ignored: %v", AdminAccessTTL())
+	}
+}
+
+func TestHashSessionToken(t *testing.T) {
+	a, b := hashSessionToken("ars_a"), hashSessionToken("ars_b")
+	if len(a) != 64 || a == b || a != hashSessionToken("ars_a") {
+		t.Errorf("unexpected hashes %s %s", a, b)
+	}
+}
+
+func TestRefreshTokenSessionID(t *testing.T) {
+	legacy := models.RefreshToken{ID: "rt_legacy"}
+	id := refreshTokenSessionID(legacy)
+	if len(id) != 32 || id != refreshTokenSessionID(legacy) {
+		t.Errorf("legacy token should get a stable derived session ID, got %q", id)
+	}
+	if got := refreshTokenSessionID(models.RefreshToken{ID: "rt_new", SessionID: "sess"}); got != "sess" {
+		t.Errorf("stored session ID should win, got %q", got)
+	}
+}
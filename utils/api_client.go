@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"project/models"
+)
+
+// Partner API scopes (models.APIClient.Scopes)
+const (
+	APIScopeReadPending = "read-pending"
+	APIScopeCallback    = "callback"
+)
+
+// APIClientKey is the context key holding the authenticated *models.APIClient
+const APIClientKey = contextKey("apiClient")
+
+// IsValidAPIScope reports whether scope is a known partner API scope.
+func IsValidAPIScope(scope string) bool {
+	return scope == APIScopeReadPending || scope == APIScopeCallback
+}
+
+// HashAPIKey returns the hex SHA-256 digest stored in api_client_keys.key_hash.
+// Keys are long random strings, so a fast hash is sufficient and allows lookup.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateAPIKey creates a new partner API key. The plain key is shown to the admin
+// once; only the prefix (for identification) and hash are persisted.
+func GenerateAPIKey() (plain, prefix, hash string, err error) {
+	b := make([]byte, 24)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	plain = "pk_" + hex.EncodeToString(b)
+	return plain, plain[:11], HashAPIKey(plain), nil
+}
+
+// APIKeyRotationOverlap is how long the previous key keeps working after a rotation
+// (API_KEY_ROTATION_OVERLAP_HOURS, default 24).
+func APIKeyRotationOverlap() time.Duration {
+	return time.Duration(AppConfig().APIClients.KeyRotationOverlapHours) * time.Hour
+}
+
+// IPAllowed reports whether ip matches one of the IPs or CIDRs in allowlist.
+// An empty allowlist allows every IP.
+func IPAllowed(allowlist []string, ip string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, entry := range allowlist {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			if _, ipnet, err := net.ParseCIDR(entry); err == nil && ipnet.Contains(parsed) {
+				return true
+			}
+			continue
+		}
+		if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAPIClient returns the partner client authenticated by middleware.APIClientMiddleware
+func GetAPIClient(r *http.Request) (*models.APIClient, bool) {
+	c, ok := r.Context().Value(APIClientKey).(*models.APIClient)
+	return c, ok
+}
@@ -0,0 +1,38 @@
+package utils
+
+import "testing"
+
+func TestIPAllowed(t *testing.T) {
+	allow := []string{"10.0.0.0/24", "203.0.113.7"}
+	cases := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.0.0.15", true},
+		{"10.0.1.15", false},
+		{"203.0.113.7", true},
+		{"203.0.113.8", false},
+		{"not-an-ip", false},
+	}
+	for _, c := range cases {
+		if got := IPAllowed(allow, c.ip); got != c.want {
+			t.Errorf("IPAllowed(%s) = %v, want %v", c.ip, got, c.want)
+		}
+	}
+	if !IPAllowed(nil, "198.51.100.1") {
+		t.Error("empty allowlist should allow every IP")
+	}
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	plain, prefix, hash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plain) != 51 || plain[:len(prefix)] != prefix {
+		t.Fatalf("unexpected key %q prefix %q", plain, prefix)
+	}
+	if hash != HashAPIKey(plain) || len(hash) != 64 {
+		t.Fatalf("hash mismatch")
+	}
+}
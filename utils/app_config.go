@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"project/config"
+)
+
+var (
+	appConfigMu sync.RWMutex
+	appConfig   *config.Config
+)
+
+// SetConfig installs the configuration loaded at startup. Every setting outside the
+// database and Redis connections is read from it.
+func SetConfig(cfg *config.Config) {
+	appConfigMu.Lock()
+	appConfig = cfg
+	appConfigMu.Unlock()
+}
+
+// AppConfig returns the installed configuration. Without SetConfig (tests, one-off
+// tools) it is loaded from the environment on first use; a configuration that cannot
+// be loaded panics instead of running with empty credentials.
+func AppConfig() *config.Config {
+	appConfigMu.RLock()
+	cfg := appConfig
+	appConfigMu.RUnlock()
+	if cfg != nil {
+		return cfg
+	}
+
+	appConfigMu.Lock()
+	defer appConfigMu.Unlock()
+	if appConfig == nil {
+		log.Printf("config: SetConfig was not called, loading from the environment")
+		loaded, err := config.Load()
+		if err != nil {
+			panic(fmt.Sprintf("config: %v", err))
+		}
+		appConfig = loaded
+	}
+	return appConfig
+}
@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"project/database"
+	"project/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ClientIPKey is the context key holding the client IP resolved by RequestIDMiddleware
+const ClientIPKey = contextKey("clientIP")
+
+// Audit actions
+const (
+	AuditUserBalance         = "user.balance"
+	AuditUserPassword        = "user.password"
+	AuditWithdrawalApprove   = "withdrawal.approve"
+	AuditWithdrawalReject    = "withdrawal.reject"
+	AuditWithdrawalPayout    = "withdrawal.payout_resolve"
+	AuditWithdrawalRelease   = "withdrawal.release"
+	AuditBankAccountReview   = "bank_account.review"
+	AuditSettingsUpdate      = "settings.update"
+	AuditSpinPrizeUpdate     = "spin_prize.update"
+	AuditRewardClaim         = "reward.claim"
+	AuditAdminTwoFactorReset = "admin.2fa_reset"
+	AuditAdminSessionRevoke  = "admin.session_revoke"
+	AuditAdminCreate         = "admin.create"
+	AuditAdminUpdate         = "admin.update"
+	AuditAdminRole           = "admin.role"
+	AuditAdminPassword       = "admin.password"
+	AuditAPIClientCreate     = "api_client.create"
+	AuditAPIClientUpdate     = "api_client.update"
+	AuditAPIClientKeyRotate  = "api_client.key_rotate"
+	AuditAPIClientKeyRevoke  = "api_client.key_revoke"
+	AuditIPBan               = "ip.ban"
+	AuditIPUnban             = "ip.unban"
+)
+
+// AuditRedacted replaces secrets in before/after snapshots
+const AuditRedacted = "[redacted]"
+
+// auditGenesisHash is the PrevHash of the first row
+var auditGenesisHash = strings.Repeat("0", 64)
+
+// GetClientIP returns the client IP set by RequestIDMiddleware.
+func GetClientIP(r *http.Request) string {
+	ip, _ := r.Context().Value(ClientIPKey).(string)
+	return ip
+}
+
+// GetRequestID returns the request ID set by RequestIDMiddleware.
+func GetRequestID(r *http.Request) string {
+	id, _ := r.Context().Value(RequestIDKey).(string)
+	return id
+}
+
+// auditSnapshot converts a value to a flat JSON object map.
+func auditSnapshot(v interface{}) map[string]interface{} {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return map[string]interface{}{"value": json.RawMessage(b)}
+	}
+	return m
+}
+
+// AuditDiff returns the fields that differ between two snapshots as
+// {"field": {"from": x, "to": y}}. Either side may be nil.
+func AuditDiff(before, after interface{}) map[string]map[string]interface{} {
+	b, a := auditSnapshot(before), auditSnapshot(after)
+	diff := map[string]map[string]interface{}{}
+	for k, av := range a {
+		bv, ok := b[k]
+		if !ok || !reflect.DeepEqual(av, bv) {
+			diff[k] = map[string]interface{}{"from": bv, "to": av}
+		}
+	}
+	for k, bv := range b {
+		if _, ok := a[k]; !ok {
+			diff[k] = map[string]interface{}{"from": bv, "to": nil}
+		}
+	}
+	return diff
+}
+
+func auditJSON(v interface{}) *string {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return nil
+	}
+	s := string(b)
+	return &s
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// AuditLogHash computes the chain hash of a row from its fields and PrevHash.
+func AuditLogHash(l models.AuditLog) string {
+	adminID := ""
+	if l.AdminID != nil {
+		adminID = fmt.Sprint(*l.AdminID)
+	}
+	fields := []string{
+		l.PrevHash, adminID, l.Action, l.TargetType, l.TargetID,
+		derefString(l.Before), derefString(l.After), derefString(l.Diff),
+		l.IP, l.RequestID, l.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
+
+// AppendAuditLog links entry to the latest row and inserts it. The latest row is
+// locked so concurrent writers extend the chain one at a time.
+func AppendAuditLog(db *gorm.DB, entry models.AuditLog) (*models.AuditLog, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var last models.AuditLog
+		res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("id DESC").Limit(1).Find(&last)
+		if res.Error != nil {
+			return res.Error
+		}
+		entry.PrevHash = auditGenesisHash
+		if res.RowsAffected > 0 {
+			entry.PrevHash = last.Hash
+		}
+		// DATETIME keeps whole seconds; hash what will be stored
+		entry.CreatedAt = time.Now().Truncate(time.Second)
+		entry.Hash = AuditLogHash(entry)
+		return tx.Create(&entry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// RecordAdminAudit records an admin action taken in request r. before/after are
+// snapshots of the target (structs or maps); secrets must be redacted by the caller.
+// Failures are logged and never block the action that was already performed.
+func RecordAdminAudit(r *http.Request, action, targetType string, targetID interface{}, before, after interface{}) {
+	entry := models.AuditLog{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   fmt.Sprint(targetID),
+		Before:     auditJSON(before),
+		After:      auditJSON(after),
+		Diff:       auditJSON(AuditDiff(before, after)),
+		IP:         GetClientIP(r),
+		RequestID:  GetRequestID(r),
+	}
+	if adminID, ok := GetAdminID(r); ok {
+		entry.AdminID = &adminID
+	}
+	if _, err := AppendAuditLog(database.DB, entry); err != nil {
+		log.Printf("[audit] %s %s/%s: %v", action, targetType, entry.TargetID, err)
+	}
+}
+
+// RecordJobAudit records an action a background job performs for adminID, e.g. one
+// item of a bulk withdrawal job. It has no request, so IP and request ID stay empty.
+func RecordJobAudit(adminID uint, action, targetType string, targetID interface{}, before, after interface{}) {
+	entry := models.AuditLog{
+		AdminID:    &adminID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   fmt.Sprint(targetID),
+		Before:     auditJSON(before),
+		After:      auditJSON(after),
+		Diff:       auditJSON(AuditDiff(before, after)),
+	}
+	if _, err := AppendAuditLog(database.DB, entry); err != nil {
+		log.Printf("[audit] %s %s/%s: %v", action, targetType, entry.TargetID, err)
+	}
+}
+
+// VerifyAuditChain walks the chain in id order and returns the id of the first row
+// whose PrevHash or Hash does not match, or 0 when the chain is intact.
+func VerifyAuditChain(db *gorm.DB) (brokenAt uint, checked int64, err error) {
+	prev := auditGenesisHash
+	var lastID uint
+	for {
+		var batch []models.AuditLog
+		if err := db.Where("id > ?", lastID).Order("id ASC").Limit(500).Find(&batch).Error; err != nil {
+			return 0, checked, err
+		}
+		if len(batch) == 0 {
+			return 0, checked, nil
+		}
+		for _, l := range batch {
+			if l.PrevHash != prev || AuditLogHash(l) != l.Hash {
+				return l.ID, checked, nil
+			}
+			prev = l.Hash
+			lastID = l.ID
+			checked++
+		}
+	}
+}
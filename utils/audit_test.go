@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"project/models"
+)
+
+func TestAuditDiff(t *testing.T) {
+	before := map[string]interface{}{"balance": 100.0, "income": 5.0, "note": "x"}
+	after := map[string]interface{}{"balance": 150.0, "income": 5.0, "type": "add"}
+
+	diff := AuditDiff(before, after)
+	if len(diff) != 3 {
+		t.Fatalf("expected 3 changed fields, got %v", diff)
+	}
+	if diff["balance"]["from"] != 100.0 || diff["balance"]["to"] != 150.0 {
+		t.Errorf("unexpected balance diff %v", diff["balance"])
+	}
+	if diff["note"]["to"] != nil || diff["type"]["from"] != nil {
+		t.Errorf("removed/added fields not reported: %v", diff)
+	}
+	if _, ok := diff["income"]; ok {
+		t.Errorf("unchanged field reported")
+	}
+	if len(AuditDiff(nil, nil)) != 0 {
+		t.Errorf("expected empty diff for nil snapshots")
+	}
+}
+
+func TestAuditLogHashChain(t *testing.T) {
+	adminID := uint(1)
+	first := models.AuditLog{
+		AdminID:    &adminID,
+		Action:     AuditUserBalance,
+		TargetType: "user",
+		TargetID:   "7",
+		PrevHash:   auditGenesisHash,
+		CreatedAt:  time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+	}
+	first.Hash = AuditLogHash(first)
+	if AuditLogHash(first) != first.Hash {
+		t.Fatal("hash is not deterministic")
+	}
+
+	second := first
+	second.PrevHash = first.Hash
+	second.TargetID = "8"
+	second.Hash = AuditLogHash(second)
+	if second.Hash == first.Hash {
+		t.Fatal("different rows produced the same hash")
+	}
+
+	tampered := first
+	tampered.TargetID = "9"
+	if AuditLogHash(tampered) == first.Hash {
+		t.Error("editing a row did not change its hash")
+	}
+}
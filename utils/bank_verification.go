@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Bank account review states (models.BankAccount.ReviewStatus)
+const (
+	BankReviewNone     = "None"
+	BankReviewPending  = "Pending"
+	BankReviewApproved = "Approved"
+	BankReviewRejected = "Rejected"
+)
+
+// LinkQuConfigured reports whether the LinkQu payout credentials are set.
+func LinkQuConfigured() bool {
+	lq := AppConfig().LinkQu
+	return lq.BaseURL != "" && lq.Username != "" && lq.PIN != "" && lq.ClientID != "" && lq.ClientSecret != ""
+}
+
+// LinkQuCallbackAuthorized reports whether the client-id/client-secret headers of a
+// LinkQu callback match the configured credentials. Empty headers never match, and
+// without LinkQu configured every callback is rejected.
+func LinkQuCallbackAuthorized(clientID, clientSecret string) bool {
+	if !LinkQuConfigured() || clientID == "" || clientSecret == "" {
+		return false
+	}
+	lq := AppConfig().LinkQu
+	idOK := subtle.ConstantTimeCompare([]byte(clientID), []byte(strings.TrimSpace(lq.ClientID)))
+	secretOK := subtle.ConstantTimeCompare([]byte(clientSecret), []byte(strings.TrimSpace(lq.ClientSecret.Value())))
+	return idOK&secretOK == 1
+}
+
+// BankNameMatchThreshold is the minimum NameMatchScore accepted without admin review
+// (BANK_NAME_MATCH_THRESHOLD, default 0.8).
+func BankNameMatchThreshold() float64 {
+	return AppConfig().BankVerification.NameMatchThreshold
+}
+
+// InquiryAccountHolder looks up the registered holder name of a bank or e-wallet account
+// through the LinkQu inquiry API. No money is moved; the inquiry amount only has to be
+// within the channel limits (BANK_VERIFY_INQUIRY_AMOUNT, default 10000).
+func InquiryAccountHolder(bankCode, accountNumber string, userID uint) (string, error) {
+	amount := AppConfig().BankVerification.InquiryAmount
+	orderID := fmt.Sprintf("BAV-%d%d", time.Now().UnixNano()%1000000000, userID)
+
+	var resp *LinkQuInquiryResponse
+	var err error
+	if IsEwallet(bankCode) {
+		resp, err = LinkQuInquiryEwallet(bankCode, accountNumber, amount, orderID)
+	} else {
+		resp, err = LinkQuInquiryBank(bankCode, accountNumber, amount, orderID)
+	}
+	if err != nil {
+		return "", err
+	}
+	holder := strings.TrimSpace(resp.AccountName)
+	if holder == "" {
+		return "", &LinkQuError{Stage: "inquiry", ResponseCode: resp.ResponseCode, Message: "nama pemilik rekening kosong"}
+	}
+	return holder, nil
+}
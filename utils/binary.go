@@ -1,29 +1,56 @@
 package utils
 
 import (
+	"errors"
+
 	"project/database"
 	"project/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// binaryOmsetMaxLevel adalah kedalaman downline yang dihitung sebagai omset
+const binaryOmsetMaxLevel = 3
+
+// binaryAssignAttempts adalah jumlah percobaan jika slot diambil registrasi lain
+const binaryAssignAttempts = 3
+
+// ErrBinarySlotTaken dikembalikan jika slot kiri/kanan sudah diisi transaksi lain
+var ErrBinarySlotTaken = errors.New("posisi binary sudah terisi")
+
 // AssignBinaryNode mengassign user baru ke binary tree (kiri atau kanan)
 // Logic: Cari posisi kosong terdekat dari upline, mulai dari kiri dulu
+// binary_nodes dan closure table binary_tree_paths diupdate dalam satu transaksi.
+// Node dibaca dengan FOR UPDATE dan slot diisi secara kondisional, sehingga dua
+// registrasi bersamaan di bawah upline yang sama tidak saling menimpa.
 func AssignBinaryNode(uplineID uint, newUserID uint) error {
-	db := database.DB
+	var err error
+	for attempt := 0; attempt < binaryAssignAttempts; attempt++ {
+		err = database.DB.Transaction(func(tx *gorm.DB) error {
+			return assignBinaryNode(tx, uplineID, newUserID)
+		})
+		if !errors.Is(err, ErrBinarySlotTaken) {
+			return err
+		}
+	}
+	return err
+}
 
+// lockBinaryNode membaca node milik userID dengan FOR UPDATE
+func lockBinaryNode(db *gorm.DB, userID uint, node *models.BinaryNode) error {
+	return db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(node).Error
+}
+
+func assignBinaryNode(db *gorm.DB, uplineID uint, newUserID uint) error {
 	// Cek apakah upline sudah punya binary node
 	var binaryNode models.BinaryNode
-	err := db.Where("user_id = ?", uplineID).First(&binaryNode).Error
+	err := lockBinaryNode(db, uplineID, &binaryNode)
 
 	if err != nil && err == gorm.ErrRecordNotFound {
 		// Upline belum punya binary node, buat baru dengan newUser di kiri
-		binaryNode = models.BinaryNode{
-			UserID:  uplineID,
-			LeftID:  &newUserID,
-			RightID: nil,
-		}
-		return db.Create(&binaryNode).Error
+		binaryNode = models.BinaryNode{UserID: uplineID}
+		return setBinaryChild(db, &binaryNode, BinaryLegLeft, newUserID)
 	} else if err != nil {
 		return err
 	}
@@ -31,14 +58,12 @@ func AssignBinaryNode(uplineID uint, newUserID uint) error {
 	// Upline sudah punya binary node
 	// Cek apakah kiri kosong, jika ya assign ke kiri
 	if binaryNode.LeftID == nil {
-		binaryNode.LeftID = &newUserID
-		return db.Save(&binaryNode).Error
+		return setBinaryChild(db, &binaryNode, BinaryLegLeft, newUserID)
 	}
 
 	// Kiri sudah terisi, cek kanan
 	if binaryNode.RightID == nil {
-		binaryNode.RightID = &newUserID
-		return db.Save(&binaryNode).Error
+		return setBinaryChild(db, &binaryNode, BinaryLegRight, newUserID)
 	}
 
 	// Kedua sisi sudah terisi, cari posisi kosong di level berikutnya
@@ -52,33 +77,28 @@ func findAndAssignPosition(leftID, rightID *uint, newUserID uint, db *gorm.DB) e
 	// Coba kiri dulu
 	if leftID != nil {
 		var leftNode models.BinaryNode
-		if err := db.Where("user_id = ?", *leftID).First(&leftNode).Error; err == nil {
+		if err := lockBinaryNode(db, *leftID, &leftNode); err == nil {
 			if leftNode.LeftID == nil {
-				leftNode.LeftID = &newUserID
-				return db.Save(&leftNode).Error
+				return setBinaryChild(db, &leftNode, BinaryLegLeft, newUserID)
 			}
 			if leftNode.RightID == nil {
-				leftNode.RightID = &newUserID
-				return db.Save(&leftNode).Error
+				return setBinaryChild(db, &leftNode, BinaryLegRight, newUserID)
 			}
 			// Kedua sisi terisi, rekursif ke level berikutnya
-			if err := findAndAssignPosition(leftNode.LeftID, leftNode.RightID, newUserID, db); err == nil {
-				return nil
-			}
+			// Error (termasuk slot yang diambil transaksi lain) diteruskan agar transaksi di-rollback
+			return findAndAssignPosition(leftNode.LeftID, leftNode.RightID, newUserID, db)
 		}
 	}
 
 	// Jika kiri tidak bisa, coba kanan
 	if rightID != nil {
 		var rightNode models.BinaryNode
-		if err := db.Where("user_id = ?", *rightID).First(&rightNode).Error; err == nil {
+		if err := lockBinaryNode(db, *rightID, &rightNode); err == nil {
 			if rightNode.LeftID == nil {
-				rightNode.LeftID = &newUserID
-				return db.Save(&rightNode).Error
+				return setBinaryChild(db, &rightNode, BinaryLegLeft, newUserID)
 			}
 			if rightNode.RightID == nil {
-				rightNode.RightID = &newUserID
-				return db.Save(&rightNode).Error
+				return setBinaryChild(db, &rightNode, BinaryLegRight, newUserID)
 			}
 			// Kedua sisi terisi, rekursif ke level berikutnya
 			return findAndAssignPosition(rightNode.LeftID, rightNode.RightID, newUserID, db)
@@ -88,7 +108,7 @@ func findAndAssignPosition(leftID, rightID *uint, newUserID uint, db *gorm.DB) e
 	// Fallback: jika semua penuh, assign ke kiri dari leftID (spillover)
 	if leftID != nil {
 		var leftNode models.BinaryNode
-		if err := db.Where("user_id = ?", *leftID).First(&leftNode).Error; err == nil {
+		if err := lockBinaryNode(db, *leftID, &leftNode); err == nil {
 			return findAndAssignPosition(leftNode.LeftID, leftNode.RightID, newUserID, db)
 		}
 	}
@@ -98,60 +118,75 @@ func findAndAssignPosition(leftID, rightID *uint, newUserID uint, db *gorm.DB) e
 	return nil
 }
 
-// CalculateOmset menghitung omset dari level 1-3 untuk user tertentu
-// Omset = total investasi dari semua downline di level 1-3 (kiri + kanan)
-func CalculateOmset(userID uint) (omsetLeft, omsetRight, totalOmset float64, err error) {
-	db := database.DB
-
-	var binaryNode models.BinaryNode
-	if err := db.Where("user_id = ?", userID).First(&binaryNode).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// User belum punya binary node, omset = 0
-			return 0, 0, 0, nil
+// setBinaryChild menaruh childID di sisi leg dari node lalu menambahkan path-nya ke closure table.
+// Hanya kolom left_id/right_id yang diupdate, dengan syarat slot masih kosong; jika slot
+// sudah diisi transaksi lain (atau node baru sudah dibuat) hasilnya ErrBinarySlotTaken.
+func setBinaryChild(db *gorm.DB, node *models.BinaryNode, leg string, childID uint) error {
+	column := "left_id"
+	if leg == BinaryLegRight {
+		column = "right_id"
+	}
+	if node.ID == 0 {
+		if leg == BinaryLegLeft {
+			node.LeftID = &childID
+		} else {
+			node.RightID = &childID
+		}
+		res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(node)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrBinarySlotTaken
+		}
+	} else {
+		res := db.Model(&models.BinaryNode{}).Where("id = ? AND "+column+" IS NULL", node.ID).Update(column, childID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrBinarySlotTaken
+		}
+		if leg == BinaryLegLeft {
+			node.LeftID = &childID
+		} else {
+			node.RightID = &childID
 		}
-		return 0, 0, 0, err
 	}
-
-	// Hitung omset dari sisi kiri (level 1-3)
-	omsetLeft = calculateOmsetRecursive(binaryNode.LeftID, db, 1, 3)
-
-	// Hitung omset dari sisi kanan (level 1-3)
-	omsetRight = calculateOmsetRecursive(binaryNode.RightID, db, 1, 3)
-
-	totalOmset = omsetLeft + omsetRight
-	return omsetLeft, omsetRight, totalOmset, nil
+	return insertBinaryPaths(db, node.UserID, childID, leg)
 }
 
-// calculateOmsetRecursive menghitung omset secara rekursif sampai level tertentu
-// Omset = total_returned (penghasilan dari return harian) dari investasi aktif (status Running) user ini + semua downline di level 1-3
-func calculateOmsetRecursive(userID *uint, db *gorm.DB, currentLevel, maxLevel int) float64 {
-	if userID == nil || currentLevel > maxLevel {
-		return 0
-	}
+// CalculateOmset menghitung omset dari level 1-3 untuk user tertentu
+// Omset = total_returned (penghasilan dari return harian) dari investasi aktif (status Running)
+// semua downline di level 1-3, dipisah per sisi (kiri + kanan) dalam satu query ke binary_tree_paths
+func CalculateOmset(userID uint) (omsetLeft, omsetRight, totalOmset float64, err error) {
+	db := database.DB
 
-	// Hitung omset dari total_returned (penghasilan yang sudah dibayar) dari investasi aktif user ini (status Running)
-	var totalOmset float64
-	var investments []models.Investment
-	if err := db.Select("total_returned").Where("user_id = ? AND status = ?", *userID, "Running").Find(&investments).Error; err == nil {
-		for _, inv := range investments {
-			totalOmset += inv.TotalReturned
-		}
+	var rows []struct {
+		Leg   string
+		Omset float64
 	}
-
-	// Ambil binary node user ini
-	var binaryNode models.BinaryNode
-	if err := db.Where("user_id = ?", *userID).First(&binaryNode).Error; err != nil {
-		// User belum punya binary node, hanya return omset dari investasinya sendiri
-		return totalOmset
+	if err := db.Table("binary_tree_paths AS p").
+		Select("p.leg, COALESCE(SUM(i.total_returned), 0) AS omset").
+		Joins("JOIN investments i ON i.user_id = p.descendant AND i.status = ?", "Running").
+		Where("p.ancestor = ? AND p.depth BETWEEN 1 AND ?", userID, binaryOmsetMaxLevel).
+		Group("p.leg").
+		Scan(&rows).Error; err != nil {
+		return 0, 0, 0, err
 	}
 
-	// Rekursif ke level berikutnya (kiri dan kanan)
-	if currentLevel < maxLevel {
-		totalOmset += calculateOmsetRecursive(binaryNode.LeftID, db, currentLevel+1, maxLevel)
-		totalOmset += calculateOmsetRecursive(binaryNode.RightID, db, currentLevel+1, maxLevel)
+	// User belum punya binary node atau belum punya downline, omset = 0
+	for _, row := range rows {
+		switch row.Leg {
+		case BinaryLegLeft:
+			omsetLeft = row.Omset
+		case BinaryLegRight:
+			omsetRight = row.Omset
+		}
 	}
 
-	return totalOmset
+	totalOmset = omsetLeft + omsetRight
+	return omsetLeft, omsetRight, totalOmset, nil
 }
 
 // CalculateUserOmsetOnly menghitung omset dari user tersebut saja (tanpa downline)
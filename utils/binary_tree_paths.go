@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"sort"
+	"time"
+
+	"project/models"
+
+	"gorm.io/gorm"
+)
+
+// Sisi binary tree, dipakai di binary_tree_paths.leg dan posisi member.
+const (
+	BinaryLegLeft  = "left"
+	BinaryLegRight = "right"
+)
+
+// insertBinaryPaths menambahkan path closure setelah childID ditaruh di sisi leg dari
+// parentID: setiap ancestor parent (termasuk parent sendiri) menjadi ancestor dari
+// seluruh subtree child. Harus dipanggil di transaksi yang sama dengan update binary_nodes.
+func insertBinaryPaths(db *gorm.DB, parentID, childID uint, leg string) error {
+	now := time.Now()
+	if err := db.Exec(
+		"INSERT IGNORE INTO binary_tree_paths (ancestor, descendant, depth, leg, created_at) VALUES (?, ?, 0, '', ?), (?, ?, 0, '', ?)",
+		parentID, parentID, now, childID, childID, now,
+	).Error; err != nil {
+		return err
+	}
+	return db.Exec(`INSERT INTO binary_tree_paths (ancestor, descendant, depth, leg, created_at)
+		SELECT a.ancestor, d.descendant, a.depth + d.depth + 1, CASE WHEN a.depth = 0 THEN ? ELSE a.leg END, ?
+		FROM binary_tree_paths a
+		JOIN binary_tree_paths d ON d.ancestor = ?
+		WHERE a.descendant = ?`,
+		leg, now, childID, parentID,
+	).Error
+}
+
+// DetachBinaryChildren mengosongkan left_id dan right_id dari node dan menghapus path dari
+// node serta semua ancestor-nya ke subtree yang terputus. Subtree tetap utuh sebagai tree sendiri.
+func DetachBinaryChildren(db *gorm.DB, node *models.BinaryNode) error {
+	var children []uint
+	for _, id := range []*uint{node.LeftID, node.RightID} {
+		if id != nil {
+			children = append(children, *id)
+		}
+	}
+	if len(children) > 0 {
+		var subtree, ancestors []uint
+		if err := db.Model(&models.BinaryTreePath{}).Where("ancestor IN ?", children).Pluck("descendant", &subtree).Error; err != nil {
+			return err
+		}
+		if err := db.Model(&models.BinaryTreePath{}).Where("descendant = ?", node.UserID).Pluck("ancestor", &ancestors).Error; err != nil {
+			return err
+		}
+		if len(subtree) > 0 && len(ancestors) > 0 {
+			if err := db.Where("ancestor IN ? AND descendant IN ?", ancestors, subtree).Delete(&models.BinaryTreePath{}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return db.Model(node).UpdateColumns(map[string]interface{}{
+		"left_id":  nil,
+		"right_id": nil,
+	}).Error
+}
+
+// CountBinaryMembersByLevel menghitung jumlah downline per level (1..maxLevel) dalam satu query.
+func CountBinaryMembersByLevel(db *gorm.DB, userID uint, maxLevel int) (map[int]int, error) {
+	var rows []struct {
+		Depth   int
+		Members int
+	}
+	if err := db.Model(&models.BinaryTreePath{}).
+		Select("depth, COUNT(*) AS members").
+		Where("ancestor = ? AND depth BETWEEN 1 AND ?", userID, maxLevel).
+		Group("depth").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[int]int, maxLevel)
+	for _, row := range rows {
+		counts[row.Depth] = row.Members
+	}
+	return counts, nil
+}
+
+// BuildBinaryTreePaths menghitung closure table lengkap dari binary_nodes. Jika satu user
+// tercatat sebagai anak dari lebih dari satu node, node dengan ID terkecil yang dipakai;
+// siklus diputus agar backfill selalu selesai.
+func BuildBinaryTreePaths(nodes []models.BinaryNode) []models.BinaryTreePath {
+	type edge struct {
+		parent uint
+		leg    string
+	}
+	sorted := append([]models.BinaryNode(nil), nodes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	parents := make(map[uint]edge)
+	members := make(map[uint]bool)
+	for _, node := range sorted {
+		members[node.UserID] = true
+		for _, child := range []struct {
+			id  *uint
+			leg string
+		}{{node.LeftID, BinaryLegLeft}, {node.RightID, BinaryLegRight}} {
+			if child.id == nil || *child.id == node.UserID {
+				continue
+			}
+			members[*child.id] = true
+			if _, ok := parents[*child.id]; !ok {
+				parents[*child.id] = edge{parent: node.UserID, leg: child.leg}
+			}
+		}
+	}
+
+	ids := make([]uint, 0, len(members))
+	for id := range members {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var paths []models.BinaryTreePath
+	for _, id := range ids {
+		paths = append(paths, models.BinaryTreePath{Ancestor: id, Descendant: id})
+		seen := map[uint]bool{id: true}
+		cur, depth := id, 0
+		for {
+			e, ok := parents[cur]
+			if !ok || seen[e.parent] {
+				break
+			}
+			depth++
+			seen[e.parent] = true
+			paths = append(paths, models.BinaryTreePath{Ancestor: e.parent, Descendant: id, Depth: depth, Leg: e.leg})
+			cur = e.parent
+		}
+	}
+	return paths
+}
+
+// RebuildBinaryTreePaths mengisi ulang binary_tree_paths dari binary_nodes dalam satu
+// transaksi dan mengembalikan jumlah path yang ditulis.
+func RebuildBinaryTreePaths(db *gorm.DB) (int, error) {
+	var paths []models.BinaryTreePath
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var nodes []models.BinaryNode
+		if err := tx.Find(&nodes).Error; err != nil {
+			return err
+		}
+		paths = BuildBinaryTreePaths(nodes)
+		if err := tx.Exec("DELETE FROM binary_tree_paths").Error; err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(paths, 1000).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(paths), nil
+}
+
+// EnsureBinaryTreePaths menjalankan RebuildBinaryTreePaths hanya jika closure table masih
+// kosong sementara binary_nodes sudah berisi, misalnya setelah migrasi pertama.
+func EnsureBinaryTreePaths(db *gorm.DB) (int, error) {
+	var paths, nodes int64
+	if err := db.Model(&models.BinaryTreePath{}).Count(&paths).Error; err != nil {
+		return 0, err
+	}
+	if paths > 0 {
+		return 0, nil
+	}
+	if err := db.Model(&models.BinaryNode{}).Count(&nodes).Error; err != nil {
+		return 0, err
+	}
+	if nodes == 0 {
+		return 0, nil
+	}
+	return RebuildBinaryTreePaths(db)
+}
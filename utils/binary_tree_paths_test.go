@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"testing"
+
+	"project/models"
+)
+
+func uintPtr(v uint) *uint { return &v }
+
+func TestBuildBinaryTreePaths(t *testing.T) {
+	//        1
+	//      /   \
+	//     2     3
+	//    / \
+	//   4   5
+	nodes := []models.BinaryNode{
+		{ID: 1, UserID: 1, LeftID: uintPtr(2), RightID: uintPtr(3)},
+		{ID: 2, UserID: 2, LeftID: uintPtr(4), RightID: uintPtr(5)},
+	}
+	got := map[[2]uint]models.BinaryTreePath{}
+	for _, p := range BuildBinaryTreePaths(nodes) {
+		got[[2]uint{p.Ancestor, p.Descendant}] = p
+	}
+	want := []models.BinaryTreePath{
+		{Ancestor: 1, Descendant: 1},
+		{Ancestor: 1, Descendant: 2, Depth: 1, Leg: BinaryLegLeft},
+		{Ancestor: 1, Descendant: 3, Depth: 1, Leg: BinaryLegRight},
+		{Ancestor: 1, Descendant: 4, Depth: 2, Leg: BinaryLegLeft},
+		{Ancestor: 1, Descendant: 5, Depth: 2, Leg: BinaryLegLeft},
+		{Ancestor: 2, Descendant: 5, Depth: 1, Leg: BinaryLegRight},
+		{Ancestor: 3, Descendant: 3},
+		{Ancestor: 5, Descendant: 5},
+	}
+	if len(got) != 11 {
+		t.Fatalf("expected 11 paths (5 self + 6 ancestor), got %d: %+v", len(got), got)
+	}
+	for _, w := range want {
+		if p, ok := got[[2]uint{w.Ancestor, w.Descendant}]; !ok || p != w {
+			t.Errorf("path %d->%d: want %+v, got %+v", w.Ancestor, w.Descendant, w, p)
+		}
+	}
+	if _, ok := got[[2]uint{3, 4}]; ok {
+		t.Error("3 is not an ancestor of 4")
+	}
+}
+
+func TestBuildBinaryTreePathsBreaksCycles(t *testing.T) {
+	nodes := []models.BinaryNode{
+		{ID: 1, UserID: 1, LeftID: uintPtr(2)},
+		{ID: 2, UserID: 2, LeftID: uintPtr(1)},
+		{ID: 3, UserID: 3, LeftID: uintPtr(2)}, // 2 already placed under 1
+	}
+	for _, p := range BuildBinaryTreePaths(nodes) {
+		if p.Ancestor == 3 && p.Descendant != 3 {
+			t.Errorf("a second parent must be ignored, got %+v", p)
+		}
+		if p.Depth > 2 {
+			t.Errorf("cycle was not broken, got %+v", p)
+		}
+	}
+}
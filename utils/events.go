@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"project/database"
+	"project/models"
+)
+
+// Event types pushed to user event streams (GET /api/users/events)
+const (
+	EventDepositUpdated    = "deposit.updated"
+	EventWithdrawalUpdated = "withdrawal.updated"
+	EventBalanceUpdated    = "balance.updated"
+)
+
+// Event is a realtime notification delivered to a single user's SSE stream.
+type Event struct {
+	Type      string      `json:"type"`
+	UserID    uint        `json:"user_id"`
+	Data      interface{} `json:"data"`
+	Timestamp int64       `json:"timestamp"`
+}
+
+// In-process fallback hub used when Redis is not configured (single replica only).
+var (
+	eventHubMu sync.Mutex
+	eventHub   = make(map[uint]map[chan Event]struct{})
+)
+
+func userEventChannel(userID uint) string {
+	return fmt.Sprintf("events:user:%d", userID)
+}
+
+// PublishUserEvent fans out an event to every stream the user has open. With Redis
+// configured it is published on pub/sub so that all replicas receive it. Publishing
+// is best-effort and must only be called after the related DB transaction commits.
+func PublishUserEvent(userID uint, eventType string, data interface{}) {
+	if userID == 0 {
+		return
+	}
+	ev := Event{Type: eventType, UserID: userID, Data: data, Timestamp: time.Now().Unix()}
+
+	if RedisClient != nil {
+		payload, err := json.Marshal(ev)
+		if err != nil {
+			log.Printf("[events] marshal %s for user %d: %v", eventType, userID, err)
+			return
+		}
+		if err := RedisClient.Publish(context.Background(), userEventChannel(userID), payload).Err(); err != nil {
+			log.Printf("[events] publish %s for user %d: %v", eventType, userID, err)
+		}
+		return
+	}
+
+	eventHubMu.Lock()
+	defer eventHubMu.Unlock()
+	for ch := range eventHub[userID] {
+		// never block the publisher on a slow consumer
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// SubscribeUserEvents returns a channel receiving the user's events until ctx is
+// done or the returned cancel func is called.
+func SubscribeUserEvents(ctx context.Context, userID uint) (<-chan Event, func()) {
+	out := make(chan Event, 16)
+
+	if RedisClient != nil {
+		ps := RedisClient.Subscribe(ctx, userEventChannel(userID))
+		done := make(chan struct{})
+		var once sync.Once
+		cancel := func() {
+			once.Do(func() {
+				close(done)
+				_ = ps.Close()
+			})
+		}
+		go func() {
+			msgs := ps.Channel()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-done:
+					return
+				case msg, ok := <-msgs:
+					if !ok {
+						return
+					}
+					var ev Event
+					if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil {
+						continue
+					}
+					select {
+					case out <- ev:
+					default:
+					}
+				}
+			}
+		}()
+		return out, cancel
+	}
+
+	eventHubMu.Lock()
+	if eventHub[userID] == nil {
+		eventHub[userID] = make(map[chan Event]struct{})
+	}
+	eventHub[userID][out] = struct{}{}
+	eventHubMu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			eventHubMu.Lock()
+			delete(eventHub[userID], out)
+			if len(eventHub[userID]) == 0 {
+				delete(eventHub, userID)
+			}
+			eventHubMu.Unlock()
+		})
+	}
+	return out, cancel
+}
+
+// PublishDepositEvent notifies the owner about a deposit status change.
+func PublishDepositEvent(deposit models.Deposit) {
+	PublishUserEvent(deposit.UserID, EventDepositUpdated, map[string]interface{}{
+		"order_id":       deposit.OrderID,
+		"amount":         deposit.Amount,
+		"payment_method": deposit.PaymentMethod,
+		"status":         deposit.Status,
+	})
+}
+
+// PublishWithdrawalEvent notifies the owner about a withdrawal status change.
+func PublishWithdrawalEvent(withdrawal models.Withdrawal) {
+	PublishUserEvent(withdrawal.UserID, EventWithdrawalUpdated, map[string]interface{}{
+		"id":           withdrawal.ID,
+		"order_id":     withdrawal.OrderID,
+		"amount":       withdrawal.Amount,
+		"final_amount": withdrawal.FinalAmount,
+		"status":       withdrawal.Status,
+	})
+}
+
+// PublishBalanceEvent reloads the user's balance and income and pushes them to the user.
+func PublishBalanceEvent(userID uint) {
+	if database.DB == nil {
+		return
+	}
+	var user models.User
+	if err := database.DB.Select("id, balance, income").Where("id = ?", userID).First(&user).Error; err != nil {
+		return
+	}
+	PublishUserEvent(user.ID, EventBalanceUpdated, map[string]interface{}{
+		"balance": user.Balance,
+		"income":  user.Income,
+	})
+}
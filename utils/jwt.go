@@ -6,10 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
+	"project/config"
 	"project/database"
 	"project/models"
 
@@ -18,35 +18,20 @@ import (
 	"gorm.io/gorm"
 )
 
-func init() {
-	if os.Getenv("JWT_SECRET") == "supersecretjwtkey" {
-		panic("JWT_SECRET environment variable is not set")
-	}
-}
-
 // RedisClient is an optional shared Redis client used for token revocation and other
 // cross-process coordination (lockout, blacklists). It will be nil when REDIS_ADDR
 // is not configured.
 var RedisClient *redis.Client
 
-func init() {
-	// Initialize Redis client if configured (optional revocation store)
-	addr := strings.TrimSpace(os.Getenv("REDIS_ADDR"))
+// InitRedis connects RedisClient when cfg.Addr is set. A failed ping only logs, since
+// revocation falls back to the database and limits to memory.
+func InitRedis(cfg config.Redis) {
+	// If someone accidentally put a space in the address, sanitize common mistakes
+	addr := strings.ReplaceAll(strings.TrimSpace(cfg.Addr), " ", "")
 	if addr == "" {
 		return
 	}
-	// If someone accidentally put a trailing colon or space, sanitize common mistakes
-	addr = strings.ReplaceAll(addr, " ", "")
-	opts := &redis.Options{Addr: addr}
-	if p := os.Getenv("REDIS_PASS"); p != "" {
-		opts.Password = p
-	}
-	if dbStr := os.Getenv("REDIS_DB"); dbStr != "" {
-		var dbn int
-		_, _ = fmt.Sscanf(dbStr, "%d", &dbn)
-		opts.DB = dbn
-	}
-	rc := redis.NewClient(opts)
+	rc := redis.NewClient(&redis.Options{Addr: addr, Password: cfg.Pass.Value(), DB: cfg.DB})
 	ctx := context.Background()
 	if err := rc.Ping(ctx).Err(); err != nil {
 		fmt.Printf("warning: redis ping failed: %v\n", err)
@@ -61,21 +46,12 @@ type contextKey string
 const UserIDKey = contextKey("userID")
 const UserRoleKey = contextKey("userRole")
 const RequestIDKey = contextKey("requestID")
+const AdminIDKey = contextKey("adminID")
 
 // ValidateToken validates a JWT token and returns the parsed token if valid
 func ValidateToken(tokenString string) (*jwt.Token, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return nil, errors.New("JWT_SECRET is not set")
-	}
-
 	// Parse without validating claims yet so we can inspect them.
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(secret), nil
-	})
+	token, err := jwt.Parse(tokenString, jwtKeyFunc)
 	if err != nil || !token.Valid {
 		return nil, errors.New("invalid token")
 	}
@@ -84,60 +60,23 @@ func ValidateToken(tokenString string) (*jwt.Token, error) {
 	return token, nil
 }
 
-// GenerateJWT generates a new JWT token for the given user ID, username and role
-func GenerateJWT(id int64, username, role string) (string, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return "", errors.New("JWT_SECRET is not set")
-	}
-
-	// Access token lifetime (short-lived). Consider making configurable.
-	var expTime time.Duration
-	if role == "admin" {
-		expTime = time.Hour * 6
-	} else {
-		expTime = time.Hour * 24
-	}
-
-	now := time.Now()
-	jti, err := generateJTI(32)
-	if err != nil {
-		return "", err
-	}
-
-	claims := jwt.MapClaims{
-		"id":       id,
-		"username": username,
-		"role":     role,
-		"exp":      now.Add(expTime).Unix(),
-		"iat":      now.Unix(),
-		"nbf":      now.Unix(),
-		"jti":      jti,
-		"aud":      os.Getenv("JWT_AUD"),
-		"iss":      os.Getenv("JWT_ISS"),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	tokenString, err := token.SignedString([]byte(secret))
-	if err != nil {
-		return "", err
-	}
-
-	return tokenString, nil
-}
+// UserAccessTTL is the lifetime of user access tokens.
+const UserAccessTTL = 15 * time.Minute
 
 // GenerateAccessToken issues a short-lived access token (default 15 minutes).
 func GenerateAccessToken(userID uint, role string) (string, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return "", errors.New("JWT_SECRET is not set")
-	}
+	token, _, _, err := generateAccessToken(userID, role)
+	return token, err
+}
+
+// generateAccessToken signs an access token and also returns its jti and expiry so
+// callers can bind it to a refresh token.
+func generateAccessToken(userID uint, role string) (string, string, time.Time, error) {
 	now := time.Now()
-	exp := now.Add(15 * time.Minute)
+	exp := now.Add(UserAccessTTL)
 	jti, err := generateJTI(32)
 	if err != nil {
-		return "", err
+		return "", "", time.Time{}, err
 	}
 
 	rc := jwt.RegisteredClaims{
@@ -145,8 +84,8 @@ func GenerateAccessToken(userID uint, role string) (string, error) {
 		IssuedAt:  jwt.NewNumericDate(now),
 		NotBefore: jwt.NewNumericDate(now),
 		ID:        jti,
-		Audience:  jwt.ClaimStrings{os.Getenv("JWT_AUD")},
-		Issuer:    os.Getenv("JWT_ISS"),
+		Audience:  jwt.ClaimStrings{AppConfig().JWT.Audience},
+		Issuer:    AppConfig().JWT.Issuer,
 	}
 
 	// Custom claims wrapper
@@ -157,12 +96,12 @@ func GenerateAccessToken(userID uint, role string) (string, error) {
 		"iat":  rc.IssuedAt.Unix(),
 		"nbf":  rc.NotBefore.Unix(),
 		"jti":  rc.ID,
-		"aud":  os.Getenv("JWT_AUD"),
-		"iss":  os.Getenv("JWT_ISS"),
+		"aud":  AppConfig().JWT.Audience,
+		"iss":  AppConfig().JWT.Issuer,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	signed, err := signJWT(claims)
+	return signed, jti, exp, err
 }
 
 // GenerateRefreshToken creates a refresh token, stores it in DB and returns the token string (contains jti)
@@ -190,18 +129,12 @@ func GenerateRefreshToken(userID uint) (string, string, error) {
 
 // ValidateAccessToken parses and validates the access token and optionally checks jti revocation store in DB (not implemented here)
 func ValidateAccessToken(tokenStr string) (*jwt.Token, jwt.MapClaims, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return nil, nil, errors.New("JWT_SECRET is not set")
-	}
-	// Parse token with claims as MapClaims so we can do explicit checks.
-	token, err := jwt.ParseWithClaims(tokenStr, jwt.MapClaims{}, func(t *jwt.Token) (interface{}, error) {
-		// Require exact HS256 algorithm to avoid algorithm confusion.
-		if t.Method == nil || t.Method.Alg() != jwt.SigningMethodHS256.Alg() {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(secret), nil
-	})
+	// Parse token with claims as MapClaims so we can do explicit checks. The key is
+	// chosen by kid and must match its algorithm exactly to avoid algorithm confusion.
+	token, err := jwt.ParseWithClaims(tokenStr, jwt.MapClaims{}, jwtKeyFunc)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, nil, errors.New("token expired")
+	}
 	if err != nil || !token.Valid {
 		return nil, nil, errors.New("invalid token")
 	}
@@ -243,21 +176,21 @@ func ValidateAccessToken(tokenStr string) (*jwt.Token, jwt.MapClaims, error) {
 	}
 
 	// aud
-	audEnv := os.Getenv("JWT_AUD")
-	if audEnv != "" {
+	expectedAud := AppConfig().JWT.Audience
+	if expectedAud != "" {
 		audRaw, ok := claims["aud"]
 		if !ok {
 			return token, nil, errors.New("aud claim missing")
 		}
 		switch v := audRaw.(type) {
 		case string:
-			if v != audEnv {
+			if v != expectedAud {
 				return token, nil, errors.New("invalid audience")
 			}
 		case []interface{}:
 			found := false
 			for _, a := range v {
-				if s, ok := a.(string); ok && s == audEnv {
+				if s, ok := a.(string); ok && s == expectedAud {
 					found = true
 					break
 				}
@@ -271,9 +204,9 @@ func ValidateAccessToken(tokenStr string) (*jwt.Token, jwt.MapClaims, error) {
 	}
 
 	// iss
-	issEnv := os.Getenv("JWT_ISS")
-	if issEnv != "" {
-		if issRaw, ok := claims["iss"].(string); !ok || issRaw != issEnv {
+	expectedIss := AppConfig().JWT.Issuer
+	if expectedIss != "" {
+		if issRaw, ok := claims["iss"].(string); !ok || issRaw != expectedIss {
 			return token, nil, errors.New("invalid issuer")
 		}
 	}
@@ -314,6 +247,9 @@ func ValidateRefreshToken(jti string) (*models.RefreshToken, error) {
 		return nil, err
 	}
 	if rt.Revoked {
+		if rt.RotatedAt != nil {
+			return nil, ErrRefreshTokenReused
+		}
 		return nil, errors.New("refresh token revoked")
 	}
 	if time.Now().After(rt.ExpiresAt) {
@@ -329,16 +265,7 @@ func ExtractUserIDFromRequest(r *http.Request) (uint, error) {
 		return 0, errors.New("missing or invalid Authorization header")
 	}
 	tokenStr := strings.TrimSpace(strings.TrimPrefix(authz, "Bearer "))
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return 0, errors.New("server misconfiguration")
-	}
-	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(secret), nil
-	})
+	token, err := jwt.Parse(tokenStr, jwtKeyFunc)
 	if err != nil || !token.Valid {
 		return 0, errors.New("invalid token")
 	}
@@ -421,3 +348,10 @@ func GetUserID(r *http.Request) (uint, bool) {
 	id, ok := v.(uint)
 	return id, ok
 }
+
+// Get authenticated admin ID from context (set by AdminAuthMiddleware)
+func GetAdminID(r *http.Request) (uint, bool) {
+	v := r.Context().Value(AdminIDKey)
+	id, ok := v.(uint)
+	return id, ok
+}
@@ -0,0 +1,315 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"project/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultJWTKeyID is the kid of the HS256 key built from JWT_SECRET. Tokens issued
+// before key rotation carry no kid header and are verified with this key.
+const DefaultJWTKeyID = "default"
+
+// JWTKey is one entry of the signing key ring.
+type JWTKey struct {
+	ID       string
+	Method   jwt.SigningMethod
+	RetireAt *time.Time // no longer accepted after this time; nil means no end
+
+	signKey   interface{} // nil for verify-only keys
+	verifyKey interface{}
+}
+
+// CanSign reports whether the key holds private (or shared secret) material.
+func (k *JWTKey) CanSign() bool { return k.signKey != nil }
+
+func (k *JWTKey) retired(now time.Time) bool {
+	return k.RetireAt != nil && !now.Before(*k.RetireAt)
+}
+
+// JWTKeyRing holds every key that may verify a token and the one that signs new ones.
+type JWTKeyRing struct {
+	Active *JWTKey
+	Keys   map[string]*JWTKey
+}
+
+// jwtKeyConfig is one key in the JWT_KEYS_FILE document. Secrets are never written
+// into the file itself: HS256 keys name an environment variable, asymmetric keys a
+// PEM file.
+type jwtKeyConfig struct {
+	KID            string     `json:"kid"`
+	Alg            string     `json:"alg"`
+	SecretEnv      string     `json:"secret_env"`
+	PrivateKeyFile string     `json:"private_key_file"`
+	PublicKeyFile  string     `json:"public_key_file"`
+	RetireAt       *time.Time `json:"retire_at"`
+}
+
+type jwtKeyRingConfig struct {
+	Active string         `json:"active"`
+	Keys   []jwtKeyConfig `json:"keys"`
+}
+
+var (
+	jwtKeysMu sync.RWMutex
+	jwtKeys   *JWTKeyRing
+)
+
+// LoadJWTKeys (re)loads the key ring from cfg.KeysFile, or builds the single
+// HS256 key from cfg.Secret when no file is configured. A failed reload keeps the
+// previous ring.
+func LoadJWTKeys(cfg config.JWT) error {
+	var (
+		ring *JWTKeyRing
+		err  error
+	)
+	if path := strings.TrimSpace(cfg.KeysFile); path != "" {
+		ring, err = loadJWTKeyRingFile(path)
+	} else {
+		ring, err = defaultJWTKeyRing(cfg.Secret.Value())
+	}
+	if err != nil {
+		return err
+	}
+	jwtKeysMu.Lock()
+	jwtKeys = ring
+	jwtKeysMu.Unlock()
+	return nil
+}
+
+// currentJWTKeys returns the loaded ring. Until LoadJWTKeys has run it falls back to
+// the JWT secret of AppConfig, like the code before key rotation existed.
+func currentJWTKeys() (*JWTKeyRing, error) {
+	jwtKeysMu.RLock()
+	ring := jwtKeys
+	jwtKeysMu.RUnlock()
+	if ring != nil {
+		return ring, nil
+	}
+	return defaultJWTKeyRing(AppConfig().JWT.Secret.Value())
+}
+
+func defaultJWTKeyRing(secret string) (*JWTKeyRing, error) {
+	if secret == "" {
+		return nil, errors.New("JWT_SECRET is not set")
+	}
+	k := &JWTKey{ID: DefaultJWTKeyID, Method: jwt.SigningMethodHS256, signKey: []byte(secret), verifyKey: []byte(secret)}
+	return &JWTKeyRing{Active: k, Keys: map[string]*JWTKey{k.ID: k}}, nil
+}
+
+// jwtKeySecret reads the HS256 secret named by secret_env, or from <secret_env>_FILE
+// like every other secret setting.
+func jwtKeySecret(env string) (string, error) {
+	if env == "" {
+		return "", nil
+	}
+	return config.Lookup(env)
+}
+
+func loadJWTKeyRingFile(path string) (*JWTKeyRing, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read JWT_KEYS_FILE: %w", err)
+	}
+	var cfg jwtKeyRingConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("parse JWT_KEYS_FILE: %w", err)
+	}
+	return buildJWTKeyRing(cfg)
+}
+
+func buildJWTKeyRing(cfg jwtKeyRingConfig) (*JWTKeyRing, error) {
+	ring := &JWTKeyRing{Keys: map[string]*JWTKey{}}
+	for _, kc := range cfg.Keys {
+		k, err := buildJWTKey(kc)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := ring.Keys[k.ID]; dup {
+			return nil, fmt.Errorf("jwt key %q: duplicate kid", k.ID)
+		}
+		ring.Keys[k.ID] = k
+	}
+	active, ok := ring.Keys[cfg.Active]
+	if !ok {
+		return nil, fmt.Errorf("active jwt key %q is not in the key ring", cfg.Active)
+	}
+	if !active.CanSign() {
+		return nil, fmt.Errorf("active jwt key %q has no private key", cfg.Active)
+	}
+	if active.RetireAt != nil {
+		return nil, fmt.Errorf("active jwt key %q must not have retire_at", cfg.Active)
+	}
+	ring.Active = active
+	return ring, nil
+}
+
+func buildJWTKey(kc jwtKeyConfig) (*JWTKey, error) {
+	if strings.TrimSpace(kc.KID) == "" {
+		return nil, errors.New("jwt key without kid")
+	}
+	k := &JWTKey{ID: kc.KID, RetireAt: kc.RetireAt}
+	readPEM := func(path string) ([]byte, error) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", kc.KID, err)
+		}
+		return b, nil
+	}
+
+	switch kc.Alg {
+	case "HS256":
+		k.Method = jwt.SigningMethodHS256
+		secret, err := jwtKeySecret(kc.SecretEnv)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", kc.KID, err)
+		}
+		if kc.SecretEnv == "" || secret == "" {
+			return nil, fmt.Errorf("jwt key %q: secret_env is empty or unset", kc.KID)
+		}
+		k.signKey, k.verifyKey = []byte(secret), []byte(secret)
+	case "RS256":
+		k.Method = jwt.SigningMethodRS256
+		if kc.PrivateKeyFile != "" {
+			b, err := readPEM(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseRSAPrivateKeyFromPEM(b)
+			if err != nil {
+				return nil, fmt.Errorf("jwt key %q: %w", kc.KID, err)
+			}
+			k.signKey, k.verifyKey = priv, &priv.PublicKey
+		} else if kc.PublicKeyFile != "" {
+			b, err := readPEM(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			pub, err := jwt.ParseRSAPublicKeyFromPEM(b)
+			if err != nil {
+				return nil, fmt.Errorf("jwt key %q: %w", kc.KID, err)
+			}
+			k.verifyKey = pub
+		}
+	case "EdDSA":
+		k.Method = jwt.SigningMethodEdDSA
+		if kc.PrivateKeyFile != "" {
+			b, err := readPEM(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseEdPrivateKeyFromPEM(b)
+			if err != nil {
+				return nil, fmt.Errorf("jwt key %q: %w", kc.KID, err)
+			}
+			edPriv, ok := priv.(ed25519.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("jwt key %q: not an Ed25519 key", kc.KID)
+			}
+			k.signKey, k.verifyKey = edPriv, edPriv.Public()
+		} else if kc.PublicKeyFile != "" {
+			b, err := readPEM(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			pub, err := jwt.ParseEdPublicKeyFromPEM(b)
+			if err != nil {
+				return nil, fmt.Errorf("jwt key %q: %w", kc.KID, err)
+			}
+			k.verifyKey = pub
+		}
+	default:
+		return nil, fmt.Errorf("jwt key %q: unsupported alg %q (use HS256, RS256 or EdDSA)", kc.KID, kc.Alg)
+	}
+	if k.verifyKey == nil {
+		return nil, fmt.Errorf("jwt key %q: private_key_file or public_key_file is required", kc.KID)
+	}
+	return k, nil
+}
+
+// signJWT signs claims with the active key and stamps its kid in the header.
+func signJWT(claims jwt.MapClaims) (string, error) {
+	ring, err := currentJWTKeys()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(ring.Active.Method, claims)
+	token.Header["kid"] = ring.Active.ID
+	return token.SignedString(ring.Active.signKey)
+}
+
+// jwtKeyFunc picks the verification key by kid and requires the token to use
+// exactly that key's algorithm, so an RSA public key can never be used as an HMAC
+// secret. Tokens without a kid are only checked against DefaultJWTKeyID.
+func jwtKeyFunc(t *jwt.Token) (interface{}, error) {
+	ring, err := currentJWTKeys()
+	if err != nil {
+		return nil, err
+	}
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		kid = DefaultJWTKeyID
+	}
+	k, ok := ring.Keys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if t.Method == nil || t.Method.Alg() != k.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	if k.retired(time.Now()) {
+		return nil, errors.New("signing key retired")
+	}
+	return k.verifyKey, nil
+}
+
+// JWK is a public key in RFC 7517 form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS returns the public keys that still verify tokens. HS256 keys are secret and
+// never published.
+func JWKS() ([]JWK, error) {
+	ring, err := currentJWTKeys()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	keys := make([]JWK, 0, len(ring.Keys))
+	for _, k := range ring.Keys {
+		if k.retired(now) {
+			continue
+		}
+		b64 := base64.RawURLEncoding.EncodeToString
+		switch pub := k.verifyKey.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, JWK{Kty: "RSA", Kid: k.ID, Use: "sig", Alg: k.Method.Alg(),
+				N: b64(pub.N.Bytes()), E: b64(big.NewInt(int64(pub.E)).Bytes())})
+		case ed25519.PublicKey:
+			keys = append(keys, JWK{Kty: "OKP", Kid: k.ID, Use: "sig", Alg: k.Method.Alg(), Crv: "Ed25519", X: b64(pub)})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Kid < keys[j].Kid })
+	return keys, nil
+}
@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"project/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writePEM(t *testing.T, name, typ string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// useKeyRing installs ring for the duration of the test.
+func useKeyRing(t *testing.T, ring *JWTKeyRing) {
+	t.Helper()
+	jwtKeysMu.Lock()
+	prev := jwtKeys
+	jwtKeys = ring
+	jwtKeysMu.Unlock()
+	t.Cleanup(func() {
+		jwtKeysMu.Lock()
+		jwtKeys = prev
+		jwtKeysMu.Unlock()
+	})
+}
+
+func useAppConfig(t *testing.T, cfg *config.Config) {
+	t.Helper()
+	appConfigMu.Lock()
+	prev := appConfig
+	appConfig = cfg
+	appConfigMu.Unlock()
+	t.Cleanup(func() {
+		appConfigMu.Lock()
+		appConfig = prev
+		appConfigMu.Unlock()
+	})
+}
+
+func testKeyRing(t *testing.T) (*JWTKeyRing, *rsa.PrivateKey) {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("JWT_SECRET", "legacy-secret")
+	retire := time.Now().Add(time.Hour)
+	ring, err := buildJWTKeyRing(jwtKeyRingConfig{
+		Active: "ed-2",
+		Keys: []jwtKeyConfig{
+			{KID: "ed-2", Alg: "EdDSA", PrivateKeyFile: writePEM(t, "ed.pem", "PRIVATE KEY", edDER)},
+			{KID: "rsa-1", Alg: "RS256", PrivateKeyFile: writePEM(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))},
+			{KID: DefaultJWTKeyID, Alg: "HS256", SecretEnv: "JWT_SECRET", RetireAt: &retire},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ring, rsaKey
+}
+
+func TestKeyRingSignAndValidate(t *testing.T) {
+	useAppConfig(t, &config.Config{})
+	ring, rsaKey := testKeyRing(t)
+	useKeyRing(t, ring)
+
+	tok, err := GenerateAccessToken(7, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := ValidateAccessToken(tok)
+	if err != nil {
+		t.Fatalf("active key token rejected: %v", err)
+	}
+	if parsed.Header["kid"] != "ed-2" || parsed.Method.Alg() != "EdDSA" {
+		t.Errorf("unexpected header %v", parsed.Header)
+	}
+
+	claims := jwt.MapClaims{"id": 7, "role": "user", "exp": time.Now().Add(time.Minute).Unix()}
+
+	// a token from the previous (non-active) key still validates
+	old := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	old.Header["kid"] = "rsa-1"
+	s, _ := old.SignedString(rsaKey)
+	if _, _, err := ValidateAccessToken(s); err != nil {
+		t.Errorf("rotated-out key rejected: %v", err)
+	}
+
+	// legacy tokens without kid use the default HS256 key until it retires
+	legacy, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("legacy-secret"))
+	if _, _, err := ValidateAccessToken(legacy); err != nil {
+		t.Errorf("legacy token rejected: %v", err)
+	}
+	past := time.Now().Add(-time.Second)
+	ring.Keys[DefaultJWTKeyID].RetireAt = &past
+	if _, _, err := ValidateAccessToken(legacy); err == nil {
+		t.Error("token signed with a retired key accepted")
+	}
+
+	unknown := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	unknown.Header["kid"] = "nope"
+	s, _ = unknown.SignedString(rsaKey)
+	if _, _, err := ValidateAccessToken(s); err == nil {
+		t.Error("token with unknown kid accepted")
+	}
+}
+
+func TestKeyRingRejectsAlgorithmConfusion(t *testing.T) {
+	ring, rsaKey := testKeyRing(t)
+	useKeyRing(t, ring)
+
+	// HMAC-sign with the RSA public key bytes while claiming the RSA kid
+	pubDER, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"id": 1, "role": "admin", "exp": time.Now().Add(time.Minute).Unix()})
+	forged.Header["kid"] = "rsa-1"
+	s, _ := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+	if _, _, err := ValidateAccessToken(s); err == nil {
+		t.Fatal("HS256 token accepted for an RS256 kid")
+	}
+}
+
+func TestJWKSPublishesOnlyPublicKeys(t *testing.T) {
+	ring, _ := testKeyRing(t)
+	useKeyRing(t, ring)
+
+	keys, err := JWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0].Kid != "ed-2" || keys[1].Kid != "rsa-1" {
+		t.Fatalf("unexpected jwks %+v", keys)
+	}
+	if keys[0].Kty != "OKP" || keys[0].Crv != "Ed25519" || keys[0].X == "" {
+		t.Errorf("bad Ed25519 jwk %+v", keys[0])
+	}
+	if keys[1].Kty != "RSA" || keys[1].N == "" || keys[1].E != "AQAB" {
+		t.Errorf("bad RSA jwk %+v", keys[1])
+	}
+}
+
+func TestBuildKeyRingValidation(t *testing.T) {
+	t.Setenv("JWT_SECRET_A", "a")
+	cases := []jwtKeyRingConfig{
+		{Active: "x", Keys: []jwtKeyConfig{{KID: "a", Alg: "HS256", SecretEnv: "JWT_SECRET_A"}}},
+		{Active: "a", Keys: []jwtKeyConfig{{KID: "a", Alg: "none"}}},
+		{Active: "a", Keys: []jwtKeyConfig{{KID: "a", Alg: "HS256", SecretEnv: "JWT_SECRET_MISSING"}}},
+		{Active: "a", Keys: []jwtKeyConfig{{KID: "a", Alg: "HS256", SecretEnv: "JWT_SECRET_A"}, {KID: "a", Alg: "HS256", SecretEnv: "JWT_SECRET_A"}}},
+	}
+	for i, c := range cases {
+		if _, err := buildJWTKeyRing(c); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
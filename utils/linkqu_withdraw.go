@@ -3,10 +3,10 @@ package utils
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 )
@@ -45,6 +45,42 @@ type LinkQuPaymentResponse struct {
 	Signature     string  `json:"signature"`
 }
 
+// LinkQuError describes a failed LinkQu payout call. Retryable is true for inquiry
+// transport errors and 5xx/429 responses, but only for 429 on a transfer: once a
+// transfer request has been sent, any outcome that is not a clear rejection sets
+// Uncertain because LinkQu may already be paying it out.
+type LinkQuError struct {
+	Stage        string
+	HTTPStatus   int
+	ResponseCode string
+	Message      string
+	Retryable    bool
+	Uncertain    bool
+}
+
+func (e *LinkQuError) Error() string {
+	return e.Message
+}
+
+// IsRetryableLinkQuError reports whether a payout call may be retried.
+func IsRetryableLinkQuError(err error) bool {
+	var lqErr *LinkQuError
+	if errors.As(err, &lqErr) {
+		return lqErr.Retryable
+	}
+	return false
+}
+
+func retryableHTTPStatus(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests
+}
+
+// transferOutcomeUnknown reports whether a transfer response with this HTTP status may
+// have been accepted: anything but a 4xx rejection.
+func transferOutcomeUnknown(status int) bool {
+	return status < 400 || status >= 500
+}
+
 // IsEwallet mengecek apakah bankCode adalah e-wallet
 func IsEwallet(bankCode string) bool {
 	ewallets := []string{"DANA", "GOPAY", "OVO", "LINKAJA", "SHOPEEPAY", "KASPRO"}
@@ -58,14 +94,15 @@ func IsEwallet(bankCode string) bool {
 
 // LinkQuInquiryBank melakukan inquiry untuk bank
 func LinkQuInquiryBank(bankCode, accountNumber string, amount float64, orderID string) (*LinkQuInquiryResponse, error) {
-	baseURL := os.Getenv("LINKQU_BASE_URL")
-	username := os.Getenv("LINKQU_USERNAME")
-	pin := os.Getenv("LINKQU_PIN")
-	clientID := os.Getenv("LINKQU_CLIENT_ID")
-	clientSecret := os.Getenv("LINKQU_CLIENT_SECRET")
+	lq := AppConfig().LinkQu
+	baseURL := lq.BaseURL
+	username := lq.Username
+	pin := lq.PIN.Value()
+	clientID := lq.ClientID
+	clientSecret := lq.ClientSecret.Value()
 
 	if baseURL == "" || username == "" || pin == "" || clientID == "" || clientSecret == "" {
-		return nil, fmt.Errorf("konfigurasi LinkQu tidak lengkap")
+		return nil, &LinkQuError{Stage: "inquiry", Message: "konfigurasi LinkQu tidak lengkap"}
 	}
 
 	baseURL = strings.TrimRight(baseURL, "/")
@@ -94,28 +131,28 @@ func LinkQuInquiryBank(bankCode, accountNumber string, amount float64, orderID s
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("koneksi gagal: %v", err)
+		return nil, &LinkQuError{Stage: "inquiry", Message: fmt.Sprintf("koneksi gagal: %v", err), Retryable: true}
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("gagal membaca response: %v", err)
+		return nil, &LinkQuError{Stage: "inquiry", HTTPStatus: resp.StatusCode, Message: fmt.Sprintf("gagal membaca response: %v", err), Retryable: true}
 	}
 
 	var inquiryResp LinkQuInquiryResponse
 	if err := json.Unmarshal(respBody, &inquiryResp); err != nil {
-		return nil, fmt.Errorf("gagal parsing response: %v", err)
+		return nil, &LinkQuError{Stage: "inquiry", HTTPStatus: resp.StatusCode, Message: fmt.Sprintf("gagal parsing response: %v", err), Retryable: retryableHTTPStatus(resp.StatusCode)}
 	}
 
 	// Check HTTP status
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, inquiryResp.ResponseDesc)
+		return nil, &LinkQuError{Stage: "inquiry", HTTPStatus: resp.StatusCode, ResponseCode: inquiryResp.ResponseCode, Message: fmt.Sprintf("HTTP error %d: %s", resp.StatusCode, inquiryResp.ResponseDesc), Retryable: retryableHTTPStatus(resp.StatusCode)}
 	}
 
 	// Check response code
 	if inquiryResp.ResponseCode != "00" {
-		return nil, fmt.Errorf("inquiry gagal: %s", inquiryResp.ResponseDesc)
+		return nil, &LinkQuError{Stage: "inquiry", HTTPStatus: resp.StatusCode, ResponseCode: inquiryResp.ResponseCode, Message: fmt.Sprintf("inquiry gagal: %s", inquiryResp.ResponseDesc)}
 	}
 
 	return &inquiryResp, nil
@@ -123,14 +160,15 @@ func LinkQuInquiryBank(bankCode, accountNumber string, amount float64, orderID s
 
 // LinkQuInquiryEwallet melakukan inquiry untuk e-wallet
 func LinkQuInquiryEwallet(bankCode, accountNumber string, amount float64, orderID string) (*LinkQuInquiryResponse, error) {
-	baseURL := os.Getenv("LINKQU_BASE_URL")
-	username := os.Getenv("LINKQU_USERNAME")
-	pin := os.Getenv("LINKQU_PIN")
-	clientID := os.Getenv("LINKQU_CLIENT_ID")
-	clientSecret := os.Getenv("LINKQU_CLIENT_SECRET")
+	lq := AppConfig().LinkQu
+	baseURL := lq.BaseURL
+	username := lq.Username
+	pin := lq.PIN.Value()
+	clientID := lq.ClientID
+	clientSecret := lq.ClientSecret.Value()
 
 	if baseURL == "" || username == "" || pin == "" || clientID == "" || clientSecret == "" {
-		return nil, fmt.Errorf("konfigurasi LinkQu tidak lengkap")
+		return nil, &LinkQuError{Stage: "inquiry", Message: "konfigurasi LinkQu tidak lengkap"}
 	}
 
 	baseURL = strings.TrimRight(baseURL, "/")
@@ -159,28 +197,28 @@ func LinkQuInquiryEwallet(bankCode, accountNumber string, amount float64, orderI
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("koneksi gagal: %v", err)
+		return nil, &LinkQuError{Stage: "inquiry", Message: fmt.Sprintf("koneksi gagal: %v", err), Retryable: true}
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("gagal membaca response: %v", err)
+		return nil, &LinkQuError{Stage: "inquiry", HTTPStatus: resp.StatusCode, Message: fmt.Sprintf("gagal membaca response: %v", err), Retryable: true}
 	}
 
 	var inquiryResp LinkQuInquiryResponse
 	if err := json.Unmarshal(respBody, &inquiryResp); err != nil {
-		return nil, fmt.Errorf("gagal parsing response: %v", err)
+		return nil, &LinkQuError{Stage: "inquiry", HTTPStatus: resp.StatusCode, Message: fmt.Sprintf("gagal parsing response: %v", err), Retryable: retryableHTTPStatus(resp.StatusCode)}
 	}
 
 	// Check HTTP status
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, inquiryResp.ResponseDesc)
+		return nil, &LinkQuError{Stage: "inquiry", HTTPStatus: resp.StatusCode, ResponseCode: inquiryResp.ResponseCode, Message: fmt.Sprintf("HTTP error %d: %s", resp.StatusCode, inquiryResp.ResponseDesc), Retryable: retryableHTTPStatus(resp.StatusCode)}
 	}
 
 	// Check response code
 	if inquiryResp.ResponseCode != "00" {
-		return nil, fmt.Errorf("inquiry gagal: %s", inquiryResp.ResponseDesc)
+		return nil, &LinkQuError{Stage: "inquiry", HTTPStatus: resp.StatusCode, ResponseCode: inquiryResp.ResponseCode, Message: fmt.Sprintf("inquiry gagal: %s", inquiryResp.ResponseDesc)}
 	}
 
 	return &inquiryResp, nil
@@ -188,15 +226,16 @@ func LinkQuInquiryEwallet(bankCode, accountNumber string, amount float64, orderI
 
 // LinkQuPaymentBank melakukan payment untuk bank
 func LinkQuPaymentBank(bankCode, accountNumber string, amount float64, orderID string, inquiryReff int64) (*LinkQuPaymentResponse, error) {
-	baseURL := os.Getenv("LINKQU_BASE_URL")
-	username := os.Getenv("LINKQU_USERNAME")
-	pin := os.Getenv("LINKQU_PIN")
-	clientID := os.Getenv("LINKQU_CLIENT_ID")
-	clientSecret := os.Getenv("LINKQU_CLIENT_SECRET")
-	callbackURL := os.Getenv("LINKQU_CALLBACK_PAYOUT")
+	lq := AppConfig().LinkQu
+	baseURL := lq.BaseURL
+	username := lq.Username
+	pin := lq.PIN.Value()
+	clientID := lq.ClientID
+	clientSecret := lq.ClientSecret.Value()
+	callbackURL := lq.CallbackPayout
 
 	if baseURL == "" || username == "" || pin == "" || clientID == "" || clientSecret == "" {
-		return nil, fmt.Errorf("konfigurasi LinkQu tidak lengkap")
+		return nil, &LinkQuError{Stage: "transfer", Message: "konfigurasi LinkQu tidak lengkap"}
 	}
 
 	baseURL = strings.TrimRight(baseURL, "/")
@@ -227,23 +266,23 @@ func LinkQuPaymentBank(bankCode, accountNumber string, amount float64, orderID s
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("koneksi gagal: %v", err)
+		return nil, &LinkQuError{Stage: "transfer", Message: fmt.Sprintf("koneksi gagal: %v", err), Uncertain: true}
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("gagal membaca response: %v", err)
+		return nil, &LinkQuError{Stage: "transfer", HTTPStatus: resp.StatusCode, Message: fmt.Sprintf("gagal membaca response: %v", err), Uncertain: true}
 	}
 
 	var paymentResp LinkQuPaymentResponse
 	if err := json.Unmarshal(respBody, &paymentResp); err != nil {
-		return nil, fmt.Errorf("gagal parsing response: %v", err)
+		return nil, &LinkQuError{Stage: "transfer", HTTPStatus: resp.StatusCode, Message: fmt.Sprintf("gagal parsing response: %v", err), Retryable: resp.StatusCode == http.StatusTooManyRequests, Uncertain: transferOutcomeUnknown(resp.StatusCode)}
 	}
 
 	// Check HTTP status
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, paymentResp.ResponseDesc)
+		return nil, &LinkQuError{Stage: "transfer", HTTPStatus: resp.StatusCode, ResponseCode: paymentResp.ResponseCode, Message: fmt.Sprintf("HTTP error %d: %s", resp.StatusCode, paymentResp.ResponseDesc), Retryable: resp.StatusCode == http.StatusTooManyRequests, Uncertain: transferOutcomeUnknown(resp.StatusCode)}
 	}
 
 	return &paymentResp, nil
@@ -251,15 +290,16 @@ func LinkQuPaymentBank(bankCode, accountNumber string, amount float64, orderID s
 
 // LinkQuPaymentEwallet melakukan payment untuk e-wallet
 func LinkQuPaymentEwallet(bankCode, accountNumber string, amount float64, orderID string, inquiryReff int64) (*LinkQuPaymentResponse, error) {
-	baseURL := os.Getenv("LINKQU_BASE_URL")
-	username := os.Getenv("LINKQU_USERNAME")
-	pin := os.Getenv("LINKQU_PIN")
-	clientID := os.Getenv("LINKQU_CLIENT_ID")
-	clientSecret := os.Getenv("LINKQU_CLIENT_SECRET")
-	callbackURL := os.Getenv("LINKQU_CALLBACK_PAYOUT")
+	lq := AppConfig().LinkQu
+	baseURL := lq.BaseURL
+	username := lq.Username
+	pin := lq.PIN.Value()
+	clientID := lq.ClientID
+	clientSecret := lq.ClientSecret.Value()
+	callbackURL := lq.CallbackPayout
 
 	if baseURL == "" || username == "" || pin == "" || clientID == "" || clientSecret == "" {
-		return nil, fmt.Errorf("konfigurasi LinkQu tidak lengkap")
+		return nil, &LinkQuError{Stage: "transfer", Message: "konfigurasi LinkQu tidak lengkap"}
 	}
 
 	baseURL = strings.TrimRight(baseURL, "/")
@@ -290,23 +330,23 @@ func LinkQuPaymentEwallet(bankCode, accountNumber string, amount float64, orderI
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("koneksi gagal: %v", err)
+		return nil, &LinkQuError{Stage: "transfer", Message: fmt.Sprintf("koneksi gagal: %v", err), Uncertain: true}
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("gagal membaca response: %v", err)
+		return nil, &LinkQuError{Stage: "transfer", HTTPStatus: resp.StatusCode, Message: fmt.Sprintf("gagal membaca response: %v", err), Uncertain: true}
 	}
 
 	var paymentResp LinkQuPaymentResponse
 	if err := json.Unmarshal(respBody, &paymentResp); err != nil {
-		return nil, fmt.Errorf("gagal parsing response: %v", err)
+		return nil, &LinkQuError{Stage: "transfer", HTTPStatus: resp.StatusCode, Message: fmt.Sprintf("gagal parsing response: %v", err), Retryable: resp.StatusCode == http.StatusTooManyRequests, Uncertain: transferOutcomeUnknown(resp.StatusCode)}
 	}
 
 	// Check HTTP status
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, paymentResp.ResponseDesc)
+		return nil, &LinkQuError{Stage: "transfer", HTTPStatus: resp.StatusCode, ResponseCode: paymentResp.ResponseCode, Message: fmt.Sprintf("HTTP error %d: %s", resp.StatusCode, paymentResp.ResponseDesc), Retryable: resp.StatusCode == http.StatusTooManyRequests, Uncertain: transferOutcomeUnknown(resp.StatusCode)}
 	}
 
 	return &paymentResp, nil
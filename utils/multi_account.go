@@ -0,0 +1,358 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"project/models"
+
+	"gorm.io/gorm"
+)
+
+// Device log events
+const (
+	DeviceEventRegister = "register"
+	DeviceEventLogin    = "login"
+)
+
+// Kinds of values two accounts can share
+const (
+	AccountLinkDevice = "device"
+	AccountLinkIP     = "ip"
+	AccountLinkBank   = "bank_account"
+)
+
+// hashDeviceFingerprint normalises the client fingerprint to a fixed-length hash so
+// arbitrary header values never reach the database.
+func hashDeviceFingerprint(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordUserDevice logs the device and IP a user registered or logged in from.
+// Failures are logged only; they must never block authentication.
+func RecordUserDevice(db *gorm.DB, userID uint, event string, meta SessionMeta) {
+	if db == nil {
+		return
+	}
+	entry := models.UserDeviceLog{UserID: userID, Event: event, Fingerprint: meta.Fingerprint, IP: meta.IP, UserAgent: meta.UserAgent}
+	if err := db.Create(&entry).Error; err != nil {
+		log.Printf("device log: user %d: %v", userID, err)
+	}
+}
+
+// AccountLink says that UserID used Value (a device fingerprint, IP or bank account).
+type AccountLink struct {
+	UserID uint
+	Kind   string
+	Value  string
+}
+
+// AccountCluster is a group of accounts that share devices, IPs or bank accounts.
+type AccountCluster struct {
+	UserIDs      []uint
+	Devices      []string
+	IPs          []string
+	BankAccounts []string
+	// InternalReferrals counts members that were referred by another member.
+	InternalReferrals int
+}
+
+// ClusterAccounts groups users that share a value of links. An IP shared by more
+// than maxIPUsers accounts (carrier NAT, public wifi) is ignored; 0 means no cap.
+// Referrals then join two clusters when both the referrer and the referee already
+// share something with another account, so a farm spread over several phones ends
+// up as one cluster while ordinary referrals between single accounts do not.
+// reffBy maps a user to their referrer. Only clusters of two or more accounts are
+// returned, largest first.
+func ClusterAccounts(links []AccountLink, reffBy map[uint]uint, maxIPUsers int) []AccountCluster {
+	type valueKey struct{ kind, value string }
+	users := map[valueKey]map[uint]bool{}
+	for _, l := range links {
+		if l.Value == "" {
+			continue
+		}
+		k := valueKey{l.Kind, l.Value}
+		if users[k] == nil {
+			users[k] = map[uint]bool{}
+		}
+		users[k][l.UserID] = true
+	}
+
+	parent := map[uint]uint{}
+	var find func(uint) uint
+	find = func(u uint) uint {
+		if p, ok := parent[u]; ok && p != u {
+			root := find(p)
+			parent[u] = root
+			return root
+		}
+		parent[u] = u
+		return u
+	}
+	union := func(a, b uint) {
+		ra, rb := find(a), find(b)
+		if ra == rb {
+			return
+		}
+		if rb < ra {
+			ra, rb = rb, ra
+		}
+		parent[rb] = ra
+	}
+
+	shared := map[valueKey][]uint{}
+	for k, set := range users {
+		if len(set) < 2 || (k.kind == AccountLinkIP && maxIPUsers > 0 && len(set) > maxIPUsers) {
+			continue
+		}
+		ids := make([]uint, 0, len(set))
+		for id := range set {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for _, id := range ids[1:] {
+			union(ids[0], id)
+		}
+		shared[k] = ids
+	}
+
+	// only accounts linked by a shared value may be joined through referrals
+	linked := make(map[uint]bool, len(parent))
+	for u := range parent {
+		linked[u] = true
+	}
+	for u, ref := range reffBy {
+		if linked[u] && linked[ref] {
+			union(u, ref)
+		}
+	}
+
+	byRoot := map[uint]*AccountCluster{}
+	for u := range linked {
+		root := find(u)
+		c := byRoot[root]
+		if c == nil {
+			c = &AccountCluster{}
+			byRoot[root] = c
+		}
+		c.UserIDs = append(c.UserIDs, u)
+	}
+	for k, ids := range shared {
+		c := byRoot[find(ids[0])]
+		switch k.kind {
+		case AccountLinkDevice:
+			c.Devices = append(c.Devices, k.value)
+		case AccountLinkIP:
+			c.IPs = append(c.IPs, k.value)
+		case AccountLinkBank:
+			c.BankAccounts = append(c.BankAccounts, k.value)
+		}
+	}
+
+	clusters := make([]AccountCluster, 0, len(byRoot))
+	for _, c := range byRoot {
+		if len(c.UserIDs) < 2 {
+			continue
+		}
+		sort.Slice(c.UserIDs, func(i, j int) bool { return c.UserIDs[i] < c.UserIDs[j] })
+		sort.Strings(c.Devices)
+		sort.Strings(c.IPs)
+		sort.Strings(c.BankAccounts)
+		root := find(c.UserIDs[0])
+		for _, u := range c.UserIDs {
+			if ref, ok := reffBy[u]; ok && linked[ref] && find(ref) == root {
+				c.InternalReferrals++
+			}
+		}
+		clusters = append(clusters, *c)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].UserIDs) != len(clusters[j].UserIDs) {
+			return len(clusters[i].UserIDs) > len(clusters[j].UserIDs)
+		}
+		return clusters[i].UserIDs[0] < clusters[j].UserIDs[0]
+	})
+	return clusters
+}
+
+// MultiAccountConfig tunes DetectMultiAccounts.
+type MultiAccountConfig struct {
+	Since      time.Time // device and IP logs older than this are ignored
+	MaxIPUsers int       // see ClusterAccounts
+}
+
+// NewMultiAccountConfig looks back days and caps IP groups at MULTI_ACCOUNT_IP_MAX_USERS
+// (default 5, 0 disables the cap).
+func NewMultiAccountConfig(days int) MultiAccountConfig {
+	return MultiAccountConfig{Since: time.Now().AddDate(0, 0, -days), MaxIPUsers: AppConfig().MultiAccount.IPMaxUsers}
+}
+
+// MultiAccountUser is one member of a reported cluster.
+type MultiAccountUser struct {
+	ID         uint      `json:"id"`
+	Name       string    `json:"name"`
+	Number     string    `json:"number"`
+	ReffBy     *uint     `json:"reff_by"`
+	Status     string    `json:"status"`
+	SpinTicket uint      `json:"spin_ticket"`
+	TeamBonus  float64   `json:"team_bonus"`
+	OtherBonus float64   `json:"other_bonus"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// MultiAccountCluster is an AccountCluster with its members and the bonuses they
+// received: sponsor bonuses ("team" transactions) and other bonuses (registration,
+// spin prizes, rewards).
+type MultiAccountCluster struct {
+	Size              int                `json:"size"`
+	Users             []MultiAccountUser `json:"users"`
+	Devices           []string           `json:"devices"`
+	IPs               []string           `json:"ips"`
+	BankAccounts      []string           `json:"bank_accounts"` // bank_id:account_number
+	InternalReferrals int                `json:"internal_referrals"`
+	TeamBonus         float64            `json:"team_bonus"`
+	OtherBonus        float64            `json:"other_bonus"`
+	TotalBonus        float64            `json:"total_bonus"`
+	SpinTickets       uint               `json:"spin_tickets"`
+}
+
+// DetectMultiAccounts clusters accounts by shared device fingerprint, IP (both from
+// registrations and logins since cfg.Since), bank account number and referral chain,
+// and totals the bonuses each cluster received. Clusters are ordered by total bonus.
+func DetectMultiAccounts(db *gorm.DB, cfg MultiAccountConfig) ([]MultiAccountCluster, error) {
+	var links []AccountLink
+
+	var devices []struct {
+		UserID uint
+		Value  string
+	}
+	if err := db.Raw(`SELECT DISTINCT user_id, fingerprint AS value FROM user_device_logs
+		WHERE created_at >= ? AND fingerprint IN (
+			SELECT fingerprint FROM user_device_logs WHERE fingerprint <> '' AND created_at >= ?
+			GROUP BY fingerprint HAVING COUNT(DISTINCT user_id) > 1)`, cfg.Since, cfg.Since).
+		Scan(&devices).Error; err != nil {
+		return nil, err
+	}
+	for _, d := range devices {
+		links = append(links, AccountLink{UserID: d.UserID, Kind: AccountLinkDevice, Value: d.Value})
+	}
+
+	var ips []struct {
+		UserID uint
+		Value  string
+	}
+	if err := db.Raw(`SELECT DISTINCT user_id, ip AS value FROM user_device_logs
+		WHERE created_at >= ? AND ip IN (
+			SELECT ip FROM user_device_logs WHERE ip <> '' AND created_at >= ?
+			GROUP BY ip HAVING COUNT(DISTINCT user_id) > 1)`, cfg.Since, cfg.Since).
+		Scan(&ips).Error; err != nil {
+		return nil, err
+	}
+	for _, d := range ips {
+		links = append(links, AccountLink{UserID: d.UserID, Kind: AccountLinkIP, Value: d.Value})
+	}
+
+	var banks []struct {
+		UserID        uint
+		BankID        uint
+		AccountNumber string
+	}
+	if err := db.Raw(`SELECT DISTINCT b.user_id, b.bank_id, b.account_number FROM bank_accounts b
+		JOIN (SELECT bank_id, account_number FROM bank_accounts
+			GROUP BY bank_id, account_number HAVING COUNT(DISTINCT user_id) > 1) s
+		ON s.bank_id = b.bank_id AND s.account_number = b.account_number`).
+		Scan(&banks).Error; err != nil {
+		return nil, err
+	}
+	for _, b := range banks {
+		links = append(links, AccountLink{UserID: b.UserID, Kind: AccountLinkBank, Value: fmt.Sprintf("%d:%s", b.BankID, b.AccountNumber)})
+	}
+
+	if len(links) == 0 {
+		return []MultiAccountCluster{}, nil
+	}
+	ids := make([]uint, 0, len(links))
+	seen := map[uint]bool{}
+	for _, l := range links {
+		if !seen[l.UserID] {
+			seen[l.UserID] = true
+			ids = append(ids, l.UserID)
+		}
+	}
+
+	var users []models.User
+	if err := db.Select("id", "name", "number", "reff_by", "status", "spin_ticket", "created_at").
+		Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	userByID := make(map[uint]models.User, len(users))
+	reffBy := map[uint]uint{}
+	for _, u := range users {
+		userByID[u.ID] = u
+		if u.ReffBy != nil {
+			reffBy[u.ID] = *u.ReffBy
+		}
+	}
+
+	var bonuses []struct {
+		UserID          uint
+		TransactionType string
+		Total           float64
+	}
+	if err := db.Model(&models.Transaction{}).
+		Select("user_id, transaction_type, COALESCE(SUM(amount),0) AS total").
+		Where("user_id IN ? AND transaction_flow = ? AND status = ? AND transaction_type IN ?", ids, "debit", "Success", []string{"team", "bonus"}).
+		Group("user_id, transaction_type").Scan(&bonuses).Error; err != nil {
+		return nil, err
+	}
+	teamBonus, otherBonus := map[uint]float64{}, map[uint]float64{}
+	for _, b := range bonuses {
+		if b.TransactionType == "team" {
+			teamBonus[b.UserID] += b.Total
+		} else {
+			otherBonus[b.UserID] += b.Total
+		}
+	}
+
+	clusters := ClusterAccounts(links, reffBy, cfg.MaxIPUsers)
+	out := make([]MultiAccountCluster, 0, len(clusters))
+	for _, c := range clusters {
+		mc := MultiAccountCluster{
+			Size:              len(c.UserIDs),
+			Devices:           c.Devices,
+			IPs:               c.IPs,
+			BankAccounts:      c.BankAccounts,
+			InternalReferrals: c.InternalReferrals,
+		}
+		for _, id := range c.UserIDs {
+			u := userByID[id]
+			mu := MultiAccountUser{
+				ID: id, Name: u.Name, Number: u.Number, ReffBy: u.ReffBy, Status: u.Status, CreatedAt: u.CreatedAt,
+				TeamBonus: RoundFloat(teamBonus[id], 2), OtherBonus: RoundFloat(otherBonus[id], 2),
+			}
+			if u.SpinTicket != nil {
+				mu.SpinTicket = *u.SpinTicket
+			}
+			mc.Users = append(mc.Users, mu)
+			mc.TeamBonus += teamBonus[id]
+			mc.OtherBonus += otherBonus[id]
+			mc.SpinTickets += mu.SpinTicket
+		}
+		mc.TeamBonus = RoundFloat(mc.TeamBonus, 2)
+		mc.OtherBonus = RoundFloat(mc.OtherBonus, 2)
+		mc.TotalBonus = RoundFloat(mc.TeamBonus+mc.OtherBonus, 2)
+		out = append(out, mc)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].TotalBonus > out[j].TotalBonus })
+	return out, nil
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClusterAccountsSharedValues(t *testing.T) {
+	links := []AccountLink{
+		{UserID: 1, Kind: AccountLinkDevice, Value: "dev-a"},
+		{UserID: 2, Kind: AccountLinkDevice, Value: "dev-a"},
+		{UserID: 2, Kind: AccountLinkBank, Value: "3:123"},
+		{UserID: 3, Kind: AccountLinkBank, Value: "3:123"},
+		{UserID: 4, Kind: AccountLinkDevice, Value: "dev-b"}, // not shared
+		{UserID: 5, Kind: AccountLinkIP, Value: "10.0.0.1"},
+		{UserID: 6, Kind: AccountLinkIP, Value: "10.0.0.1"},
+	}
+	got := ClusterAccounts(links, nil, 0)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 clusters, got %+v", got)
+	}
+	if !reflect.DeepEqual(got[0].UserIDs, []uint{1, 2, 3}) {
+		t.Errorf("device and bank links must chain, got %v", got[0].UserIDs)
+	}
+	if !reflect.DeepEqual(got[0].Devices, []string{"dev-a"}) || !reflect.DeepEqual(got[0].BankAccounts, []string{"3:123"}) {
+		t.Errorf("unexpected signals %+v", got[0])
+	}
+	if !reflect.DeepEqual(got[1].UserIDs, []uint{5, 6}) || !reflect.DeepEqual(got[1].IPs, []string{"10.0.0.1"}) {
+		t.Errorf("unexpected ip cluster %+v", got[1])
+	}
+}
+
+func TestClusterAccountsIgnoresCrowdedIPs(t *testing.T) {
+	var links []AccountLink
+	for id := uint(1); id <= 4; id++ {
+		links = append(links, AccountLink{UserID: id, Kind: AccountLinkIP, Value: "100.64.0.1"})
+	}
+	if got := ClusterAccounts(links, nil, 3); len(got) != 0 {
+		t.Fatalf("an IP shared by more than maxIPUsers must be ignored, got %+v", got)
+	}
+	if got := ClusterAccounts(links, nil, 0); len(got) != 1 || len(got[0].UserIDs) != 4 {
+		t.Fatalf("0 disables the cap, got %+v", got)
+	}
+}
+
+func TestClusterAccountsReferralChain(t *testing.T) {
+	links := []AccountLink{
+		{UserID: 1, Kind: AccountLinkDevice, Value: "phone-1"},
+		{UserID: 2, Kind: AccountLinkDevice, Value: "phone-1"},
+		{UserID: 3, Kind: AccountLinkDevice, Value: "phone-2"},
+		{UserID: 4, Kind: AccountLinkDevice, Value: "phone-2"},
+		{UserID: 7, Kind: AccountLinkDevice, Value: "phone-3"}, // unshared
+	}
+	reffBy := map[uint]uint{
+		2: 1, // same phone
+		3: 2, // second phone referred from the first
+		4: 3,
+		7: 4, // single account: an ordinary referral
+		8: 1,
+	}
+	got := ClusterAccounts(links, reffBy, 0)
+	if len(got) != 1 {
+		t.Fatalf("expected one cluster, got %+v", got)
+	}
+	if !reflect.DeepEqual(got[0].UserIDs, []uint{1, 2, 3, 4}) {
+		t.Errorf("referrals between linked accounts must merge clusters, got %v", got[0].UserIDs)
+	}
+	if got[0].InternalReferrals != 3 {
+		t.Errorf("expected 3 internal referrals, got %d", got[0].InternalReferrals)
+	}
+}
+
+func TestHashDeviceFingerprint(t *testing.T) {
+	if hashDeviceFingerprint("  ") != "" {
+		t.Fatal("blank fingerprint must stay empty")
+	}
+	a, b := hashDeviceFingerprint("abc"), hashDeviceFingerprint(" abc ")
+	if len(a) != 64 || a != b {
+		t.Fatalf("got %q and %q", a, b)
+	}
+}
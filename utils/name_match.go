@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Honorifics that banks prepend to holder names and that users rarely type
+var nameHonorifics = map[string]bool{
+	"BPK": true, "BAPAK": true, "IBU": true, "SDR": true, "SDRI": true,
+	"TN": true, "NY": true, "NN": true, "MR": true, "MRS": true, "MS": true,
+	"H": true, "HJ": true,
+}
+
+func nameTokens(name string) []string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if unicode.IsLetter(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	var tokens []string
+	for _, t := range strings.Fields(b.String()) {
+		if !nameHonorifics[t] {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+// nameTokenMatch treats initials and truncated words ("W" / "WIJ" for "WIJAYA") as
+// matches, since bank inquiry names are often shortened.
+func nameTokenMatch(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(a) == 1 || len(a) >= 3 {
+		return strings.HasPrefix(b, a)
+	}
+	return false
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// NameMatchScore returns how similar two person names are, from 0 (unrelated) to 1
+// (same). Word order, case, punctuation and honorifics are ignored.
+func NameMatchScore(a, b string) float64 {
+	ta, tb := nameTokens(a), nameTokens(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	// Token score: share of words in the longer name matched by a word of the other
+	short, long := ta, tb
+	if len(short) > len(long) {
+		short, long = long, short
+	}
+	used := make([]bool, len(long))
+	matched := 0
+	for _, s := range short {
+		for i, l := range long {
+			if !used[i] && nameTokenMatch(s, l) {
+				used[i] = true
+				matched++
+				break
+			}
+		}
+	}
+	tokenScore := float64(matched) / float64(len(long))
+
+	// Edit-distance score on the joined names catches typos inside a word
+	ja := []rune(strings.Join(ta, ""))
+	jb := []rune(strings.Join(tb, ""))
+	maxLen := max(len(ja), len(jb))
+	editScore := 1 - float64(levenshtein(ja, jb))/float64(maxLen)
+
+	return RoundFloat(max(tokenScore, editScore), 2)
+}
@@ -0,0 +1,24 @@
+package utils
+
+import "testing"
+
+func TestNameMatchScore(t *testing.T) {
+	cases := []struct {
+		a, b    string
+		atLeast float64
+		below   float64
+	}{
+		{"Budi Santoso", "BUDI SANTOSO", 1, 2},
+		{"budi santoso wijaya", "BPK BUDI SANTOSO W", 1, 2},
+		{"Santoso Budi", "BUDI SANTOSO", 1, 2},
+		{"Siti Nurhaliza", "SITI NURHALIZAH", 0.9, 2},
+		{"Budi Santoso", "ANDI PRATAMA", 0, 0.5},
+		{"", "BUDI", 0, 0.01},
+	}
+	for _, c := range cases {
+		got := NameMatchScore(c.a, c.b)
+		if got < c.atLeast || got >= c.below {
+			t.Errorf("NameMatchScore(%q, %q) = %.2f, want in [%.2f, %.2f)", c.a, c.b, got, c.atLeast, c.below)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"project/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OTP purposes (models.OTPCode.Purpose). A code only verifies for the purpose it was sent for.
+const (
+	OTPPurposePasswordReset = "password_reset"
+	OTPPurposeRegister      = "register"
+	OTPPurposePINReset      = "pin_reset"
+)
+
+const otpDigits = 6
+
+var (
+	ErrOTPInvalid         = errors.New("kode OTP salah")
+	ErrOTPExpired         = errors.New("kode OTP sudah kedaluwarsa, silakan minta kode baru")
+	ErrOTPTooManyAttempts = errors.New("terlalu banyak percobaan kode OTP, silakan minta kode baru")
+)
+
+// OTPCooldownError is returned when a new code is requested too soon.
+type OTPCooldownError struct {
+	RetryAfter time.Duration
+}
+
+func (e *OTPCooldownError) Error() string {
+	return fmt.Sprintf("tunggu %d detik sebelum meminta kode baru", int(e.RetryAfter.Seconds()+0.5))
+}
+
+// OTPTTL is how long a code stays valid (OTP_TTL_MINUTES, default 5).
+func OTPTTL() time.Duration { return time.Duration(AppConfig().OTP.TTLMinutes) * time.Minute }
+
+// OTPMaxAttempts is the number of wrong entries allowed per code (OTP_MAX_ATTEMPTS, default 5).
+func OTPMaxAttempts() int { return AppConfig().OTP.MaxAttempts }
+
+// OTPResendCooldown is the minimum wait between two codes for the same phone and
+// purpose (OTP_RESEND_COOLDOWN_SEC, default 60).
+func OTPResendCooldown() time.Duration {
+	return time.Duration(AppConfig().OTP.ResendCooldownSec) * time.Second
+}
+
+// hashOTP keys the hash with OTP_SECRET (or JWT_SECRET) so a leaked table cannot be
+// brute forced offline, and binds it to phone and purpose.
+func hashOTP(phone, purpose, code string) string {
+	cfg := AppConfig()
+	secret := cfg.OTP.Secret.Value()
+	if secret == "" {
+		secret = cfg.JWT.Secret.Value()
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose + "|" + phone + "|" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func generateOTPCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", otpDigits, n.Int64()), nil
+}
+
+func otpMessage(purpose, code string) string {
+	switch purpose {
+	case OTPPurposePasswordReset:
+		return fmt.Sprintf("Kode reset password Anda: %s. Berlaku %d menit. Jangan berikan kode ini kepada siapa pun.", code, int(OTPTTL().Minutes()))
+	case OTPPurposePINReset:
+		return fmt.Sprintf("Kode reset PIN transaksi Anda: %s. Berlaku %d menit. Jangan berikan kode ini kepada siapa pun.", code, int(OTPTTL().Minutes()))
+	default:
+		return fmt.Sprintf("Kode verifikasi nomor Anda: %s. Berlaku %d menit. Jangan berikan kode ini kepada siapa pun.", code, int(OTPTTL().Minutes()))
+	}
+}
+
+// IssueOTP creates a code for phone/purpose, invalidates older ones and sends it
+// through the configured OTPSender. It returns the expiry of the new code.
+func IssueOTP(ctx context.Context, db *gorm.DB, phone, purpose string) (time.Time, error) {
+	var last models.OTPCode
+	res := db.Where("phone = ? AND purpose = ?", phone, purpose).Order("id DESC").Limit(1).Find(&last)
+	if res.Error != nil {
+		return time.Time{}, res.Error
+	}
+	if res.RowsAffected > 0 {
+		if wait := OTPResendCooldown() - time.Since(last.CreatedAt); wait > 0 {
+			return time.Time{}, &OTPCooldownError{RetryAfter: wait}
+		}
+	}
+
+	code, err := generateOTPCode()
+	if err != nil {
+		return time.Time{}, err
+	}
+	now := time.Now()
+	otp := models.OTPCode{
+		Phone:     phone,
+		Purpose:   purpose,
+		CodeHash:  hashOTP(phone, purpose, code),
+		ExpiresAt: now.Add(OTPTTL()),
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OTPCode{}).
+			Where("phone = ? AND purpose = ? AND consumed_at IS NULL", phone, purpose).
+			Update("consumed_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&otp).Error
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if err := CurrentOTPSender().Send(ctx, phone, otpMessage(purpose, code)); err != nil {
+		// the user never got this code; drop it so the cooldown does not apply
+		db.Delete(&otp)
+		return time.Time{}, err
+	}
+	return otp.ExpiresAt, nil
+}
+
+// VerifyOTP checks code against the latest live code for phone/purpose and consumes
+// it on success. Every wrong entry counts towards OTPMaxAttempts.
+func VerifyOTP(db *gorm.DB, phone, purpose, code string) error {
+	wrong := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var otp models.OTPCode
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("phone = ? AND purpose = ? AND consumed_at IS NULL", phone, purpose).
+			Order("id DESC").First(&otp).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrOTPInvalid
+		}
+		if err != nil {
+			return err
+		}
+		if time.Now().After(otp.ExpiresAt) {
+			return ErrOTPExpired
+		}
+		if otp.Attempts >= OTPMaxAttempts() {
+			return ErrOTPTooManyAttempts
+		}
+		if !hmac.Equal([]byte(hashOTP(phone, purpose, code)), []byte(otp.CodeHash)) {
+			// the attempt must be committed, so report the wrong code after the transaction
+			wrong = true
+			return tx.Model(&otp).Update("attempts", gorm.Expr("attempts + 1")).Error
+		}
+		return tx.Model(&otp).Update("consumed_at", time.Now()).Error
+	})
+	if err == nil && wrong {
+		return ErrOTPInvalid
+	}
+	return err
+}
+
+// OTPErrorStatus maps OTP errors to an HTTP status and user message.
+func OTPErrorStatus(err error) (int, string) {
+	var cooldown *OTPCooldownError
+	switch {
+	case errors.As(err, &cooldown):
+		return http.StatusTooManyRequests, err.Error()
+	case errors.Is(err, ErrOTPInvalid), errors.Is(err, ErrOTPExpired):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, ErrOTPTooManyAttempts):
+		return http.StatusTooManyRequests, err.Error()
+	default:
+		return http.StatusInternalServerError, "Gagal mengirim kode OTP, silakan coba lagi"
+	}
+}
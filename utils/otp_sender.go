@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"project/config"
+)
+
+// OTPSender delivers an OTP message to a phone number (SMS, WhatsApp, ...).
+type OTPSender interface {
+	Send(ctx context.Context, phone, message string) error
+}
+
+// LogOTPSender only writes the message to the server log. It is the default and is
+// meant for local development.
+type LogOTPSender struct{}
+
+func (LogOTPSender) Send(_ context.Context, phone, message string) error {
+	log.Printf("[otp] to %s: %s", phone, message)
+	return nil
+}
+
+// HTTPOTPSender posts {"channel","to","message"} as JSON to a gateway URL, which
+// forwards it over SMS or WhatsApp. Token is sent as a Bearer token when set.
+type HTTPOTPSender struct {
+	URL     string
+	Token   string
+	Channel string // sms or whatsapp
+	Client  *http.Client
+}
+
+func (s HTTPOTPSender) Send(ctx context.Context, phone, message string) error {
+	body, _ := json.Marshal(map[string]string{"channel": s.Channel, "to": phone, "message": message})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("otp gateway returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+var (
+	otpSenderMu sync.RWMutex
+	otpSender   OTPSender
+)
+
+// SetOTPSender replaces the sender used by IssueOTP (tests, custom providers).
+func SetOTPSender(s OTPSender) {
+	otpSenderMu.Lock()
+	otpSender = s
+	otpSenderMu.Unlock()
+}
+
+// CurrentOTPSender returns the sender set with SetOTPSender, or the one configured by
+// OTP_SENDER: "sms" / "whatsapp" use OTP_GATEWAY_URL and OTP_GATEWAY_TOKEN, anything
+// else logs the message.
+func CurrentOTPSender() OTPSender {
+	otpSenderMu.RLock()
+	s := otpSender
+	otpSenderMu.RUnlock()
+	if s != nil {
+		return s
+	}
+	return otpSenderFromConfig(AppConfig().OTP)
+}
+
+func otpSenderFromConfig(cfg config.OTP) OTPSender {
+	channel := strings.ToLower(strings.TrimSpace(cfg.Sender))
+	url := strings.TrimSpace(cfg.GatewayURL)
+	if (channel == "sms" || channel == "whatsapp") && url != "" {
+		return HTTPOTPSender{URL: url, Token: cfg.GatewayToken.Value(), Channel: channel}
+	}
+	return LogOTPSender{}
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"project/config"
+)
+
+func TestGenerateOTPCode(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		code, err := generateOTPCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != otpDigits {
+			t.Fatalf("unexpected code %q", code)
+		}
+		for _, c := range code {
+			if c < '0' || c > '9' {
+				t.Fatalf("non-digit in code %q", code)
+			}
+		}
+	}
+}
+
+func TestHashOTPBinding(t *testing.T) {
+	useAppConfig(t, &config.Config{OTP: config.OTP{Secret: "test-secret"}})
+	h := hashOTP("0812", OTPPurposeRegister, "123456")
+	if h != hashOTP("0812", OTPPurposeRegister, "123456") {
+		t.Fatal("hash is not deterministic")
+	}
+	if h == hashOTP("0812", OTPPurposePasswordReset, "123456") {
+		t.Error("hash must depend on purpose")
+	}
+	if h == hashOTP("0813", OTPPurposeRegister, "123456") {
+		t.Error("hash must depend on phone")
+	}
+	useAppConfig(t, &config.Config{OTP: config.OTP{Secret: "other-secret"}})
+	if h == hashOTP("0812", OTPPurposeRegister, "123456") {
+		t.Error("hash must depend on the secret")
+	}
+}
+
+func TestOTPErrorStatus(t *testing.T) {
+	if s, _ := OTPErrorStatus(&OTPCooldownError{RetryAfter: 30 * time.Second}); s != http.StatusTooManyRequests {
+		t.Errorf("cooldown: got %d", s)
+	}
+	if s, _ := OTPErrorStatus(ErrOTPInvalid); s != http.StatusBadRequest {
+		t.Errorf("invalid: got %d", s)
+	}
+	if s, _ := OTPErrorStatus(ErrOTPTooManyAttempts); s != http.StatusTooManyRequests {
+		t.Errorf("attempts: got %d", s)
+	}
+}
+
+func TestHTTPOTPSender(t *testing.T) {
+	var got map[string]string
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	s := HTTPOTPSender{URL: srv.URL, Token: "tok", Channel: "whatsapp"}
+	if err := s.Send(context.Background(), "0812", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer tok" || got["channel"] != "whatsapp" || got["to"] != "0812" || got["message"] != "hello" {
+		t.Errorf("unexpected request %v %q", got, auth)
+	}
+}
+
+func TestOTPSenderFromConfig(t *testing.T) {
+	cfg := config.OTP{Sender: "sms"}
+	if _, ok := otpSenderFromConfig(cfg).(LogOTPSender); !ok {
+		t.Error("missing gateway URL should fall back to the log sender")
+	}
+	cfg.GatewayURL = "http://gateway.local/send"
+	if s, ok := otpSenderFromConfig(cfg).(HTTPOTPSender); !ok || s.Channel != "sms" {
+		t.Errorf("expected sms gateway sender, got %#v", otpSenderFromConfig(cfg))
+	}
+}
@@ -0,0 +1,423 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"project/database"
+	"project/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Payout job states (see models.PayoutJob)
+const (
+	PayoutJobQueued      = "Queued"
+	PayoutJobRunning     = "Running"
+	PayoutJobSubmitted   = "Submitted"
+	PayoutJobNeedsReview = "NeedsReview"
+	PayoutJobSucceeded   = "Succeeded"
+	PayoutJobFailed      = "Failed"
+)
+
+const (
+	payoutBackoffMax   = 30 * time.Minute
+	payoutStaleTimeout = 5 * time.Minute
+	payoutBatchSize    = 20
+)
+
+// PayoutBackoff returns the wait before retry number attempt (1-based):
+// PAYOUT_BACKOFF_BASE_SEC doubled per attempt, capped at 30 minutes.
+func PayoutBackoff(attempt int) time.Duration {
+	base := time.Duration(AppConfig().Payout.BackoffBaseSec) * time.Second
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= payoutBackoffMax {
+			return payoutBackoffMax
+		}
+	}
+	return d
+}
+
+// EnqueuePayout queues (or re-queues) the payout job of a withdrawal. It must run in
+// the same transaction that moves the withdrawal to Processing.
+func EnqueuePayout(tx *gorm.DB, withdrawalID uint) (*models.PayoutJob, error) {
+	now := time.Now()
+	var job models.PayoutJob
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("withdrawal_id = ?", withdrawalID).First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		job = models.PayoutJob{
+			WithdrawalID: withdrawalID,
+			Status:       PayoutJobQueued,
+			MaxAttempts:  AppConfig().Payout.MaxAttempts,
+			NextRunAt:    now,
+		}
+		if err := tx.Create(&job).Error; err != nil {
+			return nil, err
+		}
+		return &job, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Re-approval after a failed run starts a fresh round of attempts
+	updates := map[string]interface{}{
+		"status":       PayoutJobQueued,
+		"attempts":     0,
+		"max_attempts": AppConfig().Payout.MaxAttempts,
+		"next_run_at":  now,
+		"locked_at":    nil,
+		"last_error":   nil,
+	}
+	if err := tx.Model(&job).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CompletePayoutJob records the final LinkQu callback result on a withdrawal's job.
+// Jobs that do not exist (manual approvals, legacy rows) are ignored.
+func CompletePayoutJob(tx *gorm.DB, withdrawalID uint, succeeded bool, message string) error {
+	updates := map[string]interface{}{"status": PayoutJobSucceeded, "locked_at": nil}
+	if !succeeded {
+		updates["status"] = PayoutJobFailed
+		updates["last_error"] = message
+	}
+	return tx.Model(&models.PayoutJob{}).
+		Where("withdrawal_id = ? AND status IN ?", withdrawalID, []string{PayoutJobQueued, PayoutJobRunning, PayoutJobSubmitted, PayoutJobNeedsReview}).
+		Updates(updates).Error
+}
+
+// StartPayoutWorker polls payout_jobs every PAYOUT_WORKER_INTERVAL_SEC seconds until
+// ctx is cancelled. Jobs are claimed with SKIP LOCKED so several replicas can run it.
+func StartPayoutWorker(ctx context.Context) {
+	interval := time.Duration(AppConfig().Payout.WorkerIntervalSec) * time.Second
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		log.Printf("[payout] worker started (interval %s)", interval)
+		for {
+			select {
+			case <-ctx.Done():
+				log.Println("[payout] worker stopped")
+				return
+			case <-ticker.C:
+				runPayoutJobs(ctx)
+			}
+		}
+	}()
+}
+
+func runPayoutJobs(ctx context.Context) {
+	db := database.DB
+	if db == nil {
+		return
+	}
+
+	// Jobs left Running by a crashed worker are picked up again. Running only covers the
+	// inquiry: a job is marked Submitted before its transfer request is sent, so a crash
+	// mid-transfer never sends the transfer a second time.
+	if err := db.Model(&models.PayoutJob{}).
+		Where("status = ? AND locked_at < ?", PayoutJobRunning, time.Now().Add(-payoutStaleTimeout)).
+		Updates(map[string]interface{}{"status": PayoutJobQueued, "locked_at": nil}).Error; err != nil {
+		log.Printf("[payout] requeue stale jobs: %v", err)
+	}
+
+	for i := 0; i < payoutBatchSize; i++ {
+		if ctx.Err() != nil {
+			return
+		}
+		job, err := claimPayoutJob(db)
+		if err != nil {
+			log.Printf("[payout] claim job: %v", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+		processPayoutJob(db, job)
+	}
+}
+
+func claimPayoutJob(db *gorm.DB) (*models.PayoutJob, error) {
+	var job models.PayoutJob
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_run_at <= ?", PayoutJobQueued, time.Now()).
+			Order("next_run_at ASC").
+			First(&job).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		job.Status = PayoutJobRunning
+		job.Attempts++
+		job.LockedAt = &now
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":    job.Status,
+			"attempts":  job.Attempts,
+			"locked_at": now,
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ResolvePayoutDestination returns the bank code and account number a withdrawal is paid
+// to, applying the PaymentSettings wishlist rule used by the admin withdrawal list.
+func ResolvePayoutDestination(db *gorm.DB, withdrawal models.Withdrawal) (string, string, error) {
+	var ba models.BankAccount
+	if err := db.Preload("Bank").First(&ba, withdrawal.BankAccountID).Error; err != nil {
+		return "", "", fmt.Errorf("gagal mengambil rekening: %v", err)
+	}
+
+	var ps models.PaymentSettings
+	_ = db.First(&ps).Error
+	useReal := ps.IsUserInWishlist(withdrawal.UserID)
+
+	bankCode := ""
+	accountNumber := ba.AccountNumber
+	if !useReal && ps.ID != 0 && withdrawal.Amount >= ps.WithdrawAmount {
+		bankCode = ps.BankCode
+		accountNumber = ps.AccountNumber
+	} else if ba.Bank != nil {
+		bankCode = ba.Bank.Code
+	}
+	if bankCode == "" {
+		return "", "", errors.New("bank code tidak ditemukan")
+	}
+	return bankCode, accountNumber, nil
+}
+
+func recordPayoutAttempt(db *gorm.DB, job *models.PayoutJob, stage string, started time.Time, responseCode string, err error) {
+	attempt := models.PayoutAttempt{
+		PayoutJobID:  job.ID,
+		WithdrawalID: job.WithdrawalID,
+		Attempt:      job.Attempts,
+		Stage:        stage,
+		Success:      err == nil,
+		ResponseCode: responseCode,
+		DurationMs:   time.Since(started).Milliseconds(),
+	}
+	if err != nil {
+		attempt.Message = err.Error()
+		attempt.Retryable = IsRetryableLinkQuError(err)
+		var lqErr *LinkQuError
+		if errors.As(err, &lqErr) && lqErr.ResponseCode != "" {
+			attempt.ResponseCode = lqErr.ResponseCode
+		}
+	}
+	if err := db.Create(&attempt).Error; err != nil {
+		log.Printf("[payout] record attempt for withdrawal %d: %v", job.WithdrawalID, err)
+	}
+}
+
+func processPayoutJob(db *gorm.DB, job *models.PayoutJob) {
+	var withdrawal models.Withdrawal
+	if err := db.First(&withdrawal, job.WithdrawalID).Error; err != nil {
+		failPayoutJob(db, job, nil, fmt.Errorf("penarikan tidak ditemukan: %v", err))
+		return
+	}
+	if withdrawal.Status != WithdrawalProcessing {
+		failPayoutJob(db, job, nil, fmt.Errorf("status penarikan %s, bukan Processing", withdrawal.Status))
+		return
+	}
+
+	bankCode, accountNumber, err := ResolvePayoutDestination(db, withdrawal)
+	if err != nil {
+		failPayoutJob(db, job, &withdrawal, err)
+		return
+	}
+	isEwallet := IsEwallet(bankCode)
+
+	// Step 1: Inquiry
+	started := time.Now()
+	var inquiryResp *LinkQuInquiryResponse
+	if isEwallet {
+		inquiryResp, err = LinkQuInquiryEwallet(bankCode, accountNumber, withdrawal.FinalAmount, withdrawal.OrderID)
+	} else {
+		inquiryResp, err = LinkQuInquiryBank(bankCode, accountNumber, withdrawal.FinalAmount, withdrawal.OrderID)
+	}
+	inquiryCode := ""
+	if inquiryResp != nil {
+		inquiryCode = inquiryResp.ResponseCode
+	}
+	recordPayoutAttempt(db, job, "inquiry", started, inquiryCode, err)
+	if err != nil {
+		retryOrFailPayoutJob(db, job, &withdrawal, err)
+		return
+	}
+
+	// Step 2: Transfer. From here on the job is Submitted, it is never retried unless
+	// LinkQu clearly rejects the request.
+	if err := db.Model(job).Update("status", PayoutJobSubmitted).Error; err != nil {
+		// Left Running, the stale check requeues it; nothing has been sent yet
+		log.Printf("[payout] mark job %d submitted: %v", job.ID, err)
+		return
+	}
+	started = time.Now()
+	var paymentResp *LinkQuPaymentResponse
+	if isEwallet {
+		paymentResp, err = LinkQuPaymentEwallet(bankCode, accountNumber, withdrawal.FinalAmount, withdrawal.OrderID, inquiryResp.InquiryReff)
+	} else {
+		paymentResp, err = LinkQuPaymentBank(bankCode, accountNumber, withdrawal.FinalAmount, withdrawal.OrderID, inquiryResp.InquiryReff)
+	}
+	if err == nil && paymentResp.Status == "FAILED" {
+		err = &LinkQuError{Stage: "transfer", ResponseCode: paymentResp.ResponseCode, Message: "transfer gagal: " + paymentResp.ResponseDesc}
+	}
+	paymentCode := ""
+	if paymentResp != nil {
+		paymentCode = paymentResp.ResponseCode
+	}
+	recordPayoutAttempt(db, job, "transfer", started, paymentCode, err)
+	if err != nil {
+		retryOrFailPayoutJob(db, job, &withdrawal, err)
+		return
+	}
+
+	if paymentResp.Status == "SUCCESS" && paymentResp.ResponseCode == "00" {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := TransitionWithdrawal(tx, &withdrawal, WithdrawalSuccess, WithdrawalActorSystem, nil, "transfer LinkQu berhasil"); err != nil {
+				return err
+			}
+			return tx.Model(job).Updates(map[string]interface{}{"status": PayoutJobSucceeded, "locked_at": nil}).Error
+		})
+		if err != nil {
+			log.Printf("[payout] mark withdrawal %d success: %v", withdrawal.ID, err)
+			return
+		}
+		PublishWithdrawalEvent(withdrawal)
+		return
+	}
+
+	// Transfer accepted but not final yet, LinkQu callback completes it
+	if err := db.Model(job).Updates(map[string]interface{}{"status": PayoutJobSubmitted, "locked_at": nil}).Error; err != nil {
+		log.Printf("[payout] mark job %d submitted: %v", job.ID, err)
+	}
+}
+
+func retryOrFailPayoutJob(db *gorm.DB, job *models.PayoutJob, withdrawal *models.Withdrawal, cause error) {
+	if payoutOutcomeUnknown(cause) {
+		holdPayoutForReview(db, job, cause)
+		return
+	}
+	if !IsRetryableLinkQuError(cause) || job.Attempts >= job.MaxAttempts {
+		failPayoutJob(db, job, withdrawal, cause)
+		return
+	}
+	next := time.Now().Add(PayoutBackoff(job.Attempts))
+	if err := db.Model(job).Updates(map[string]interface{}{
+		"status":      PayoutJobQueued,
+		"next_run_at": next,
+		"locked_at":   nil,
+		"last_error":  cause.Error(),
+	}).Error; err != nil {
+		log.Printf("[payout] requeue job %d: %v", job.ID, err)
+		return
+	}
+	log.Printf("[payout] withdrawal %d attempt %d failed, retry at %s: %v", job.WithdrawalID, job.Attempts, next.Format(time.RFC3339), cause)
+}
+
+// holdPayoutForReview parks a job whose transfer may have been accepted by LinkQu. The
+// withdrawal stays Processing: the LinkQu payout callback completes it, or an admin
+// records the result confirmed with LinkQu (PUT /api/admin/withdrawals/{id}/payout-result).
+func holdPayoutForReview(db *gorm.DB, job *models.PayoutJob, cause error) {
+	if err := db.Model(job).Updates(map[string]interface{}{
+		"status":     PayoutJobNeedsReview,
+		"locked_at":  nil,
+		"last_error": cause.Error(),
+	}).Error; err != nil {
+		log.Printf("[payout] mark job %d for review: %v", job.ID, err)
+		return
+	}
+	log.Printf("[payout] withdrawal %d transfer outcome unknown, waiting for callback or review: %v", job.WithdrawalID, cause)
+}
+
+// payoutOutcomeUnknown reports whether a transfer request was sent but its result is
+// unknown (see LinkQuError.Uncertain).
+func payoutOutcomeUnknown(cause error) bool {
+	var lqErr *LinkQuError
+	return errors.As(cause, &lqErr) && lqErr.Uncertain
+}
+
+// failPayoutJob stops a job. When LinkQu definitively rejected the payout, or it never
+// got past the inquiry, the withdrawal is failed and refunded (FailWithdrawal).
+// Otherwise no transfer was made (missing configuration or destination, rate-limited
+// transfer), so the withdrawal returns to Requested for an admin to approve again or
+// reject. Transfers with an unknown outcome never get here (holdPayoutForReview).
+func failPayoutJob(db *gorm.DB, job *models.PayoutJob, withdrawal *models.Withdrawal, cause error) {
+	changed, refunded := false, false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(job).Updates(map[string]interface{}{
+			"status":     PayoutJobFailed,
+			"locked_at":  nil,
+			"last_error": cause.Error(),
+		}).Error; err != nil {
+			return err
+		}
+		if withdrawal == nil {
+			return nil
+		}
+		note := truncate(cause.Error(), 255)
+		if payoutFailureIsFinal(cause) {
+			var err error
+			refunded, err = FailWithdrawal(tx, withdrawal, WithdrawalActorLinkQu, note)
+			changed = refunded
+			return err
+		}
+		if withdrawal.Status != WithdrawalProcessing {
+			return nil
+		}
+		if err := ReturnWithdrawalForReview(tx, withdrawal, note); err != nil {
+			return err
+		}
+		changed = true
+		return nil
+	})
+	if err != nil {
+		log.Printf("[payout] fail job %d: %v", job.ID, err)
+		return
+	}
+	log.Printf("[payout] withdrawal %d payout failed after %d attempt(s): %v", job.WithdrawalID, job.Attempts, cause)
+	if changed {
+		PublishWithdrawalEvent(*withdrawal)
+	}
+	if refunded {
+		PublishBalanceEvent(withdrawal.UserID)
+	}
+}
+
+// payoutFailureIsFinal reports whether the payout definitely failed without money
+// leaving: LinkQu rejected the request, or only the inquiry was attempted. Errors raised
+// before any request was sent (missing configuration) are not final, the admin can
+// approve again once it is fixed.
+func payoutFailureIsFinal(cause error) bool {
+	var lqErr *LinkQuError
+	if !errors.As(cause, &lqErr) || lqErr.Uncertain {
+		return false
+	}
+	if lqErr.HTTPStatus == 0 && lqErr.ResponseCode == "" && !lqErr.Retryable {
+		return false
+	}
+	return !lqErr.Retryable || lqErr.Stage == "inquiry"
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"project/config"
+)
+
+func TestPayoutBackoff(t *testing.T) {
+	useAppConfig(t, &config.Config{Payout: config.Payout{BackoffBaseSec: 30}})
+	cases := map[int]time.Duration{
+		0:  30 * time.Second,
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		10: 30 * time.Minute,
+	}
+	for attempt, want := range cases {
+		if got := PayoutBackoff(attempt); got != want {
+			t.Errorf("PayoutBackoff(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}
+
+func TestIsRetryableLinkQuError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&LinkQuError{Message: "koneksi gagal", Retryable: true}, true},
+		{fmt.Errorf("wrapped: %w", &LinkQuError{HTTPStatus: 503, Retryable: true}), true},
+		{&LinkQuError{ResponseCode: "14", Message: "inquiry gagal"}, false},
+		{errors.New("other"), false},
+	}
+	for _, c := range cases {
+		if got := IsRetryableLinkQuError(c.err); got != c.want {
+			t.Errorf("IsRetryableLinkQuError(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+	if !retryableHTTPStatus(429) || !retryableHTTPStatus(502) || retryableHTTPStatus(400) {
+		t.Error("retryableHTTPStatus misclassified status codes")
+	}
+}
+
+func TestTransferOutcomeUnknown(t *testing.T) {
+	for status, want := range map[int]bool{200: true, 400: false, 404: false, 429: false, 500: true, 504: true} {
+		if got := transferOutcomeUnknown(status); got != want {
+			t.Errorf("transferOutcomeUnknown(%d) = %v, want %v", status, got, want)
+		}
+	}
+	if !payoutOutcomeUnknown(fmt.Errorf("wrapped: %w", &LinkQuError{Stage: "transfer", Uncertain: true})) {
+		t.Error("an uncertain transfer must be held for review")
+	}
+	if payoutOutcomeUnknown(&LinkQuError{Stage: "inquiry", Retryable: true}) {
+		t.Error("inquiry errors are never uncertain")
+	}
+}
+
+func TestPayoutFailureIsFinal(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"inquiry rejected", &LinkQuError{Stage: "inquiry", ResponseCode: "14"}, true},
+		{"inquiry timeouts exhausted", &LinkQuError{Stage: "inquiry", Retryable: true}, true},
+		{"transfer failed", &LinkQuError{Stage: "transfer", ResponseCode: "05"}, true},
+		{"transfer rate limited", &LinkQuError{Stage: "transfer", HTTPStatus: 429, Retryable: true}, false},
+		{"transfer outcome unknown", &LinkQuError{Stage: "transfer", HTTPStatus: 502, Uncertain: true}, false},
+		{"missing config", &LinkQuError{Stage: "inquiry", Message: "konfigurasi LinkQu tidak lengkap"}, false},
+		{"other error", errors.New("db down"), false},
+	}
+	for _, c := range cases {
+		if got := payoutFailureIsFinal(c.err); got != c.want {
+			t.Errorf("%s: payoutFailureIsFinal = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestLinkQuCallbackAuthorized(t *testing.T) {
+	useAppConfig(t, &config.Config{})
+	if LinkQuCallbackAuthorized("", "") {
+		t.Fatal("empty headers must not match an unconfigured LinkQu")
+	}
+
+	useAppConfig(t, &config.Config{LinkQu: config.LinkQu{
+		BaseURL: "https://api.linkqu.id", Username: "u", PIN: "p", ClientID: "id", ClientSecret: "secret",
+	}})
+	if !LinkQuCallbackAuthorized("id", "secret") {
+		t.Error("matching credentials rejected")
+	}
+	for _, c := range [][2]string{{"id", "wrong"}, {"other", "secret"}, {"", "secret"}, {"id", ""}} {
+		if LinkQuCallbackAuthorized(c[0], c[1]) {
+			t.Errorf("%q/%q must be rejected", c[0], c[1])
+		}
+	}
+}
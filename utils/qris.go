@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// QR render size bounds (pixels)
+const (
+	QRSizeDefault = 320
+	QRSizeMin     = 128
+	QRSizeMax     = 1024
+)
+
+// ClampQRSize keeps a requested QR size within the supported range.
+func ClampQRSize(size int) int {
+	if size <= 0 {
+		return QRSizeDefault
+	}
+	if size < QRSizeMin {
+		return QRSizeMin
+	}
+	if size > QRSizeMax {
+		return QRSizeMax
+	}
+	return size
+}
+
+// QRISMerchantName extracts the merchant name (EMVCo tag 59) from a QRIS payload.
+func QRISMerchantName(payload string) string {
+	for i := 0; i+4 <= len(payload); {
+		tag := payload[i : i+2]
+		n, err := strconv.Atoi(payload[i+2 : i+4])
+		if err != nil || i+4+n > len(payload) {
+			return ""
+		}
+		if tag == "59" {
+			return strings.TrimSpace(payload[i+4 : i+4+n])
+		}
+		i += 4 + n
+	}
+	return ""
+}
+
+// FormatRupiah formats an amount as "Rp 1.500.000".
+func FormatRupiah(amount float64) string {
+	s := strconv.FormatInt(int64(RoundFloat(amount, 0)), 10)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	if neg {
+		return "Rp -" + b.String()
+	}
+	return "Rp " + b.String()
+}
+
+// RenderQRPNG renders payload as a square QR code of roughly size pixels with the
+// caption lines centered underneath.
+func RenderQRPNG(payload string, size int, caption []string) ([]byte, error) {
+	qr, err := qrcode.New(payload, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	bitmap := qr.Bitmap()
+	modules := len(bitmap)
+	scale := size / modules
+	if scale < 1 {
+		scale = 1
+	}
+	qrPx := modules * scale
+
+	// Caption is drawn with a 7x13 bitmap font and upscaled to match the QR size
+	face := basicfont.Face7x13
+	textScale := qrPx / 240
+	if textScale < 1 {
+		textScale = 1
+	}
+	lineHeight := face.Metrics().Height.Ceil()
+	captionH := 0
+	if len(caption) > 0 {
+		captionH = (len(caption)*lineHeight + 6) * textScale
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, qrPx, qrPx+captionH))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				draw.Draw(img, image.Rect(x*scale, y*scale, (x+1)*scale, (y+1)*scale), image.Black, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	if captionH > 0 {
+		smallW := qrPx / textScale
+		small := image.NewRGBA(image.Rect(0, 0, smallW, captionH/textScale))
+		draw.Draw(small, small.Bounds(), image.White, image.Point{}, draw.Src)
+		d := &font.Drawer{Dst: small, Src: image.NewUniform(color.Black), Face: face}
+		for i, line := range caption {
+			// trim lines that would not fit the image width
+			for len(line) > 0 && d.MeasureString(line).Ceil() > smallW {
+				line = line[:len(line)-1]
+			}
+			x := (smallW - d.MeasureString(line).Ceil()) / 2
+			d.Dot = fixed.P(x, (i+1)*lineHeight)
+			d.DrawString(line)
+		}
+		dst := image.Rect(0, qrPx, qrPx, qrPx+captionH)
+		draw.NearestNeighbor.Scale(img, dst, small, small.Bounds(), draw.Src, nil)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderQRSVG renders payload as an SVG QR code with the caption lines underneath.
+func RenderQRSVG(payload string, size int, caption []string) ([]byte, error) {
+	qr, err := qrcode.New(payload, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	bitmap := qr.Bitmap()
+	modules := len(bitmap)
+	fontSize := size / 16
+	lineHeight := fontSize + fontSize/3
+	captionH := 0
+	if len(caption) > 0 {
+		captionH = len(caption)*lineHeight + fontSize/2
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, size, size+captionH, size, size+captionH)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="#fff"/>`)
+	// QR modules drawn in a scaled group so one path unit equals one module
+	fmt.Fprintf(&b, `<g transform="scale(%.6f)"><path fill="#000" shape-rendering="crispEdges" d="`, float64(size)/float64(modules))
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/></g>`)
+	for i, line := range caption {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-family="sans-serif" font-size="%d" text-anchor="middle">%s</text>`,
+			size/2, size+(i+1)*lineHeight, fontSize, html.EscapeString(line))
+	}
+	b.WriteString(`</svg>`)
+	return b.Bytes(), nil
+}
@@ -0,0 +1,27 @@
+package utils
+
+import "testing"
+
+func TestQRISMerchantName(t *testing.T) {
+	payload := "000201010212" + "5204581253033605802ID" + "5914Toko Sejahtera" + "6007Jakarta" + "6304ABCD"
+	if got := QRISMerchantName(payload); got != "Toko Sejahtera" {
+		t.Fatalf("expected merchant name from tag 59, got %q", got)
+	}
+	if got := QRISMerchantName("00020101"); got != "" {
+		t.Fatalf("expected empty merchant name for malformed payload, got %q", got)
+	}
+}
+
+func TestFormatRupiah(t *testing.T) {
+	cases := map[float64]string{
+		0:       "Rp 0",
+		999:     "Rp 999",
+		1000:    "Rp 1.000",
+		1500000: "Rp 1.500.000",
+	}
+	for in, want := range cases {
+		if got := FormatRupiah(in); got != want {
+			t.Fatalf("FormatRupiah(%v) = %q, want %q", in, got, want)
+		}
+	}
+}
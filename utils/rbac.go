@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"net/http"
+	"sort"
+)
+
+// Admin roles (models.Admin.Role)
+const (
+	AdminRoleSuperadmin = "superadmin"
+	AdminRoleFinance    = "finance"
+	AdminRoleSupport    = "support"
+	AdminRoleContent    = "content"
+
+	// adminRoleLegacy is the role every admin had before RBAC; treated as superadmin
+	adminRoleLegacy = "admin"
+)
+
+// Admin permissions, declared per route in routes/admins.go
+const (
+	PermDashboardView      = "dashboard.view"
+	PermUsersView          = "users.view"
+	PermUsersEdit          = "users.edit"
+	PermUsersBalance       = "users.balance"
+	PermUsersPassword      = "users.password"
+	PermInvestmentsView    = "investments.view"
+	PermInvestmentsEdit    = "investments.edit"
+	PermCatalogManage      = "catalog.manage"
+	PermWithdrawalsView    = "withdrawals.view"
+	PermWithdrawalsApprove = "withdrawals.approve"
+	PermDepositsView       = "deposits.view"
+	PermDepositsApprove    = "deposits.approve"
+	PermBanksManage        = "banks.manage"
+	PermBankAccountsView   = "bank_accounts.view"
+	PermBankAccountsReview = "bank_accounts.review"
+	PermTransactionsView   = "transactions.view"
+	PermRewardsManage      = "rewards.manage"
+	PermContentManage      = "content.manage"
+	PermSettingsView       = "settings.view"
+	PermSettingsEdit       = "settings.edit"
+	PermBinaryView         = "binary.view"
+	PermBinaryClaim        = "binary.claim"
+	PermAPIClientsManage   = "api_clients.manage"
+	PermAdminsManage       = "admins.manage"
+	PermAuditView          = "audit.view"
+	PermSecurityManage     = "security.manage"
+)
+
+// AdminRoleKey is the context key holding the authenticated admin's role
+const AdminRoleKey = contextKey("adminRole")
+
+var allAdminPermissions = []string{
+	PermDashboardView, PermUsersView, PermUsersEdit, PermUsersBalance, PermUsersPassword,
+	PermInvestmentsView, PermInvestmentsEdit, PermCatalogManage,
+	PermWithdrawalsView, PermWithdrawalsApprove, PermDepositsView, PermDepositsApprove,
+	PermBanksManage, PermBankAccountsView, PermBankAccountsReview, PermTransactionsView,
+	PermRewardsManage, PermContentManage, PermSettingsView, PermSettingsEdit,
+	PermBinaryView, PermBinaryClaim, PermAPIClientsManage, PermAdminsManage,
+	PermAuditView, PermSecurityManage,
+}
+
+var adminRolePermissions = map[string][]string{
+	AdminRoleSuperadmin: allAdminPermissions,
+	AdminRoleFinance: {
+		PermDashboardView, PermUsersView, PermUsersBalance, PermInvestmentsView,
+		PermWithdrawalsView, PermWithdrawalsApprove, PermDepositsView, PermDepositsApprove,
+		PermBanksManage, PermBankAccountsView, PermBankAccountsReview, PermTransactionsView,
+		PermRewardsManage, PermSettingsView, PermBinaryView, PermBinaryClaim,
+	},
+	AdminRoleSupport: {
+		PermDashboardView, PermUsersView, PermUsersEdit, PermUsersPassword, PermInvestmentsView,
+		PermWithdrawalsView, PermDepositsView, PermBankAccountsView, PermTransactionsView,
+		PermBinaryView,
+	},
+	AdminRoleContent: {
+		PermDashboardView, PermContentManage, PermCatalogManage,
+	},
+}
+
+// NormalizeAdminRole maps the legacy "admin" role to superadmin.
+func NormalizeAdminRole(role string) string {
+	if role == adminRoleLegacy {
+		return AdminRoleSuperadmin
+	}
+	return role
+}
+
+// IsValidAdminRole reports whether role is one of the RBAC roles.
+func IsValidAdminRole(role string) bool {
+	_, ok := adminRolePermissions[role]
+	return ok
+}
+
+// AdminRoles returns the role names in alphabetical order.
+func AdminRoles() []string {
+	roles := make([]string, 0, len(adminRolePermissions))
+	for role := range adminRolePermissions {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// AdminPermissions returns the permissions granted to role.
+func AdminPermissions(role string) []string {
+	perms := adminRolePermissions[NormalizeAdminRole(role)]
+	if perms == nil {
+		return []string{}
+	}
+	return perms
+}
+
+// AdminHasPermission reports whether role grants permission.
+func AdminHasPermission(role, permission string) bool {
+	for _, p := range AdminPermissions(role) {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAdminRole returns the authenticated admin's role (set by AdminAuthMiddleware)
+func GetAdminRole(r *http.Request) (string, bool) {
+	role, ok := r.Context().Value(AdminRoleKey).(string)
+	return role, ok
+}
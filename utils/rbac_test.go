@@ -0,0 +1,37 @@
+package utils
+
+import "testing"
+
+func TestAdminHasPermission(t *testing.T) {
+	cases := []struct {
+		role, perm string
+		want       bool
+	}{
+		{AdminRoleSuperadmin, PermAdminsManage, true},
+		{"admin", PermSettingsEdit, true}, // legacy role
+		{AdminRoleFinance, PermWithdrawalsApprove, true},
+		{AdminRoleFinance, PermSettingsEdit, false},
+		{AdminRoleSupport, PermUsersPassword, true},
+		{AdminRoleSupport, PermUsersBalance, false},
+		{AdminRoleContent, PermContentManage, true},
+		{AdminRoleContent, PermWithdrawalsView, false},
+		{"unknown", PermDashboardView, false},
+		{"", PermDashboardView, false},
+	}
+	for _, c := range cases {
+		if got := AdminHasPermission(c.role, c.perm); got != c.want {
+			t.Errorf("AdminHasPermission(%q, %q) = %v, want %v", c.role, c.perm, got, c.want)
+		}
+	}
+}
+
+func TestAdminRoles(t *testing.T) {
+	for _, role := range AdminRoles() {
+		if !IsValidAdminRole(role) {
+			t.Errorf("%s listed but not valid", role)
+		}
+	}
+	if IsValidAdminRole("admin") {
+		t.Error("legacy role should not be assignable")
+	}
+}
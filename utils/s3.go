@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"mime"
-	"os"
 	"path"
 	"strings"
 	"time"
@@ -18,13 +17,14 @@ import (
 
 // getS3Config returns AWS config for S3
 func getS3Config() (aws.Config, error) {
-	region := os.Getenv("S3_REGION")
+	s3cfg := AppConfig().S3
+	region := s3cfg.Region
 	if region == "" {
 		region = "ap-southeast-1" // default Singapore
 	}
 
-	accessKey := os.Getenv("S3_ACCESS_KEY")
-	secretKey := os.Getenv("S3_SECRET_KEY")
+	accessKey := s3cfg.AccessKey.Value()
+	secretKey := s3cfg.SecretKey.Value()
 
 	if accessKey == "" || secretKey == "" {
 		return aws.Config{}, fmt.Errorf("S3_ACCESS_KEY or S3_SECRET_KEY missing")
@@ -45,9 +45,9 @@ func getS3Config() (aws.Config, error) {
 
 // UploadToS3 uploads a file to AWS S3
 func UploadToS3(objectName string, file io.Reader, fileSize int64) error {
-	bucket := os.Getenv("S3_BUCKET")
+	bucket := AppConfig().S3.Bucket
 	if bucket == "" {
-		return fmt.Errorf("S3_BUCKET not set in environment")
+		return fmt.Errorf("S3_BUCKET not configured")
 	}
 
 	cfg, err := getS3Config()
@@ -77,9 +77,9 @@ func UploadToS3(objectName string, file io.Reader, fileSize int64) error {
 
 // GenerateSignedURL returns a presigned GET URL for the given object
 func GenerateSignedURL(objectName string, expirySeconds int64) (string, error) {
-	bucket := os.Getenv("S3_BUCKET")
+	bucket := AppConfig().S3.Bucket
 	if bucket == "" {
-		return "", fmt.Errorf("S3_BUCKET not set in environment")
+		return "", fmt.Errorf("S3_BUCKET not configured")
 	}
 
 	cfg, err := getS3Config()
@@ -124,9 +124,9 @@ func UploadToS3AndPresign(objectName string, file io.ReadSeeker, fileSize int64,
 
 // UploadToS3Server uploads a file to S3_BUCKET_SERVER and returns the full URL
 func UploadToS3Server(objectName string, file io.Reader, fileSize int64) (string, error) {
-	bucket := os.Getenv("S3_BUCKET_SERVER")
+	bucket := AppConfig().S3.BucketServer
 	if bucket == "" {
-		return "", fmt.Errorf("S3_BUCKET_SERVER not set in environment")
+		return "", fmt.Errorf("S3_BUCKET_SERVER not configured")
 	}
 
 	cfg, err := getS3Config()
@@ -152,13 +152,13 @@ func UploadToS3Server(objectName string, file io.Reader, fileSize int64) (string
 	}
 
 	// Construct public URL (assuming S3_BUCKET_SERVER is public or has CloudFront)
-	s3Region := os.Getenv("S3_REGION")
+	s3Region := AppConfig().S3.Region
 	if s3Region == "" {
 		s3Region = "ap-southeast-1"
 	}
 	// Format: https://bucket-name.s3.region.amazonaws.com/key
 	// Or if using custom domain, use S3_BASE_URL if available
-	baseURL := os.Getenv("S3_BASE_URL")
+	baseURL := AppConfig().S3.BaseURL
 	if baseURL != "" {
 		return fmt.Sprintf("%s/%s", strings.TrimSuffix(baseURL, "/"), objectName), nil
 	}
@@ -167,9 +167,9 @@ func UploadToS3Server(objectName string, file io.Reader, fileSize int64) (string
 
 // DeleteFromS3 deletes a file from S3_BUCKET_SERVER
 func DeleteFromS3Server(objectName string) error {
-	bucket := os.Getenv("S3_BUCKET_SERVER")
+	bucket := AppConfig().S3.BucketServer
 	if bucket == "" {
-		return fmt.Errorf("S3_BUCKET_SERVER not set in environment")
+		return fmt.Errorf("S3_BUCKET_SERVER not configured")
 	}
 
 	cfg, err := getS3Config()
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"log"
+	"net/http"
+
+	"project/models"
+
+	"gorm.io/gorm"
+)
+
+// Security event types
+const (
+	SecurityEventRefreshTokenReuse = "refresh_token_reuse"
+)
+
+// RecordSecurityEvent stores a security event for userID (0 when unknown). details is
+// marshalled to JSON. Failures are logged only.
+func RecordSecurityEvent(db *gorm.DB, r *http.Request, eventType string, userID uint, details interface{}) {
+	event := models.SecurityEvent{
+		Type:    eventType,
+		Details: auditJSON(details),
+	}
+	if userID != 0 {
+		event.UserID = &userID
+	}
+	if r != nil {
+		event.IP = GetClientIP(r)
+		event.UserAgent = requestUserAgent(r)
+	}
+	if err := db.Create(&event).Error; err != nil {
+		log.Printf("[security] %s user=%d: %v", eventType, userID, err)
+	}
+}
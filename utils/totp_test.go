@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B vectors (SHA1, truncated to 6 digits)
+func TestTOTPCodeRFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	cases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for ts, want := range cases {
+		got, err := TOTPCode(secret, time.Unix(ts, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("t=%d: got %s, want %s", ts, got, want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	code, _ := TOTPCode(secret, now)
+
+	step, ok := ValidateTOTP(secret, code, now, 0)
+	if !ok {
+		t.Fatal("current code rejected")
+	}
+	if _, ok := ValidateTOTP(secret, code, now, step); ok {
+		t.Error("replayed code accepted")
+	}
+	if _, ok := ValidateTOTP(secret, code, now.Add(30*time.Second), 0); !ok {
+		t.Error("previous period should be accepted")
+	}
+	if _, ok := ValidateTOTP(secret, code, now.Add(2*time.Minute), 0); ok {
+		t.Error("code from 4 periods ago accepted")
+	}
+	if _, ok := ValidateTOTP(secret, "12345", now, 0); ok {
+		t.Error("short code accepted")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 8 || len(codes[0]) != 11 {
+		t.Fatalf("unexpected codes %v", codes)
+	}
+	if HashRecoveryCode(codes[0]) != HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))) {
+		t.Error("hash should ignore case and dashes")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("Stoneform", "admin", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/Stoneform:admin?") || !strings.Contains(uri, "secret=ABC") {
+		t.Errorf("unexpected uri %s", uri)
+	}
+}
@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"project/models"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// TransactionPINHeader carries the transaction PIN for withdrawals, bank account
+// changes and password changes (see middleware.RequireTransactionPIN).
+const TransactionPINHeader = "X-Transaction-PIN"
+
+const transactionPINLength = 6
+
+var (
+	ErrPINFormat     = errors.New("PIN transaksi harus 6 digit angka")
+	ErrPINWeak       = errors.New("PIN transaksi terlalu mudah ditebak")
+	ErrPINMismatch   = errors.New("konfirmasi PIN transaksi tidak cocok")
+	ErrPINNotSet     = errors.New("PIN transaksi belum dibuat")
+	ErrPINAlreadySet = errors.New("PIN transaksi sudah dibuat")
+	ErrPINInvalid    = errors.New("PIN transaksi salah")
+)
+
+// ValidateTransactionPIN checks that pin is six digits and not a repeated or
+// consecutive sequence such as 111111 or 123456.
+func ValidateTransactionPIN(pin string) error {
+	if len(pin) != transactionPINLength {
+		return ErrPINFormat
+	}
+	for i := 0; i < len(pin); i++ {
+		if pin[i] < '0' || pin[i] > '9' {
+			return ErrPINFormat
+		}
+	}
+	same, up, down := true, true, true
+	for i := 1; i < len(pin); i++ {
+		d := int(pin[i]) - int(pin[i-1])
+		same = same && d == 0
+		up = up && d == 1
+		down = down && d == -1
+	}
+	if same || up || down {
+		return ErrPINWeak
+	}
+	return nil
+}
+
+// SetTransactionPIN validates pin and stores its bcrypt hash for userID.
+func SetTransactionPIN(db *gorm.DB, userID uint, pin string) error {
+	updates, err := transactionPINUpdates(pin)
+	if err != nil {
+		return err
+	}
+	return db.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error
+}
+
+// CreateTransactionPIN stores the first PIN for userID. The update only matches a user
+// without a PIN, so two concurrent requests cannot both set one; the loser gets
+// ErrPINAlreadySet.
+func CreateTransactionPIN(db *gorm.DB, userID uint, pin string) error {
+	updates, err := transactionPINUpdates(pin)
+	if err != nil {
+		return err
+	}
+	res := db.Model(&models.User{}).
+		Where("id = ? AND (transaction_pin IS NULL OR transaction_pin = '')", userID).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrPINAlreadySet
+	}
+	return nil
+}
+
+func transactionPINUpdates(pin string) (map[string]interface{}, error) {
+	if err := ValidateTransactionPIN(pin); err != nil {
+		return nil, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"transaction_pin": string(hash),
+		"pin_updated_at":  time.Now(),
+	}, nil
+}
+
+// HasTransactionPIN reports whether userID has set a PIN.
+func HasTransactionPIN(db *gorm.DB, userID uint) (bool, error) {
+	var user models.User
+	if err := db.Select("id", "transaction_pin").First(&user, userID).Error; err != nil {
+		return false, err
+	}
+	return user.TransactionPIN != nil && *user.TransactionPIN != "", nil
+}
+
+// VerifyTransactionPIN compares pin with the stored hash. It returns ErrPINNotSet when
+// the user has no PIN yet and ErrPINInvalid on a mismatch; lockout is up to the caller.
+func VerifyTransactionPIN(db *gorm.DB, userID uint, pin string) error {
+	var user models.User
+	if err := db.Select("id", "transaction_pin").First(&user, userID).Error; err != nil {
+		return err
+	}
+	if user.TransactionPIN == nil || *user.TransactionPIN == "" {
+		return ErrPINNotSet
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(*user.TransactionPIN), []byte(pin)); err != nil {
+		return ErrPINInvalid
+	}
+	return nil
+}
+
+// TransactionPINErrorStatus maps PIN errors to an HTTP status and user message.
+func TransactionPINErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrPINFormat), errors.Is(err, ErrPINWeak), errors.Is(err, ErrPINMismatch):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, ErrPINInvalid):
+		return http.StatusForbidden, err.Error()
+	case errors.Is(err, ErrPINNotSet), errors.Is(err, ErrPINAlreadySet):
+		return http.StatusConflict, err.Error()
+	default:
+		return http.StatusInternalServerError, "Terjadi kesalahan sistem, silakan coba lagi"
+	}
+}
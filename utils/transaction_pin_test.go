@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestValidateTransactionPIN(t *testing.T) {
+	cases := map[string]error{
+		"482913":  nil,
+		"000001":  nil,
+		"12345":   ErrPINFormat,
+		"1234567": ErrPINFormat,
+		"12a456":  ErrPINFormat,
+		"":        ErrPINFormat,
+		"111111":  ErrPINWeak,
+		"123456":  ErrPINWeak,
+		"987654":  ErrPINWeak,
+	}
+	for pin, want := range cases {
+		if err := ValidateTransactionPIN(pin); !errors.Is(err, want) {
+			t.Errorf("%q: got %v, want %v", pin, err, want)
+		}
+	}
+}
+
+func TestTransactionPINErrorStatus(t *testing.T) {
+	cases := map[error]int{
+		ErrPINWeak:               http.StatusBadRequest,
+		ErrPINMismatch:           http.StatusBadRequest,
+		ErrPINInvalid:            http.StatusForbidden,
+		ErrPINNotSet:             http.StatusConflict,
+		ErrPINAlreadySet:         http.StatusConflict,
+		errors.New("db is down"): http.StatusInternalServerError,
+	}
+	for err, want := range cases {
+		if got, _ := TransactionPINErrorStatus(err); got != want {
+			t.Errorf("%v: got %d, want %d", err, got, want)
+		}
+	}
+}
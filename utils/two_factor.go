@@ -0,0 +1,275 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"project/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Two-factor owners (models.TwoFactor.OwnerType)
+const (
+	TwoFactorOwnerAdmin = "admin"
+	TwoFactorOwnerUser  = "user"
+)
+
+// MFA challenge tokens are signed like access tokens but carry this role, so neither
+// AuthMiddleware nor AdminAuthMiddleware accepts them.
+const (
+	MFAChallengeRole  = "mfa"
+	MFAStageVerify    = "verify"
+	MFAStageEnroll    = "enroll"
+	mfaChallengeTTL   = 5 * time.Minute
+	recoveryCodeCount = 10
+	TwoFactorHeader   = "X-2FA-Code"
+)
+
+var (
+	ErrTwoFactorNotEnabled     = errors.New("autentikasi dua faktor belum aktif")
+	ErrTwoFactorAlreadyEnabled = errors.New("autentikasi dua faktor sudah aktif")
+	ErrInvalidTwoFactorCode    = errors.New("kode autentikasi tidak valid")
+	ErrInvalidMFAToken         = errors.New("sesi verifikasi tidak valid atau sudah kedaluwarsa")
+)
+
+// GetTwoFactor returns the enrolment of an owner, or nil when there is none.
+func GetTwoFactor(db *gorm.DB, ownerType string, ownerID uint) (*models.TwoFactor, error) {
+	var tf models.TwoFactor
+	res := db.Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).Limit(1).Find(&tf)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &tf, nil
+}
+
+// TwoFactorEnabled reports whether the owner has a confirmed enrolment.
+func TwoFactorEnabled(db *gorm.DB, ownerType string, ownerID uint) (bool, error) {
+	tf, err := GetTwoFactor(db, ownerType, ownerID)
+	if err != nil {
+		return false, err
+	}
+	return tf != nil && tf.Enabled, nil
+}
+
+// BeginTwoFactorSetup creates (or replaces) a pending secret for the owner.
+func BeginTwoFactorSetup(db *gorm.DB, ownerType string, ownerID uint) (*models.TwoFactor, error) {
+	tf, err := GetTwoFactor(db, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if tf != nil && tf.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil {
+		tf = &models.TwoFactor{OwnerType: ownerType, OwnerID: ownerID}
+	}
+	tf.Secret = secret
+	tf.LastUsedStep = 0
+	if err := db.Save(tf).Error; err != nil {
+		return nil, err
+	}
+	return tf, nil
+}
+
+// ConfirmTwoFactor enables a pending enrolment once code matches its secret and returns
+// freshly generated recovery codes.
+func ConfirmTwoFactor(db *gorm.DB, ownerType string, ownerID uint, code string) ([]string, error) {
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var tf models.TwoFactor
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).First(&tf).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTwoFactorNotEnabled
+			}
+			return err
+		}
+		if tf.Enabled {
+			return ErrTwoFactorAlreadyEnabled
+		}
+		step, ok := ValidateTOTP(tf.Secret, code, time.Now(), tf.LastUsedStep)
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		now := time.Now()
+		if err := tx.Model(&tf).Updates(map[string]interface{}{
+			"enabled": true, "enabled_at": now, "last_used_step": step,
+		}).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, tf.ID)
+		return err
+	})
+	return codes, err
+}
+
+// VerifyTwoFactor accepts either a current TOTP code or an unused recovery code.
+func VerifyTwoFactor(db *gorm.DB, ownerType string, ownerID uint, code string) error {
+	code = strings.TrimSpace(code)
+	return db.Transaction(func(tx *gorm.DB) error {
+		var tf models.TwoFactor
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("owner_type = ? AND owner_id = ? AND enabled = ?", ownerType, ownerID, true).First(&tf).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTwoFactorNotEnabled
+			}
+			return err
+		}
+		if step, ok := ValidateTOTP(tf.Secret, code, time.Now(), tf.LastUsedStep); ok {
+			return tx.Model(&tf).Update("last_used_step", step).Error
+		}
+		if code == "" {
+			return ErrInvalidTwoFactorCode
+		}
+		res := tx.Model(&models.TwoFactorRecoveryCode{}).
+			Where("two_factor_id = ? AND code_hash = ? AND used_at IS NULL", tf.ID, HashRecoveryCode(code)).
+			Limit(1).Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of an enabled enrolment.
+func RegenerateRecoveryCodes(db *gorm.DB, ownerType string, ownerID uint) ([]string, error) {
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		tf, err := GetTwoFactor(tx, ownerType, ownerID)
+		if err != nil {
+			return err
+		}
+		if tf == nil || !tf.Enabled {
+			return ErrTwoFactorNotEnabled
+		}
+		codes, err = replaceRecoveryCodes(tx, tf.ID)
+		return err
+	})
+	return codes, err
+}
+
+// RemainingRecoveryCodes counts unused recovery codes of an enrolment.
+func RemainingRecoveryCodes(db *gorm.DB, twoFactorID uint) int64 {
+	var n int64
+	db.Model(&models.TwoFactorRecoveryCode{}).Where("two_factor_id = ? AND used_at IS NULL", twoFactorID).Count(&n)
+	return n
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, twoFactorID uint) ([]string, error) {
+	if err := tx.Where("two_factor_id = ?", twoFactorID).Delete(&models.TwoFactorRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes, err := GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]models.TwoFactorRecoveryCode, 0, len(codes))
+	for _, c := range codes {
+		rows = append(rows, models.TwoFactorRecoveryCode{TwoFactorID: twoFactorID, CodeHash: HashRecoveryCode(c)})
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor removes the enrolment and its recovery codes.
+func DisableTwoFactor(db *gorm.DB, ownerType string, ownerID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		tf, err := GetTwoFactor(tx, ownerType, ownerID)
+		if err != nil || tf == nil {
+			return err
+		}
+		if err := tx.Where("two_factor_id = ?", tf.ID).Delete(&models.TwoFactorRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(tf).Error
+	})
+}
+
+// GenerateMFAChallengeToken issues the short-lived token returned by the first login
+// step. subject is TwoFactorOwnerAdmin or TwoFactorOwnerUser; stage is MFAStageVerify
+// or MFAStageEnroll (admins without an enrolment).
+func GenerateMFAChallengeToken(subject string, id uint, stage string) (string, error) {
+	jti, err := generateJTI(32)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"id":    id,
+		"role":  MFAChallengeRole,
+		"sub":   subject,
+		"stage": stage,
+		"exp":   now.Add(mfaChallengeTTL).Unix(),
+		"iat":   now.Unix(),
+		"nbf":   now.Unix(),
+		"jti":   jti,
+		"aud":   AppConfig().JWT.Audience,
+		"iss":   AppConfig().JWT.Issuer,
+	}
+	return signJWT(claims)
+}
+
+// MFAChallenge is a parsed challenge token.
+type MFAChallenge struct {
+	ID    uint
+	Stage string
+	JTI   string
+}
+
+// ParseMFAChallengeToken validates a challenge token issued for subject.
+func ParseMFAChallengeToken(tokenStr, subject string) (*MFAChallenge, error) {
+	_, claims, err := ValidateAccessToken(strings.TrimSpace(tokenStr))
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	if role, _ := claims["role"].(string); role != MFAChallengeRole {
+		return nil, ErrInvalidMFAToken
+	}
+	if sub, _ := claims["sub"].(string); sub != subject {
+		return nil, ErrInvalidMFAToken
+	}
+	id, ok := claims["id"].(float64)
+	if !ok || id <= 0 {
+		return nil, ErrInvalidMFAToken
+	}
+	stage, _ := claims["stage"].(string)
+	jti, _ := claims["jti"].(string)
+	return &MFAChallenge{ID: uint(id), Stage: stage, JTI: jti}, nil
+}
+
+// ConsumeMFAChallenge revokes a challenge token after a successful second step.
+func ConsumeMFAChallenge(c *MFAChallenge) {
+	if c.JTI != "" {
+		_ = RevokeJTI(c.JTI, mfaChallengeTTL)
+	}
+}
+
+// TwoFactorErrorStatus maps two-factor errors to an HTTP status and user message.
+func TwoFactorErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrInvalidTwoFactorCode), errors.Is(err, ErrInvalidMFAToken):
+		return http.StatusUnauthorized, err.Error()
+	case errors.Is(err, ErrTwoFactorNotEnabled), errors.Is(err, ErrTwoFactorAlreadyEnabled):
+		return http.StatusConflict, err.Error()
+	default:
+		return http.StatusInternalServerError, "Terjadi kesalahan sistem, silakan coba lagi"
+	}
+}
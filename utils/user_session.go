@@ -0,0 +1,208 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"project/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TokenJTIKey is the context key holding the jti of the user access token (set by AuthMiddleware)
+const TokenJTIKey = contextKey("tokenJTI")
+
+// DeviceNameHeader lets clients name the device when the request body has no device_name
+const DeviceNameHeader = "X-Device-Name"
+
+// DeviceFingerprintHeader carries a stable client-generated device identifier. Only its
+// hash is stored (see RecordUserDevice).
+const DeviceFingerprintHeader = "X-Device-Fingerprint"
+
+const userRefreshTTLDays = 7
+
+// SessionMeta describes the device a user session was issued to.
+type SessionMeta struct {
+	DeviceName  string
+	UserAgent   string
+	IP          string
+	Fingerprint string // hashed X-Device-Fingerprint, empty if not sent
+}
+
+// SessionMetaFromRequest reads the client IP, user agent and device name of r.
+// deviceName comes from the request body and falls back to the X-Device-Name header.
+// The device fingerprint is read from the X-Device-Fingerprint header.
+func SessionMetaFromRequest(r *http.Request, deviceName string) SessionMeta {
+	deviceName = strings.TrimSpace(deviceName)
+	if deviceName == "" {
+		deviceName = strings.TrimSpace(r.Header.Get(DeviceNameHeader))
+	}
+	if len(deviceName) > 100 {
+		deviceName = deviceName[:100]
+	}
+	return SessionMeta{
+		DeviceName:  deviceName,
+		UserAgent:   requestUserAgent(r),
+		IP:          GetClientIP(r),
+		Fingerprint: hashDeviceFingerprint(r.Header.Get(DeviceFingerprintHeader)),
+	}
+}
+
+// UserTokens is an access token plus the refresh token issued with it.
+type UserTokens struct {
+	AccessToken  string
+	AccessExpire time.Time
+	RefreshToken string
+	SessionID    string
+}
+
+// IssueUserTokens creates an access token and a refresh token bound to it. An empty
+// sessionID starts a new session (a new login); rotation passes the existing one.
+func IssueUserTokens(db *gorm.DB, userID uint, sessionID string, meta SessionMeta) (*UserTokens, error) {
+	if db == nil {
+		return nil, errors.New("database not initialized")
+	}
+	access, jti, exp, err := generateAccessToken(userID, "user")
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := generateJTI(48)
+	if err != nil {
+		return nil, err
+	}
+	if sessionID == "" {
+		if sessionID, err = generateJTI(32); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now()
+	rt := models.RefreshToken{
+		ID:         refresh,
+		UserID:     userID,
+		SessionID:  sessionID,
+		DeviceName: meta.DeviceName,
+		UserAgent:  meta.UserAgent,
+		IP:         meta.IP,
+		AccessJTI:  jti,
+		LastUsedAt: &now,
+		ExpiresAt:  now.Add(userRefreshTTLDays * 24 * time.Hour),
+		CreatedAt:  now,
+	}
+	if err := db.Create(&rt).Error; err != nil {
+		return nil, err
+	}
+	return &UserTokens{AccessToken: access, AccessExpire: exp, RefreshToken: refresh, SessionID: sessionID}, nil
+}
+
+// RevokeUserSessions revokes the matching refresh tokens of userID and blacklists the
+// access tokens issued with them. An empty sessionID revokes every session.
+func RevokeUserSessions(db *gorm.DB, userID uint, sessionID string) (int, error) {
+	query := db.Where("user_id = ? AND revoked = ?", userID, false)
+	if sessionID != "" {
+		query = query.Where("session_id = ?", sessionID)
+	}
+	var tokens []models.RefreshToken
+	if err := query.Find(&tokens).Error; err != nil {
+		return 0, err
+	}
+	if len(tokens) == 0 {
+		return 0, nil
+	}
+	ids := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		ids = append(ids, t.ID)
+	}
+	if err := db.Model(&models.RefreshToken{}).Where("id IN ?", ids).Update("revoked", true).Error; err != nil {
+		return 0, err
+	}
+	for _, t := range tokens {
+		if t.AccessJTI != "" {
+			_ = RevokeJTI(t.AccessJTI, UserAccessTTL)
+		}
+	}
+	return len(tokens), nil
+}
+
+// GetTokenJTI returns the jti of the authenticated user's access token.
+func GetTokenJTI(r *http.Request) string {
+	jti, _ := r.Context().Value(TokenJTIKey).(string)
+	return jti
+}
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+)
+
+// refreshTokenSessionID returns the session family of rt. Tokens issued before sessions
+// existed have none and get one derived from their own ID, the same on every call.
+func refreshTokenSessionID(rt models.RefreshToken) string {
+	if rt.SessionID != "" {
+		return rt.SessionID
+	}
+	return hashSessionToken(rt.ID)[:32]
+}
+
+// RotateRefreshToken exchanges a refresh token for a new pair in the same family.
+// Presenting a token that was already rotated means it was copied: the whole family
+// is revoked, a security event is recorded and ErrRefreshTokenReused is returned.
+func RotateRefreshToken(db *gorm.DB, token string, r *http.Request) (*UserTokens, error) {
+	var (
+		tokens *UserTokens
+		reused models.RefreshToken
+	)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var rt models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", token).First(&rt).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+		if rt.RotatedAt != nil {
+			reused = rt
+			return ErrRefreshTokenReused
+		}
+		if rt.Revoked || time.Now().After(rt.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		// A token issued before sessions existed starts its family now; the old row
+		// joins it so a later reuse of that token revokes the new family.
+		sessionID := refreshTokenSessionID(rt)
+		now := time.Now()
+		if err := tx.Model(&rt).Updates(map[string]interface{}{"revoked": true, "rotated_at": now, "session_id": sessionID}).Error; err != nil {
+			return err
+		}
+
+		meta := SessionMetaFromRequest(r, "")
+		if meta.DeviceName == "" {
+			meta.DeviceName = rt.DeviceName
+		}
+		var err error
+		if tokens, err = IssueUserTokens(tx, rt.UserID, sessionID, meta); err != nil {
+			return err
+		}
+		if rt.AccessJTI != "" {
+			_ = RevokeJTI(rt.AccessJTI, UserAccessTTL)
+		}
+		return nil
+	})
+
+	if errors.Is(err, ErrRefreshTokenReused) {
+		// legacy rows rotated before the session ID was stored on them
+		sessionID := refreshTokenSessionID(reused)
+		revoked, _ := RevokeUserSessions(db, reused.UserID, sessionID)
+		RecordSecurityEvent(db, r, SecurityEventRefreshTokenReuse, reused.UserID, map[string]interface{}{
+			"session_id":      sessionID,
+			"rotated_at":      reused.RotatedAt,
+			"tokens_revoked":  revoked,
+			"original_ip":     reused.IP,
+			"original_device": reused.DeviceName,
+		})
+	}
+	return tokens, err
+}
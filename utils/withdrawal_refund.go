@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"fmt"
+
+	"project/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WithdrawalRefundOrderID is the order ID of the compensating transaction written when
+// a withdrawal fails. The unique index on transactions.order_id makes the refund
+// impossible to book twice.
+func WithdrawalRefundOrderID(orderID string) string {
+	return "RF-" + orderID
+}
+
+// FailWithdrawal marks a withdrawal whose payout failed as Failed and refunds the full
+// amount to the user's income with a "refund" transaction, all inside tx. It is
+// idempotent: a withdrawal that is already Failed returns refunded=false and no error.
+// Requested withdrawals paid out directly by a partner pass through Processing first.
+func FailWithdrawal(tx *gorm.DB, w *models.Withdrawal, actor, note string) (refunded bool, err error) {
+	var current models.Withdrawal
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, w.ID).Error; err != nil {
+		return false, err
+	}
+	w.Status = current.Status
+	if w.Status == WithdrawalFailed {
+		return false, nil
+	}
+
+	if w.Status == WithdrawalRequested {
+		if err := TransitionWithdrawal(tx, w, WithdrawalProcessing, actor, nil, ""); err != nil {
+			return false, err
+		}
+	}
+	if err := TransitionWithdrawal(tx, w, WithdrawalFailed, actor, nil, note); err != nil {
+		return false, err
+	}
+
+	if err := tx.Model(&models.User{}).Where("id = ?", w.UserID).
+		Update("income", gorm.Expr("income + ?", w.Amount)).Error; err != nil {
+		return false, err
+	}
+
+	msg := fmt.Sprintf("Pengembalian dana penarikan gagal %s", w.OrderID)
+	if err := tx.Create(&models.Transaction{
+		UserID:          w.UserID,
+		Amount:          w.Amount,
+		Charge:          0,
+		OrderID:         WithdrawalRefundOrderID(w.OrderID),
+		TransactionFlow: "debit",
+		TransactionType: "refund",
+		Message:         &msg,
+		Status:          "Success",
+	}).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"project/models"
+
+	"gorm.io/gorm"
+)
+
+// Risk rule codes stored in withdrawals.risk_reasons
+const (
+	RiskNewAccount          = "new_account"
+	RiskNoDeposit           = "no_deposit"
+	RiskWithdrawOverDeposit = "withdraw_over_deposit"
+	RiskRecentBankChange    = "recent_bank_change"
+	RiskSharedBankAccount   = "shared_bank_account"
+	RiskBonusOnlyIncome     = "bonus_only_income"
+	RiskBankNameMismatch    = "bank_name_mismatch"
+)
+
+// RiskReason is one triggered rule with its contribution to the score.
+type RiskReason struct {
+	Code    string `json:"code"`
+	Score   int    `json:"score"`
+	Message string `json:"message"`
+}
+
+// WithdrawalRisk is the outcome of scoring a withdrawal request.
+type WithdrawalRisk struct {
+	Score   int          `json:"score"`
+	Hold    bool         `json:"hold"`
+	Reasons []RiskReason `json:"reasons"`
+}
+
+// WithdrawalRiskInput holds the signals the rules are evaluated on.
+type WithdrawalRiskInput struct {
+	AccountAge       time.Duration
+	TotalDeposits    float64
+	TotalWithdrawals float64 // including the request being scored
+	BankChangedAgo   *time.Duration
+	SharedWithUsers  int
+	BonusIncome      float64
+	ReturnIncome     float64
+	NameMismatch     bool
+}
+
+// WithdrawalRiskConfig holds the rule thresholds, read from the RISK_* settings.
+type WithdrawalRiskConfig struct {
+	MinAccountAge      time.Duration
+	MaxDepositRatio    float64
+	BankChangeCooldown time.Duration
+	HoldScore          int
+}
+
+// LoadWithdrawalRiskConfig reads the thresholds from the RISK_* settings.
+func LoadWithdrawalRiskConfig() WithdrawalRiskConfig {
+	risk := AppConfig().Risk
+	return WithdrawalRiskConfig{
+		MinAccountAge:      time.Duration(risk.MinAccountAgeHours * float64(time.Hour)),
+		MaxDepositRatio:    risk.MaxWithdrawDepositRatio,
+		BankChangeCooldown: time.Duration(risk.BankChangeHours * float64(time.Hour)),
+		HoldScore:          risk.HoldScore,
+	}
+}
+
+// ScoreWithdrawalRisk applies the rules to the collected signals.
+func ScoreWithdrawalRisk(in WithdrawalRiskInput, cfg WithdrawalRiskConfig) WithdrawalRisk {
+	risk := WithdrawalRisk{Reasons: []RiskReason{}}
+	add := func(code string, score int, msg string) {
+		risk.Reasons = append(risk.Reasons, RiskReason{Code: code, Score: score, Message: msg})
+		risk.Score += score
+	}
+
+	if in.AccountAge < cfg.MinAccountAge {
+		add(RiskNewAccount, 30, fmt.Sprintf("Akun baru berumur %.0f jam", in.AccountAge.Hours()))
+	}
+	if in.TotalDeposits <= 0 {
+		add(RiskNoDeposit, 40, "Belum pernah melakukan deposit")
+	} else if in.TotalWithdrawals > in.TotalDeposits*cfg.MaxDepositRatio {
+		add(RiskWithdrawOverDeposit, 30, fmt.Sprintf("Total penarikan %s melebihi %.0fx total deposit %s",
+			FormatRupiah(in.TotalWithdrawals), cfg.MaxDepositRatio, FormatRupiah(in.TotalDeposits)))
+	}
+	if in.BankChangedAgo != nil && *in.BankChangedAgo < cfg.BankChangeCooldown {
+		add(RiskRecentBankChange, 30, fmt.Sprintf("Rekening tujuan ditambahkan/diubah %.0f jam lalu", in.BankChangedAgo.Hours()))
+	}
+	if in.SharedWithUsers > 0 {
+		add(RiskSharedBankAccount, 50, fmt.Sprintf("Rekening tujuan juga terdaftar di %d akun lain", in.SharedWithUsers))
+	}
+	if in.BonusIncome > 0 && in.ReturnIncome <= 0 {
+		add(RiskBonusOnlyIncome, 40, "Penghasilan hanya berasal dari bonus")
+	}
+	if in.NameMismatch {
+		add(RiskBankNameMismatch, 30, "Nama pemilik rekening belum sesuai dan menunggu peninjauan")
+	}
+
+	risk.Hold = risk.Score >= cfg.HoldScore
+	return risk
+}
+
+// CollectWithdrawalRiskInput gathers the rule signals for a withdrawal of amount from
+// userID to acc.
+func CollectWithdrawalRiskInput(db *gorm.DB, userID uint, acc models.BankAccount, amount float64) (WithdrawalRiskInput, error) {
+	var in WithdrawalRiskInput
+
+	var user models.User
+	if err := db.Select("id", "created_at").First(&user, userID).Error; err != nil {
+		return in, err
+	}
+	in.AccountAge = time.Since(user.CreatedAt)
+
+	if err := db.Model(&models.Deposit{}).
+		Where("user_id = ? AND status = ?", userID, "Success").
+		Select("COALESCE(SUM(amount),0)").Scan(&in.TotalDeposits).Error; err != nil {
+		return in, err
+	}
+
+	if err := db.Model(&models.Withdrawal{}).
+		Where("user_id = ? AND status NOT IN ?", userID, []string{WithdrawalRejected, WithdrawalCancelled, WithdrawalFailed}).
+		Select("COALESCE(SUM(amount),0)").Scan(&in.TotalWithdrawals).Error; err != nil {
+		return in, err
+	}
+	in.TotalWithdrawals += amount
+
+	if acc.ChangedAt != nil {
+		ago := time.Since(*acc.ChangedAt)
+		in.BankChangedAgo = &ago
+	}
+
+	var shared int64
+	if err := db.Model(&models.BankAccount{}).
+		Where("bank_id = ? AND account_number = ? AND user_id <> ?", acc.BankID, acc.AccountNumber, userID).
+		Distinct("user_id").Count(&shared).Error; err != nil {
+		return in, err
+	}
+	in.SharedWithUsers = int(shared)
+
+	// Income credits ("debit" flow): returns come from investments, the rest are bonuses
+	if err := db.Model(&models.Transaction{}).
+		Where("user_id = ? AND transaction_flow = ? AND status = ? AND transaction_type IN ?", userID, "debit", "Success", []string{"bonus", "team", "investment"}).
+		Select("COALESCE(SUM(amount),0)").Scan(&in.BonusIncome).Error; err != nil {
+		return in, err
+	}
+	if err := db.Model(&models.Transaction{}).
+		Where("user_id = ? AND transaction_flow = ? AND status = ? AND transaction_type = ?", userID, "debit", "Success", "return").
+		Select("COALESCE(SUM(amount),0)").Scan(&in.ReturnIncome).Error; err != nil {
+		return in, err
+	}
+
+	in.NameMismatch = acc.ReviewStatus == BankReviewPending
+	return in, nil
+}
+
+// EncodeRiskReasons serialises reasons for withdrawals.risk_reasons.
+func EncodeRiskReasons(reasons []RiskReason) *string {
+	if len(reasons) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(reasons)
+	if err != nil {
+		return nil
+	}
+	s := string(b)
+	return &s
+}
+
+// DecodeRiskReasons parses withdrawals.risk_reasons; invalid or empty values yield nil.
+func DecodeRiskReasons(raw *string) []RiskReason {
+	if raw == nil || *raw == "" {
+		return nil
+	}
+	var reasons []RiskReason
+	if err := json.Unmarshal([]byte(*raw), &reasons); err != nil {
+		return nil
+	}
+	return reasons
+}
+
+// RiskReasonSummary joins reason codes for the status history note.
+func RiskReasonSummary(reasons []RiskReason) string {
+	codes := make([]string, 0, len(reasons))
+	for _, r := range reasons {
+		codes = append(codes, r.Code)
+	}
+	return truncate("risk: "+strings.Join(codes, ", "), 255)
+}
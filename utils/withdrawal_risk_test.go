@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScoreWithdrawalRisk(t *testing.T) {
+	cfg := WithdrawalRiskConfig{
+		MinAccountAge:      72 * time.Hour,
+		MaxDepositRatio:    3,
+		BankChangeCooldown: 24 * time.Hour,
+		HoldScore:          50,
+	}
+	recent := 2 * time.Hour
+
+	cases := []struct {
+		name  string
+		in    WithdrawalRiskInput
+		score int
+		hold  bool
+	}{
+		{"established", WithdrawalRiskInput{AccountAge: 30 * 24 * time.Hour, TotalDeposits: 1000000, TotalWithdrawals: 500000, ReturnIncome: 200000}, 0, false},
+		{"new account", WithdrawalRiskInput{AccountAge: time.Hour, TotalDeposits: 1000000, TotalWithdrawals: 100000}, 30, false},
+		{"no deposit bonus only", WithdrawalRiskInput{AccountAge: 30 * 24 * time.Hour, TotalWithdrawals: 50000, BonusIncome: 50000}, 80, true},
+		{"over deposit ratio", WithdrawalRiskInput{AccountAge: 30 * 24 * time.Hour, TotalDeposits: 100000, TotalWithdrawals: 400000, ReturnIncome: 1}, 30, false},
+		{"recent bank change shared", WithdrawalRiskInput{AccountAge: 30 * 24 * time.Hour, TotalDeposits: 100000, TotalWithdrawals: 100000, BankChangedAgo: &recent, SharedWithUsers: 2}, 80, true},
+		{"name mismatch", WithdrawalRiskInput{AccountAge: 30 * 24 * time.Hour, TotalDeposits: 100000, TotalWithdrawals: 100000, NameMismatch: true}, 30, false},
+	}
+	for _, c := range cases {
+		got := ScoreWithdrawalRisk(c.in, cfg)
+		if got.Score != c.score || got.Hold != c.hold {
+			t.Errorf("%s: score=%d hold=%v, want score=%d hold=%v (%+v)", c.name, got.Score, got.Hold, c.score, c.hold, got.Reasons)
+		}
+	}
+}
+
+func TestRiskReasonsRoundTrip(t *testing.T) {
+	reasons := []RiskReason{{Code: RiskNoDeposit, Score: 40, Message: "x"}}
+	got := DecodeRiskReasons(EncodeRiskReasons(reasons))
+	if len(got) != 1 || got[0].Code != RiskNoDeposit || got[0].Score != 40 {
+		t.Fatalf("round trip = %+v", got)
+	}
+	if EncodeRiskReasons(nil) != nil {
+		t.Fatal("empty reasons should encode to nil")
+	}
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	"project/models"
+
+	"gorm.io/gorm"
+)
+
+// Withdrawal states
+const (
+	WithdrawalRequested  = "Requested"
+	WithdrawalHold       = "Hold"
+	WithdrawalApproved   = "Approved"
+	WithdrawalProcessing = "Processing"
+	WithdrawalSuccess    = "Success"
+	WithdrawalFailed     = "Failed"
+	WithdrawalRejected   = "Rejected"
+	WithdrawalCancelled  = "Cancelled"
+)
+
+// Actors recorded in withdrawal_status_history
+const (
+	WithdrawalActorUser   = "user"
+	WithdrawalActorAdmin  = "admin"
+	WithdrawalActorSystem = "system"
+	WithdrawalActorLinkQu = "linkqu"
+	WithdrawalActorSFXCR  = "sfxcr"
+	WithdrawalActorKyta   = "kyta"
+)
+
+var (
+	ErrInvalidWithdrawalTransition = errors.New("perubahan status penarikan tidak diizinkan")
+	ErrWithdrawalStatusChanged     = errors.New("status penarikan sudah berubah")
+)
+
+// withdrawalTransitions lists the allowed next states of every state. Hold is set by
+// the risk rules and goes back to Requested only through ReleaseHeldWithdrawal (an
+// admin); Processing returns to Requested only through ReturnWithdrawalForReview.
+// Neither is a generic transition, so callbacks cannot trigger them.
+var withdrawalTransitions = map[string][]string{
+	WithdrawalRequested:  {WithdrawalHold, WithdrawalApproved, WithdrawalProcessing, WithdrawalRejected, WithdrawalCancelled},
+	WithdrawalHold:       {WithdrawalApproved, WithdrawalRejected, WithdrawalCancelled},
+	WithdrawalApproved:   {WithdrawalProcessing, WithdrawalSuccess, WithdrawalFailed},
+	WithdrawalProcessing: {WithdrawalSuccess, WithdrawalFailed},
+}
+
+var withdrawalStatusLabels = map[string]string{
+	WithdrawalRequested:  "Menunggu Persetujuan",
+	WithdrawalHold:       "Sedang Ditinjau",
+	WithdrawalApproved:   "Disetujui",
+	WithdrawalProcessing: "Sedang Diproses",
+	WithdrawalSuccess:    "Berhasil",
+	WithdrawalFailed:     "Gagal",
+	WithdrawalRejected:   "Ditolak",
+	WithdrawalCancelled:  "Dibatalkan",
+}
+
+// CanTransitionWithdrawal reports whether a withdrawal may move from one state to another.
+func CanTransitionWithdrawal(from, to string) bool {
+	for _, next := range withdrawalTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinalWithdrawalStatus reports whether no further transition is possible.
+func IsFinalWithdrawalStatus(status string) bool {
+	return len(withdrawalTransitions[status]) == 0
+}
+
+// WithdrawalStatusLabel returns the user-facing label of a status.
+func WithdrawalStatusLabel(status string) string {
+	if label, ok := withdrawalStatusLabels[status]; ok {
+		return label
+	}
+	return status
+}
+
+// NormalizeWithdrawalStatus maps the legacy "Pending" filter value to Requested.
+func NormalizeWithdrawalStatus(status string) string {
+	if status == "Pending" {
+		return WithdrawalRequested
+	}
+	return status
+}
+
+// withdrawalTransactionStatus maps a withdrawal state to the related transaction status.
+func withdrawalTransactionStatus(status string) string {
+	switch status {
+	case WithdrawalSuccess:
+		return "Success"
+	case WithdrawalCancelled:
+		return "Cancelled"
+	case WithdrawalFailed, WithdrawalRejected:
+		return "Failed"
+	default:
+		return "Pending"
+	}
+}
+
+// RecordWithdrawalRequested writes the initial history row of a newly created withdrawal.
+func RecordWithdrawalRequested(tx *gorm.DB, w *models.Withdrawal) error {
+	userID := w.UserID
+	return tx.Create(&models.WithdrawalStatusHistory{
+		WithdrawalID: w.ID,
+		ToStatus:     WithdrawalRequested,
+		Actor:        WithdrawalActorUser,
+		ActorID:      &userID,
+	}).Error
+}
+
+// TransitionWithdrawal moves w to the given state inside tx. The update is conditional
+// on the current status so concurrent changes fail with ErrWithdrawalStatusChanged.
+// It writes a withdrawal_status_history row and keeps the related transaction status
+// in sync. Balance refunds remain the caller's responsibility.
+func TransitionWithdrawal(tx *gorm.DB, w *models.Withdrawal, to, actor string, actorID *uint, note string) error {
+	if !CanTransitionWithdrawal(w.Status, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidWithdrawalTransition, w.Status, to)
+	}
+	return applyWithdrawalTransition(tx, w, to, actor, actorID, note)
+}
+
+// ReleaseHeldWithdrawal moves a Hold withdrawal back to Requested on behalf of an admin
+// who reviewed the risk reasons.
+func ReleaseHeldWithdrawal(tx *gorm.DB, w *models.Withdrawal, adminID uint, note string) error {
+	if w.Status != WithdrawalHold {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidWithdrawalTransition, w.Status, WithdrawalRequested)
+	}
+	return applyWithdrawalTransition(tx, w, WithdrawalRequested, WithdrawalActorAdmin, &adminID, note)
+}
+
+// ReturnWithdrawalForReview moves a Processing withdrawal back to Requested so an admin
+// can approve or reject it again. It is allowed only once its payout job is Failed,
+// i.e. the transfer was never sent or LinkQu rejected it, so approving again cannot
+// pay the user twice.
+func ReturnWithdrawalForReview(tx *gorm.DB, w *models.Withdrawal, note string) error {
+	if w.Status != WithdrawalProcessing {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidWithdrawalTransition, w.Status, WithdrawalRequested)
+	}
+	var job models.PayoutJob
+	if err := tx.Where("withdrawal_id = ?", w.ID).First(&job).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if job.Status != PayoutJobFailed {
+		return fmt.Errorf("%w: %s -> %s, payout belum dipastikan gagal", ErrInvalidWithdrawalTransition, w.Status, WithdrawalRequested)
+	}
+	return applyWithdrawalTransition(tx, w, WithdrawalRequested, WithdrawalActorSystem, nil, note)
+}
+
+func applyWithdrawalTransition(tx *gorm.DB, w *models.Withdrawal, to, actor string, actorID *uint, note string) error {
+	from := w.Status
+	res := tx.Model(&models.Withdrawal{}).Where("id = ? AND status = ?", w.ID, from).Update("status", to)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrWithdrawalStatusChanged
+	}
+
+	history := models.WithdrawalStatusHistory{
+		WithdrawalID: w.ID,
+		FromStatus:   from,
+		ToStatus:     to,
+		Actor:        actor,
+		ActorID:      actorID,
+		Note:         note,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return err
+	}
+
+	if txStatus := withdrawalTransactionStatus(to); txStatus != withdrawalTransactionStatus(from) {
+		if err := tx.Model(&models.Transaction{}).
+			Where("order_id = ? AND transaction_type = ?", w.OrderID, "withdrawal").
+			Update("status", txStatus).Error; err != nil {
+			return err
+		}
+	}
+
+	w.Status = to
+	return nil
+}
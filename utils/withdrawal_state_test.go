@@ -0,0 +1,44 @@
+package utils
+
+import "testing"
+
+func TestCanTransitionWithdrawal(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     bool
+	}{
+		{WithdrawalRequested, WithdrawalApproved, true},
+		{WithdrawalRequested, WithdrawalRejected, true},
+		{WithdrawalRequested, WithdrawalCancelled, true},
+		{WithdrawalRequested, WithdrawalHold, true},
+		{WithdrawalHold, WithdrawalRequested, false},
+		{WithdrawalHold, WithdrawalApproved, true},
+		{WithdrawalHold, WithdrawalProcessing, false},
+		{WithdrawalApproved, WithdrawalProcessing, true},
+		{WithdrawalProcessing, WithdrawalSuccess, true},
+		{WithdrawalProcessing, WithdrawalRequested, false},
+		{WithdrawalApproved, WithdrawalApproved, false},
+		{WithdrawalProcessing, WithdrawalCancelled, false},
+		{WithdrawalSuccess, WithdrawalFailed, false},
+		{WithdrawalRejected, WithdrawalApproved, false},
+		{"Pending", WithdrawalApproved, false},
+	}
+	for _, c := range cases {
+		if got := CanTransitionWithdrawal(c.from, c.to); got != c.want {
+			t.Errorf("CanTransitionWithdrawal(%s, %s) = %v, want %v", c.from, c.to, got, c.want)
+		}
+	}
+}
+
+func TestIsFinalWithdrawalStatus(t *testing.T) {
+	for _, s := range []string{WithdrawalSuccess, WithdrawalFailed, WithdrawalRejected, WithdrawalCancelled} {
+		if !IsFinalWithdrawalStatus(s) {
+			t.Errorf("%s should be final", s)
+		}
+	}
+	for _, s := range []string{WithdrawalRequested, WithdrawalApproved, WithdrawalProcessing} {
+		if IsFinalWithdrawalStatus(s) {
+			t.Errorf("%s should not be final", s)
+		}
+	}
+}